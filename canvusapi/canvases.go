@@ -0,0 +1,208 @@
+package canvusapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultCanvasName is the name given to the single canvas configured by CANVAS_ID
+const DefaultCanvasName = "default"
+
+// canvasNamePattern restricts names to characters that are safe in URL paths
+var canvasNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// TLSOptions configures how a client verifies an MCS server's certificate
+type TLSOptions struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots (e.g. an on-prem CA)
+	CAFile string `json:"ca_file,omitempty"`
+	// InsecureSkipVerify disables certificate verification. Only for test servers.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// Canvas maps a logical name to the server, canvas and credentials used to reach it.
+// Empty Server, APIKey and TLS fields fall back to the registry's Defaults.
+type Canvas struct {
+	Name     string `json:"name"`
+	CanvasID string `json:"canvas_id"`
+	Server   string `json:"server,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	// APIKeyEnv names an environment variable holding the key, keeping secrets out of the file
+	APIKeyEnv string      `json:"api_key_env,omitempty"`
+	TLS       *TLSOptions `json:"tls,omitempty"`
+	// PromptsDir holds prompt templates used on this canvas only
+	PromptsDir string `json:"prompts_dir,omitempty"`
+	// Language of this canvas's helper notes and persona answers, or "auto"
+	Language string `json:"language,omitempty"`
+}
+
+// Defaults are the connection settings for canvases that do not set their own
+type Defaults struct {
+	Server string
+	APIKey string
+	TLS    TLSOptions
+}
+
+// Registry maps logical canvas names to their connection settings
+type Registry struct {
+	canvases []Canvas
+	byName   map[string]Canvas
+	defaults Defaults
+}
+
+// NewRegistry validates canvases, filling in default names, and indexes them by name
+func NewRegistry(canvases []Canvas) (*Registry, error) {
+	if len(canvases) == 0 {
+		return nil, fmt.Errorf("no canvases configured")
+	}
+	r := &Registry{byName: make(map[string]Canvas)}
+	for i, c := range canvases {
+		if c.CanvasID == "" {
+			return nil, fmt.Errorf("canvas %d (%q) has no canvas_id", i+1, c.Name)
+		}
+		if c.Name == "" {
+			c.Name = c.CanvasID
+		}
+		if !canvasNamePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("canvas name %q may only contain letters, digits, '-' and '_'", c.Name)
+		}
+		if _, dup := r.byName[c.Name]; dup {
+			return nil, fmt.Errorf("duplicate canvas name %q", c.Name)
+		}
+		r.byName[c.Name] = c
+		r.canvases = append(r.canvases, c)
+	}
+	return r, nil
+}
+
+// LoadRegistry reads a JSON list of canvases from path
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read canvases file: %w", err)
+	}
+	var canvases []Canvas
+	if err := json.Unmarshal(data, &canvases); err != nil {
+		return nil, fmt.Errorf("failed to parse canvases file %s: %w", path, err)
+	}
+	return NewRegistry(canvases)
+}
+
+// ParseCanvasList parses a comma-separated list of "name=canvasID" or bare canvas IDs
+func ParseCanvasList(list string) (*Registry, error) {
+	var canvases []Canvas
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		c := Canvas{CanvasID: entry}
+		if name, id, ok := strings.Cut(entry, "="); ok {
+			c = Canvas{Name: strings.TrimSpace(name), CanvasID: strings.TrimSpace(id)}
+		}
+		canvases = append(canvases, c)
+	}
+	return NewRegistry(canvases)
+}
+
+// SetDefaults sets the server, key and TLS options used by canvases that do not set their own
+func (r *Registry) SetDefaults(d Defaults) {
+	r.defaults = d
+}
+
+// Canvases returns the registered canvases in configuration order
+func (r *Registry) Canvases() []Canvas {
+	return append([]Canvas(nil), r.canvases...)
+}
+
+// Lookup returns the canvas registered under name
+func (r *Registry) Lookup(name string) (Canvas, bool) {
+	c, ok := r.byName[name]
+	return c, ok
+}
+
+// NewClient creates a client for the canvas registered under name
+func (r *Registry) NewClient(name string) (*Client, error) {
+	c, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown canvas %q", name)
+	}
+	return NewClientForCanvas(c, r.defaults)
+}
+
+// NewClients creates one client per registered canvas
+func (r *Registry) NewClients() ([]*Client, error) {
+	clients := make([]*Client, 0, len(r.canvases))
+	for _, c := range r.canvases {
+		client, err := NewClientForCanvas(c, r.defaults)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// NewClientForCanvas creates a client for c, using d for any unset connection fields
+func NewClientForCanvas(c Canvas, d Defaults) (*Client, error) {
+	server := c.Server
+	if server == "" {
+		server = d.Server
+	}
+	apiKey := c.APIKey
+	if apiKey == "" && c.APIKeyEnv != "" {
+		apiKey = os.Getenv(c.APIKeyEnv)
+	}
+	if apiKey == "" {
+		apiKey = d.APIKey
+	}
+	if server == "" || apiKey == "" {
+		return nil, fmt.Errorf("canvas %s: missing server or API key", c.Name)
+	}
+
+	tlsOpts := c.TLS
+	if tlsOpts == nil {
+		tlsOpts = &d.TLS
+	}
+	transport, err := newTransport(*tlsOpts)
+	if err != nil {
+		return nil, fmt.Errorf("canvas %s: %w", c.Name, err)
+	}
+
+	client := NewClient(server, c.CanvasID, apiKey)
+	client.Name = c.Name
+	if transport != nil {
+		client.HTTP.Transport = transport
+	}
+	return client, nil
+}
+
+// newTransport returns a transport honouring opts, or nil if the default transport will do
+func newTransport(opts TLSOptions) (*http.Transport, error) {
+	if opts.CAFile == "" && !opts.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
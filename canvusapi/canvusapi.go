@@ -34,6 +34,7 @@ const (
 
 // Core types and interfaces at the top
 type Client struct {
+	Name     string // logical canvas name used in URLs and logs (defaults to CanvasID)
 	Server   string
 	CanvasID string
 	ApiKey   string
@@ -64,6 +65,7 @@ func (e *APIError) IsRetryable() bool {
 // Core client methods
 func NewClient(server, canvasID, apiKey string) *Client {
 	return &Client{
+		Name:     canvasID,
 		Server:   server,
 		CanvasID: canvasID,
 		ApiKey:   apiKey,
@@ -121,7 +123,17 @@ func (c *Client) Request(method, endpoint string, payload interface{}, out inter
 			reqURL += "?subscribe=true"
 		}
 	}
+	return c.send(method, reqURL, endpoint, payload, out)
+}
+
+// ServerRequest calls a server-level endpoint (e.g. "/canvases") that is not scoped to the client's canvas
+func (c *Client) ServerRequest(method, endpoint string, payload interface{}, out interface{}) error {
+	reqURL := fmt.Sprintf("%s/api/v1%s", strings.TrimRight(c.Server, "/"), endpoint)
+	return c.send(method, reqURL, endpoint, payload, out)
+}
 
+// send performs a request with retries on transient failures, decoding the JSON response into out
+func (c *Client) send(method, reqURL, endpoint string, payload interface{}, out interface{}) error {
 	var jsonData []byte
 	var err error
 	if payload != nil {
@@ -296,7 +308,7 @@ func (c *Client) uploadFile(endpoint, filePath string, metadata map[string]inter
 	timer := timing.Start(fmt.Sprintf("canvus_api_upload_%s", endpoint))
 
 	// Use a client with longer timeout for uploads
-	uploadClient := &http.Client{Timeout: UploadHTTPTimeout}
+	uploadClient := &http.Client{Timeout: UploadHTTPTimeout, Transport: c.HTTP.Transport}
 	resp, err := uploadClient.Do(req)
 	if err != nil {
 		timer.StopAndLog(false)
@@ -0,0 +1,121 @@
+package canvusapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CanvasInfo describes a canvas as listed by the server
+type CanvasInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	FolderID string `json:"folder_id"`
+	State    string `json:"state"`
+	Mode     string `json:"mode"`
+}
+
+// FolderInfo describes a canvas folder as listed by the server
+type FolderInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	FolderID string `json:"folder_id"` // parent folder, empty for the root
+}
+
+// ListCanvases returns every canvas the client's API key can see on its server
+func (c *Client) ListCanvases() ([]CanvasInfo, error) {
+	var canvases []CanvasInfo
+	err := c.ServerRequest("GET", "/canvases", nil, &canvases)
+	return canvases, err
+}
+
+// ListFolders returns every canvas folder the client's API key can see on its server
+func (c *Client) ListFolders() ([]FolderInfo, error) {
+	var folders []FolderInfo
+	err := c.ServerRequest("GET", "/canvas-folders", nil, &folders)
+	return folders, err
+}
+
+// FolderPath returns the slash-separated path of folderID, e.g. "/Workshops/2024"
+func FolderPath(folders []FolderInfo, folderID string) string {
+	byID := make(map[string]FolderInfo, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+	var parts []string
+	seen := make(map[string]bool)
+	for id := folderID; id != "" && !seen[id]; {
+		seen[id] = true
+		f, ok := byID[id]
+		if !ok {
+			break
+		}
+		// The root folder has no parent and is shown as "/"
+		if f.FolderID != "" {
+			parts = append([]string{f.Name}, parts...)
+		}
+		id = f.FolderID
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// FindCanvas returns the canvas whose ID matches query, or whose name matches it
+// case-insensitively. It fails if no canvas or more than one canvas matches.
+func FindCanvas(canvases []CanvasInfo, query string) (CanvasInfo, error) {
+	var matches []CanvasInfo
+	for _, c := range canvases {
+		if c.ID == query {
+			return c, nil
+		}
+		if strings.EqualFold(c.Name, query) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return CanvasInfo{}, fmt.Errorf("no canvas named %q", query)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, 0, len(matches))
+	for _, c := range matches {
+		ids = append(ids, c.ID)
+	}
+	sort.Strings(ids)
+	return CanvasInfo{}, fmt.Errorf("%d canvases are named %q, attach by ID instead: %s", len(matches), query, strings.Join(ids, ", "))
+}
+
+// Upsert adds c to the registry, replacing any canvas registered under the same name
+func (r *Registry) Upsert(c Canvas) error {
+	if !canvasNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("canvas name %q may only contain letters, digits, '-' and '_'", c.Name)
+	}
+	if c.CanvasID == "" {
+		return fmt.Errorf("canvas %q has no canvas_id", c.Name)
+	}
+	if _, ok := r.byName[c.Name]; ok {
+		for i := range r.canvases {
+			if r.canvases[i].Name == c.Name {
+				r.canvases[i] = c
+			}
+		}
+	} else {
+		r.canvases = append(r.canvases, c)
+	}
+	r.byName[c.Name] = c
+	return nil
+}
+
+// Save writes the registry to path as a JSON list of canvases
+func (r *Registry) Save(path string) error {
+	data, err := json.MarshalIndent(r.canvases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal canvases: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write canvases file: %w", err)
+	}
+	return nil
+}
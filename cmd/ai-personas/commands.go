@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+)
+
+// command is a CLI subcommand run instead of the canvas watcher
+type command struct {
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+// Usage lines shown by printUsage and on argument errors
+const (
+	canvasesUsage = "canvases list"
+	attachUsage   = "attach <name|id> [--as name]"
+	personasUsage = "personas generate|regenerate|save|import|list|load|export|delete ..."
+	askUsage      = "ask \"question?\" [--canvas name] [--submit-only]"
+	exportUsage   = "export [--format md|json] [--canvas name] [--question id] [--all] [--out file]"
+	cleanupUsage  = "cleanup --question id [--canvas name] [--delete-question]"
+	validateUsage = "validate"
+	configUsage   = "config"
+)
+
+// commands maps subcommand names to their implementations
+var commands = map[string]command{
+	"canvases": {canvasesUsage, "List canvases visible to CANVUS_API_KEY on CANVUS_SERVER", runCanvases},
+	"attach":   {attachUsage, "Verify access to a canvas and write it into the config", runAttach},
+	"personas": {personasUsage, "Generate or regenerate persona notes, or save, import and manage them in the persona library", runPersonas},
+	"ask":      {askUsage, "Ask the personas a question and print their answers", runAsk},
+	"export":   {exportUsage, "Export answered questions from the workflow checkpoints", runExport},
+	"cleanup":  {cleanupUsage, "Delete the notes and connectors a question's answers created", runCleanup},
+	"validate": {validateUsage, "Check the Gemini, OpenAI and Canvus API keys", runValidate},
+	"config":   {configUsage, "Print the effective configuration with API keys masked", runConfig},
+}
+
+// runCommand runs the subcommand named by args[0] and returns the process exit code
+func runCommand(args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage()
+		return 2
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: ai-personas [command]")
+	fmt.Fprintln(os.Stderr, "\nWithout a command, watches the configured canvases.\n\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", commands[name].usage, commands[name].summary)
+	}
+	w.Flush()
+}
+
+// usageError reports wrong arguments to a subcommand
+func usageError(usage string) error {
+	return fmt.Errorf("usage: ai-personas %s", usage)
+}
+
+// parseFlags parses args with fs, allowing one leading positional argument
+// before the flags (e.g. `ask "question?" --canvas room1`). Returns the
+// positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = args[:1], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return append(positional, fs.Args()...), nil
+}
+
+// serverClient creates a client for server-level calls using the default server and key
+func serverClient() (*canvusapi.Client, error) {
+	return canvusapi.NewClientForCanvas(canvusapi.Canvas{Name: "server"}, currentConfig().Canvus.Defaults())
+}
+
+// runCanvases implements "canvases list"
+func runCanvases(args []string) error {
+	if len(args) != 1 || args[0] != "list" {
+		return usageError(canvasesUsage)
+	}
+	client, err := serverClient()
+	if err != nil {
+		return err
+	}
+	canvases, err := client.ListCanvases()
+	if err != nil {
+		return fmt.Errorf("failed to list canvases on %s: %w", client.Server, err)
+	}
+	folders, err := client.ListFolders()
+	if err != nil {
+		return fmt.Errorf("failed to list folders on %s: %w", client.Server, err)
+	}
+
+	sort.Slice(canvases, func(i, j int) bool {
+		return strings.ToLower(canvases[i].Name) < strings.ToLower(canvases[j].Name)
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tFOLDER\tSTATE")
+	for _, c := range canvases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, c.ID, canvusapi.FolderPath(folders, c.FolderID), c.State)
+	}
+	return w.Flush()
+}
+
+// runAttach implements "attach <name|id>": it resolves the canvas by name, checks
+// the API key can read it, then records it in CANVASES_FILE (under --as, default
+// derived from the canvas name) or, without a registry file, as CANVAS_ID in .env
+func runAttach(args []string) error {
+	fs := flag.NewFlagSet("attach", flag.ContinueOnError)
+	as := fs.String("as", "", "logical name to register the canvas under (CANVASES_FILE only)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError(attachUsage)
+	}
+	query := positional[0]
+
+	client, err := serverClient()
+	if err != nil {
+		return err
+	}
+	canvases, err := client.ListCanvases()
+	if err != nil {
+		return fmt.Errorf("failed to list canvases on %s: %w", client.Server, err)
+	}
+	info, err := canvusapi.FindCanvas(canvases, query)
+	if err != nil {
+		return err
+	}
+
+	// Verify access the same way startup validation does
+	client.CanvasID = info.ID
+	if _, err := client.GetCanvasInfo(); err != nil {
+		return fmt.Errorf("cannot access canvas %q (%s): %w", info.Name, info.ID, err)
+	}
+
+	if path := currentConfig().Canvus.CanvasesFile; path != "" {
+		name := *as
+		if name == "" {
+			name = registryName(info.Name)
+		}
+		if err := attachToRegistry(path, canvusapi.Canvas{Name: name, CanvasID: info.ID}); err != nil {
+			return err
+		}
+		fmt.Printf("Attached canvas %q (%s) as %s in %s\n", info.Name, info.ID, name, path)
+		return nil
+	}
+
+	cwd, _ := os.Getwd()
+	envPath := filepath.Join(cwd, ".env")
+	if err := setEnvValue(envPath, "CANVAS_ID", info.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Attached canvas %q (%s) as CANVAS_ID in %s\n", info.Name, info.ID, envPath)
+	return nil
+}
+
+// registryName derives a registry name from a canvas name, replacing characters
+// that are not allowed in /c/{name}/ routes
+func registryName(canvasName string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(canvasName))
+	name = strings.Trim(name, "-")
+	if name == "" {
+		return canvusapi.DefaultCanvasName
+	}
+	return name
+}
+
+// attachToRegistry adds c to the registry file at path, creating the file if needed
+func attachToRegistry(path string, c canvusapi.Canvas) error {
+	registry, err := canvusapi.LoadRegistry(path)
+	if errors.Is(err, os.ErrNotExist) {
+		registry, err = canvusapi.NewRegistry([]canvusapi.Canvas{c})
+	}
+	if err != nil {
+		return err
+	}
+	if err := registry.Upsert(c); err != nil {
+		return err
+	}
+	return registry.Save(path)
+}
+
+// setEnvValue sets key=value in the .env file at path, keeping all other lines
+// (including comments) as they are
+func setEnvValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		if k, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(k) == key {
+			lines[i] = key + "=" + value
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, key+"="+value)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
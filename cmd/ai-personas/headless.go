@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+	"github.com/jaypaulb/AI-personas/internal/gemini"
+	"github.com/jaypaulb/AI-personas/internal/library"
+	"github.com/jaypaulb/AI-personas/internal/startup"
+	"github.com/jaypaulb/AI-personas/internal/types"
+	"github.com/jaypaulb/AI-personas/internal/web"
+)
+
+// cliPersonasKey identifies persona generation started from the CLI, in place of a Create_Personas note ID
+const cliPersonasKey = "cli-personas"
+
+// commandContext returns a context cancelled on SIGINT/SIGTERM
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// canvasClient creates a client for the registered canvas called name. An empty
+// name selects the only configured canvas.
+func canvasClient(name string) (*canvusapi.Client, error) {
+	registry, err := currentConfig().Canvus.Registry()
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		return registry.NewClient(name)
+	}
+	canvases := registry.Canvases()
+	if len(canvases) > 1 {
+		names := make([]string, 0, len(canvases))
+		for _, c := range canvases {
+			names = append(names, c.Name)
+		}
+		return nil, fmt.Errorf("several canvases are configured, choose one with --canvas (%s)", strings.Join(names, ", "))
+	}
+	return registry.NewClient(canvases[0].Name)
+}
+
+// Usage lines of the personas subcommands
+var personasUsages = map[string]string{
+	"generate":   "personas generate [--panel name] [--canvas name]",
+	"save":       "personas save <n|name|all>... [--tags a,b] [--canvas name]",
+	"import":     "personas import [--tags a,b] [--id id,...] [--canvas name]",
+	"list":       "personas list [--tags a,b]",
+	"load":       "personas load <file.json> [--tags a,b]",
+	"export":     "personas export [--tags a,b] [--out file]",
+	"delete":     "personas delete <id>",
+	"regenerate": "personas regenerate <n> [--panel name] [--canvas name]",
+}
+
+// runPersonas implements "personas": generate creates the persona notes next to
+// the Personas anchor, exactly as a Create_Personas note would (or next to another
+// panel's anchor with --panel), and regenerate replaces one of them; the others
+// work with the persona library
+func runPersonas(args []string) error {
+	if len(args) == 0 {
+		return personasUsageError()
+	}
+	sub, args := args[0], args[1:]
+	usage, ok := personasUsages[sub]
+	if !ok {
+		return personasUsageError()
+	}
+	fs := flag.NewFlagSet("personas "+sub, flag.ContinueOnError)
+	canvasName := fs.String("canvas", "", "registered canvas name")
+	tags := fs.String("tags", "", "comma separated library tags, e.g. an industry")
+	ids := fs.String("id", "", "comma separated library persona IDs")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	panelName := fs.String("panel", "", "persona panel: customer (default), internal, partner or investor")
+	var positional []string
+	// save takes several persona references before its flags
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	positional = append(positional, rest...)
+	tagList := splitList(*tags)
+	panel := types.PanelCustomer
+	if *panelName != "" {
+		var ok bool
+		if panel, ok = atom.ParsePanel(*panelName); !ok {
+			return fmt.Errorf("panel must be customer, internal, partner or investor, got %q", *panelName)
+		}
+	}
+
+	switch sub {
+	case "generate", "import":
+		if len(positional) != 0 || (sub == "import" && panel != types.PanelCustomer) {
+			return usageError(usage)
+		}
+		client, err := canvasClient(*canvasName)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext()
+		defer cancel()
+		if sub == "generate" {
+			if err := gemini.CreatePanelPersonas(ctx, cliPersonasKey, client, nil, panel); err != nil {
+				return err
+			}
+		} else {
+			entries, err := library.GetGlobalLibrary().Select(tagList, splitList(*ids))
+			if err != nil {
+				return err
+			}
+			if _, err := gemini.ImportPersonasFromLibrary(ctx, cliPersonasKey, client, entries); err != nil {
+				return err
+			}
+		}
+		personas, err := gemini.FetchPersonasFromNotes(gemini.PanelKey(cliPersonasKey, panel), client)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%ss on canvas %s:\n", atom.PanelNotePrefix(panel), client.Name)
+		for i, p := range personas {
+			fmt.Printf("  %d. %s - %s\n", i+1, p.Name, p.Role)
+		}
+		return nil
+
+	case "regenerate":
+		if len(positional) != 1 {
+			return usageError(usage)
+		}
+		slot, ok := atom.PersonaSlot(positional[0])
+		if !ok {
+			return fmt.Errorf("persona must be a number from 1 to 4, got %q", positional[0])
+		}
+		client, err := canvasClient(*canvasName)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext()
+		defer cancel()
+		old, persona, err := gemini.RegeneratePersonaSlot(ctx, client, panel, slot)
+		if err != nil {
+			return err
+		}
+		if old.Name == "" {
+			old.Name = "FAILED"
+		}
+		fmt.Printf("%s - %s (was %s)\n", atom.PersonaNoteTitle(panel, slot, persona.Name), persona.Role, old.Name)
+		return nil
+
+	case "save":
+		if len(positional) == 0 {
+			return usageError(usage)
+		}
+		client, err := canvasClient(*canvasName)
+		if err != nil {
+			return err
+		}
+		saved, err := gemini.SavePersonasToLibrary(client, positional, tagList)
+		for _, e := range saved {
+			fmt.Printf("Saved %s as %s\n", e.Persona.Name, e.ID)
+		}
+		return err
+
+	case "list":
+		if len(positional) != 0 {
+			return usageError(usage)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tROLE\tTAGS")
+		for _, e := range library.GetGlobalLibrary().List(tagList) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.ID, e.Persona.Name, e.Persona.Role, strings.Join(e.Tags, ", "))
+		}
+		return w.Flush()
+
+	case "load":
+		if len(positional) != 1 {
+			return usageError(usage)
+		}
+		data, err := os.ReadFile(positional[0])
+		if err != nil {
+			return err
+		}
+		entries, err := library.Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", positional[0], err)
+		}
+		saved, err := library.GetGlobalLibrary().Import(entries, tagList, filepath.Base(positional[0]))
+		for _, e := range saved {
+			fmt.Printf("Loaded %s as %s\n", e.Persona.Name, e.ID)
+		}
+		return err
+
+	case "export":
+		if len(positional) != 0 {
+			return usageError(usage)
+		}
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", *out, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(library.GetGlobalLibrary().List(tagList))
+
+	case "delete":
+		if len(positional) != 1 {
+			return usageError(usage)
+		}
+		return library.GetGlobalLibrary().Delete(positional[0])
+	}
+	return nil
+}
+
+// personasUsageError lists the personas subcommands
+func personasUsageError() error {
+	names := make([]string, 0, len(personasUsages))
+	for name := range personasUsages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = "  ai-personas " + personasUsages[name]
+	}
+	return fmt.Errorf("usage:\n%s", strings.Join(lines, "\n"))
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runAsk implements "ask": it places the question on the canvas like the web page
+// does, answers it in this process and prints each persona's answers. With
+// --submit-only the question is left for a running watcher to answer.
+func runAsk(args []string) error {
+	fs := flag.NewFlagSet("ask", flag.ContinueOnError)
+	canvasName := fs.String("canvas", "", "registered canvas name")
+	submitOnly := fs.Bool("submit-only", false, "only place the question note; let a running watcher answer it")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		return usageError(askUsage)
+	}
+	client, err := canvasClient(*canvasName)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := web.NewServer(client).SubmitQuestion(positional[0])
+	if err != nil {
+		return err
+	}
+	if *submitOnly {
+		fmt.Printf("Question submitted to canvas %s (note %s)\n", client.Name, noteID)
+		return nil
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	gemini.HandleAIQuestion(ctx, client, canvus.WidgetEvent{ID: noteID, Type: "Note", Title: "New_AI_Question"}, currentConfig().Workflow.ChatTokenLimit)
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted; question %s will resume when the watcher next starts", noteID)
+	}
+
+	rec, ok := checkpoint.GetGlobalStore().Get(noteID)
+	if !ok || rec.Status != checkpoint.StatusDone {
+		msg := rec.Error
+		if msg == "" {
+			msg = "the workflow did not complete"
+		}
+		return fmt.Errorf("question %s was not answered: %s", noteID, msg)
+	}
+	return writeMarkdown(os.Stdout, []checkpoint.Record{rec})
+}
+
+// runExport implements "export": it writes the questions and answers recorded in
+// the workflow checkpoints as Markdown or JSON
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "md", "output format: md or json")
+	canvasName := fs.String("canvas", "", "only export questions from this registered canvas")
+	question := fs.String("question", "", "only export the question with this note ID")
+	all := fs.Bool("all", false, "include failed, cancelled and unfinished questions")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || (*format != "md" && *format != "json") {
+		return usageError(exportUsage)
+	}
+
+	canvasID := ""
+	if *canvasName != "" {
+		registry, err := currentConfig().Canvus.Registry()
+		if err != nil {
+			return err
+		}
+		c, ok := registry.Lookup(*canvasName)
+		if !ok {
+			return fmt.Errorf("unknown canvas %q", *canvasName)
+		}
+		canvasID = c.CanvasID
+	}
+
+	var records []checkpoint.Record
+	for _, rec := range checkpoint.GetGlobalStore().List(canvasID) {
+		if *question != "" && rec.QnoteID != *question {
+			continue
+		}
+		if !*all && rec.Status != checkpoint.StatusDone {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if *question != "" && len(records) == 0 {
+		return fmt.Errorf("no matching record for question %s (use --all to include unfinished questions)", *question)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	return writeMarkdown(w, records)
+}
+
+// writeMarkdown renders each question with its personas' answers and reactions
+func writeMarkdown(w io.Writer, records []checkpoint.Record) error {
+	var b strings.Builder
+	for i, rec := range records {
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+		question := rec.Question
+		if question == "" {
+			question = "(question text not recorded)"
+		}
+		fmt.Fprintf(&b, "# %s\n\n", question)
+		fmt.Fprintf(&b, "_Note %s on canvas %s, %s, asked %s_\n\n", rec.QnoteID, rec.Canvas, rec.Status, rec.StartedAt.Format(time.RFC1123))
+		if rec.Error != "" {
+			fmt.Fprintf(&b, "> %s\n\n", rec.Error)
+		}
+		for _, p := range rec.Personas {
+			fmt.Fprintf(&b, "## %s\n\n", p.Name)
+			if p.PromptVersion != "" {
+				fmt.Fprintf(&b, "_Prompts %s_\n\n", p.PromptVersion)
+			}
+			if p.Answer != "" {
+				fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(p.Answer))
+			}
+			if p.MetaAnswer != "" {
+				fmt.Fprintf(&b, "**After hearing the others:** %s\n\n", strings.TrimSpace(p.MetaAnswer))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// runCleanup implements "cleanup": it removes the notes, connectors and anchor a
+// question's workflow created, leaving its checkpoint in place for export
+func runCleanup(args []string) error {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	question := fs.String("question", "", "note ID of the question to clean up")
+	canvasName := fs.String("canvas", "", "registered canvas name (default: the canvas the question was asked on)")
+	deleteQnote := fs.Bool("delete-question", false, "also delete the question note")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *question == "" {
+		return usageError(cleanupUsage)
+	}
+
+	rec, ok := checkpoint.GetGlobalStore().Get(*question)
+	if !ok {
+		return fmt.Errorf("no checkpoint for question %s", *question)
+	}
+	var client *canvusapi.Client
+	if *canvasName != "" {
+		client, err = canvasClient(*canvasName)
+	} else {
+		client, err = clientForCanvasID(rec.Canvas)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := gemini.CleanupQuestion(client, *question, *deleteQnote); err != nil {
+		return err
+	}
+	fmt.Printf("Cleaned up question %s on canvas %s\n", *question, client.Name)
+	return nil
+}
+
+// clientForCanvasID creates a client for the registered canvas with the given canvas ID
+func clientForCanvasID(canvasID string) (*canvusapi.Client, error) {
+	registry, err := currentConfig().Canvus.Registry()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range registry.Canvases() {
+		if c.CanvasID == canvasID {
+			return registry.NewClient(c.Name)
+		}
+	}
+	return nil, fmt.Errorf("canvas %s is not configured, choose one with --canvas", canvasID)
+}
+
+// runValidate implements "validate" using the same checks as startup
+func runValidate(args []string) error {
+	if len(args) != 0 {
+		return usageError(validateUsage)
+	}
+	if err := startup.ValidateAPIKeys(currentConfig(), 30*time.Second); err != nil {
+		return err
+	}
+	fmt.Println("All API keys are valid")
+	return nil
+}
+
+// runConfig implements "config": it prints the configuration loaded at startup
+func runConfig(args []string) error {
+	if len(args) != 0 {
+		return usageError(configUsage)
+	}
+	fmt.Print(currentConfig())
+	return nil
+}
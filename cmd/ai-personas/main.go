@@ -7,15 +7,27 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime/debug"
-	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/cache"
 	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+	"github.com/jaypaulb/AI-personas/internal/config"
 	"github.com/jaypaulb/AI-personas/internal/gemini"
+	"github.com/jaypaulb/AI-personas/internal/library"
+	"github.com/jaypaulb/AI-personas/internal/logutil"
+	"github.com/jaypaulb/AI-personas/internal/personastore"
+	"github.com/jaypaulb/AI-personas/internal/queue"
+	"github.com/jaypaulb/AI-personas/internal/ratelimit"
 	"github.com/jaypaulb/AI-personas/internal/startup"
+	"github.com/jaypaulb/AI-personas/internal/timing"
+	"github.com/jaypaulb/AI-personas/internal/usage"
 	"github.com/jaypaulb/AI-personas/internal/web"
 	"github.com/joho/godotenv"
 )
@@ -23,85 +35,230 @@ import (
 // GracefulShutdownTimeout is the maximum time to wait for goroutines to complete
 const GracefulShutdownTimeout = 30 * time.Second
 
-// Configuration loaded from environment
 var (
-	debugMode      = false
-	chatTokenLimit = 256
-	noteMonitors   sync.Map // Thread-safe map for concurrent access: noteID -> bool
+	appConfig    atomic.Pointer[config.Config] // replaced on reload, read with currentConfig
+	noteMonitors sync.Map                      // Thread-safe map for concurrent access: noteID -> bool
+
+	// envFromFile records the variables copied from .env and their previous values,
+	// so a reload can restore variables removed from the file
+	envFromFile = make(map[string]envValue)
 )
 
+// envValue is an environment variable's value before .env overrode it
+type envValue struct {
+	value string
+	set   bool
+}
+
+// currentConfig returns the configuration loaded at startup or by the last reload
+func currentConfig() *config.Config {
+	if cfg := appConfig.Load(); cfg != nil {
+		return cfg
+	}
+	return config.Default()
+}
+
 // workflowWG tracks active workflow goroutines for graceful shutdown
 var workflowWG sync.WaitGroup
 
 func main() {
-	// Load environment configuration
-	loadEnv()
+	// Load configuration from the config file, .env and environment
+	loadConfig()
+
+	// Subcommands run once and exit instead of watching the canvases
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+	cfg := currentConfig()
+	log.Printf("[startup] Effective configuration:\n%s", cfg)
 
 	// Validate all API keys at startup
-	if err := startup.ValidateAPIKeys(30 * time.Second); err != nil {
+	if err := startup.ValidateAPIKeys(cfg, 30*time.Second); err != nil {
 		log.Fatalf("[startup] API key validation failed: %v", err)
 	}
 
-	// Initialize Canvus client
-	client, err := canvusapi.NewClientFromEnv()
+	// Initialize one Canvus client per configured canvas
+	registry, err := cfg.Canvus.Registry()
 	if err != nil {
-		log.Fatalf("Failed to initialize Canvus client: %v", err)
+		log.Fatalf("Failed to load canvases: %v", err)
+	}
+	clients, err := registry.NewClients()
+	if err != nil {
+		log.Fatalf("Failed to initialize Canvus clients: %v", err)
 	}
 
-	// Start web server
-	webServer := web.NewServer(client)
-	webServer.Start()
+	// Start web server (one question page and Remote QR code per canvas)
+	web.NewHubForClients(webConfig(cfg), clients).Start()
 
-	// Start event monitoring
-	eventMonitor := canvus.NewEventMonitor(client)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	triggers := make(chan canvus.EventTrigger, 10)
-
 	// Handle graceful shutdown
 	setupShutdownHandler(cancel)
 
+	// Reload prompts and tunables on SIGHUP or when the config file or .env changes
+	startReloader(ctx)
+
+	// Start the bounded worker pool that runs LLM-heavy workflow stages
+	workflowQueue := queue.New(cfg.Workflow.Concurrency)
+	workflowQueue.Start(ctx, &workflowWG)
+	gemini.SetWorkflowQueue(workflowQueue)
+
+	for _, client := range clients {
+		startCanvas(ctx, client)
+	}
+
+	// Wait for graceful shutdown
+	<-ctx.Done()
+	waitForShutdown()
+}
+
+// startCanvas resumes interrupted workflows on a canvas, then subscribes to its
+// widget events and runs its event loop until ctx is cancelled
+func startCanvas(ctx context.Context, client *canvusapi.Client) {
+	log.Printf("[main] Serving canvas %s (ID: %s)", client.Name, client.CanvasID)
+
+	// Resume (or clean up) question workflows interrupted by the last shutdown
+	gemini.RecoverWorkflows(client, func(qnoteID string) {
+		handleNewAIQuestion(ctx, client, canvus.EventTrigger{
+			Type:   canvus.TriggerNewAIQuestion,
+			Widget: canvus.WidgetEvent{ID: qnoteID, Type: "Note", Title: "New_AI_Question"},
+		})
+	})
+
+	eventMonitor := canvus.NewEventMonitorWithConfig(client, canvus.EventMonitorConfig{
+		DebugMode:        currentConfig().Debug,
+		DebounceDuration: canvus.DefaultEventMonitorConfig().DebounceDuration,
+	})
+	triggers := make(chan canvus.EventTrigger, 10)
+
 	// Start event subscription
 	workflowWG.Add(1)
 	go func() {
 		defer workflowWG.Done()
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("[error] SubscribeAndDetectTriggers panic recovered: %v\n%s", r, debug.Stack())
+				log.Printf("[error] SubscribeAndDetectTriggers panic recovered for canvas %s: %v\n%s", client.Name, r, debug.Stack())
 			}
 		}()
 		eventMonitor.SubscribeAndDetectTriggers(ctx, triggers)
 	}()
 
-	// Main event loop
-	runEventLoop(ctx, client, triggers)
-
-	// Wait for graceful shutdown
-	waitForShutdown()
+	// Main event loop for this canvas
+	go runEventLoop(ctx, client, triggers)
 }
 
-// loadEnv loads configuration from .env file and environment
-func loadEnv() {
+// envPath returns the path of the .env file in the working directory
+func envPath() string {
 	cwd, _ := os.Getwd()
-	absEnvPath := filepath.Join(cwd, ".env")
-	log.Printf("[startup] Looking for .env at: %s", absEnvPath)
+	return filepath.Join(cwd, ".env")
+}
 
-	if envMap, err := godotenv.Read(absEnvPath); err == nil {
-		for k, v := range envMap {
-			os.Setenv(k, v)
-		}
-		log.Printf("[startup] .env loaded from: %s", absEnvPath)
+// loadConfig copies .env into the environment, loads the typed configuration
+// and hands it to every component
+func loadConfig() {
+	log.Printf("[startup] Looking for .env at: %s", envPath())
+	cfg, err := readConfig()
+	if err != nil {
+		log.Fatalf("[startup] %v", err)
 	}
+	applyConfig(cfg)
+}
 
-	if os.Getenv("DEBUG") == "1" {
-		debugMode = true
+// readConfig copies .env into the environment, restoring variables that were
+// removed from it since the last read, then loads the typed configuration
+func readConfig() (*config.Config, error) {
+	envMap, err := godotenv.Read(envPath())
+	if err != nil {
+		envMap = nil
 	}
+	for k, prev := range envFromFile {
+		if _, ok := envMap[k]; !ok {
+			if prev.set {
+				os.Setenv(k, prev.value)
+			} else {
+				os.Unsetenv(k)
+			}
+			delete(envFromFile, k)
+		}
+	}
+	for k, v := range envMap {
+		if _, ok := envFromFile[k]; !ok {
+			value, set := os.LookupEnv(k)
+			envFromFile[k] = envValue{value, set}
+		}
+		os.Setenv(k, v)
+	}
+	return config.Load("")
+}
 
-	if v := os.Getenv("CHAT_TOKEN_LIMIT"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			chatTokenLimit = n
+// startReloader watches the config file, .env and prompt directories and applies
+// each valid change to new workflows. Settings that need a restart are reported instead.
+func startReloader(ctx context.Context) {
+	configFile := config.ResolvePath("")
+	if configFile == "" {
+		configFile = config.DefaultFile
+	}
+	files := append([]string{configFile, envPath()}, currentConfig().PromptDirs()...)
+	reloader := config.NewReloader(currentConfig(), readConfig, files...)
+	reloader.OnReload(func(old, cfg *config.Config) {
+		applyRuntimeConfig(old, cfg)
+		if changed := config.RestartRequired(old, cfg); len(changed) > 0 {
+			log.Printf("[config] Restart to apply changes to: %s", strings.Join(changed, ", "))
 		}
+	})
+	reloader.Start(ctx)
+}
+
+// applyConfig injects cfg into the packages that read configuration
+func applyConfig(cfg *config.Config) {
+	checkpoint.SetGlobalStore(checkpoint.New(cfg.Workflow.CheckpointDir))
+	personastore.SetGlobalStore(personastore.New(cfg.Workflow.PersonaDir))
+	library.SetGlobalLibrary(library.New(cfg.Workflow.LibraryDir))
+
+	if cfg.Cache.Enabled {
+		cache.SetGlobalCache(cache.New(cfg.Cache.Dir, cfg.Cache.TTL))
+	} else {
+		log.Printf("[cache] LLM response cache disabled")
+		cache.SetGlobalCache(cache.Disabled())
+	}
+
+	tracker := usage.NewTracker(usage.PriceTableFromFile(cfg.Usage.PriceTableFile))
+	if err := tracker.SetStateFile(cfg.Usage.StateFile); err != nil {
+		log.Printf("[usage] %v; budget totals start from zero", err)
+	}
+	usage.SetGlobalTracker(tracker)
+	applyRuntimeConfig(nil, cfg)
+}
+
+// applyRuntimeConfig applies the settings that can change while running: debug
+// and log level, the Gemini/OpenAI settings and prompts used by new workflows,
+// rate limits and budgets. old is nil at startup.
+func applyRuntimeConfig(old, cfg *config.Config) {
+	appConfig.Store(cfg)
+	timing.SetDebug(cfg.Debug)
+	logutil.SetLevel(cfg.LogLevel)
+	gemini.Configure(cfg)
+	if old == nil || old.Gemini.RateLimit != cfg.Gemini.RateLimit || old.Gemini.RateBurst != cfg.Gemini.RateBurst {
+		ratelimit.Configure("gemini", cfg.Gemini.RateLimit, cfg.Gemini.RateBurst)
+	}
+	if old == nil || old.OpenAI.RateLimit != cfg.OpenAI.RateLimit || old.OpenAI.RateBurst != cfg.OpenAI.RateBurst {
+		ratelimit.Configure("openai", cfg.OpenAI.RateLimit, cfg.OpenAI.RateBurst)
 	}
+	usage.GetGlobalTracker().SetBudget(usage.Budget{
+		CanvasTokens: cfg.Usage.CanvasTokens,
+		CanvasCost:   cfg.Usage.CanvasCost,
+		DailyTokens:  cfg.Usage.DailyTokens,
+		DailyCost:    cfg.Usage.DailyCost,
+	})
+}
+
+// webConfig returns the web server settings from cfg
+func webConfig(cfg *config.Config) web.ServerConfig {
+	wc := web.DefaultServerConfig()
+	wc.Port = cfg.Web.Port
+	wc.PublicWebURL = cfg.Web.PublicURL
+	wc.AdminToken = cfg.Web.AdminToken
+	return wc
 }
 
 // setupShutdownHandler configures graceful shutdown on SIGINT/SIGTERM
@@ -138,12 +295,12 @@ func waitForShutdown() {
 // runEventLoop processes events from the trigger channel
 func runEventLoop(ctx context.Context, client *canvusapi.Client, triggers <-chan canvus.EventTrigger) {
 	for {
-		log.Printf("[main] Waiting for triggers...")
+		log.Printf("[main] Waiting for triggers on canvas %s...", client.Name)
 		select {
 		case trig := <-triggers:
 			handleTrigger(ctx, client, trig)
 		case <-ctx.Done():
-			log.Printf("[main] Context cancelled. Exiting event loop.")
+			log.Printf("[main] Context cancelled. Exiting event loop for canvas %s.", client.Name)
 			return
 		}
 	}
@@ -163,13 +320,32 @@ func handleTrigger(ctx context.Context, client *canvusapi.Client, trig canvus.Ev
 
 	case canvus.TriggerConnectorCreated:
 		handleConnectorCreated(ctx, client, trig)
+
+	case canvus.TriggerCancelAIQuestion:
+		handleCancelAIQuestion(client, trig)
+
+	case canvus.TriggerWidgetDeleted:
+		handleWidgetDeleted(client, trig)
+
+	case canvus.TriggerPersonaNoteEdited:
+		handlePersonaNoteEdited(ctx, client, trig)
+
+	case canvus.TriggerSavePersonaNote, canvus.TriggerImportPersonasNote:
+		handleLibraryNote(ctx, client, trig)
+
+	case canvus.TriggerRegeneratePersona:
+		handleRegeneratePersona(ctx, client, trig)
+
+	case canvus.TriggerInterviewQuestion:
+		handleInterviewQuestion(ctx, client, trig)
 	}
 }
 
 // handleCreatePersonas handles persona creation triggers
 func handleCreatePersonas(ctx context.Context, client *canvusapi.Client, trig canvus.EventTrigger) {
-	log.Printf("\n\nTrigger - Create_Personas Note detected. Proceeding with Persona Creation.\n\n")
-	err := gemini.CreatePersonas(ctx, trig.Widget.ID, client)
+	panel, _ := atom.CreatePersonasPanel(trig.Widget.Title)
+	log.Printf("\n\nTrigger - %s Note detected. Proceeding with Persona Creation.\n\n", strings.TrimSpace(trig.Widget.Title))
+	err := gemini.CreatePanelPersonas(ctx, trig.Widget.ID, client, nil, panel)
 	if err != nil {
 		log.Printf("[error] CreatePersonas failed: %v\n", err)
 		return
@@ -198,7 +374,7 @@ func handleNewAIQuestion(ctx context.Context, client *canvusapi.Client, trig can
 					log.Printf("[error] handleNewAIQuestion goroutine panic recovered for noteID=%s: %v\n%s", noteID, r, debug.Stack())
 				}
 			}()
-			gemini.HandleAIQuestion(ctx, client, trig.Widget, chatTokenLimit)
+			gemini.HandleAIQuestion(ctx, client, trig.Widget, currentConfig().Workflow.ChatTokenLimit)
 		}(trig.Widget.ID)
 	}
 }
@@ -214,6 +390,99 @@ func handleConnectorCreated(ctx context.Context, client *canvusapi.Client, trig
 				log.Printf("[error] handleConnectorCreated goroutine panic recovered for connectorID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
 			}
 		}()
-		gemini.HandleFollowupConnector(ctx, client, trig.Widget, chatTokenLimit)
+		gemini.HandleFollowupConnector(ctx, client, trig.Widget, currentConfig().Workflow.ChatTokenLimit)
+	}()
+}
+
+// handleCancelAIQuestion cancels the workflow of a Qnote retitled to Cancel_AI_Question
+func handleCancelAIQuestion(client *canvusapi.Client, trig canvus.EventTrigger) {
+	if !gemini.IsQuestionActive(trig.Widget.ID) {
+		return
+	}
+	log.Printf("[main] TriggerCancelAIQuestion for noteID=%s", trig.Widget.ID)
+	workflowWG.Add(1)
+	go func() {
+		defer workflowWG.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[error] handleCancelAIQuestion goroutine panic recovered for noteID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
+			}
+		}()
+		gemini.CancelQuestion(client, trig.Widget.ID, true)
+	}()
+}
+
+// handlePersonaNoteEdited pushes an edited persona note into the sessions using it
+func handlePersonaNoteEdited(ctx context.Context, client *canvusapi.Client, trig canvus.EventTrigger) {
+	workflowWG.Add(1)
+	go func() {
+		defer workflowWG.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[error] handlePersonaNoteEdited goroutine panic recovered for noteID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
+			}
+		}()
+		gemini.HandlePersonaNoteEdit(ctx, client, trig.Widget)
+	}()
+}
+
+// handleLibraryNote saves personas to, or imports them from, the persona library
+func handleLibraryNote(ctx context.Context, client *canvusapi.Client, trig canvus.EventTrigger) {
+	workflowWG.Add(1)
+	go func() {
+		defer workflowWG.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[error] handleLibraryNote goroutine panic recovered for noteID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
+			}
+		}()
+		gemini.HandleLibraryNote(ctx, client, trig.Widget, trig.Type == canvus.TriggerSavePersonaNote)
+	}()
+}
+
+// handleRegeneratePersona replaces the persona of a note retitled "Persona N: REGENERATE"
+// (or "Internal Persona N: REGENERATE" and so on)
+func handleRegeneratePersona(ctx context.Context, client *canvusapi.Client, trig canvus.EventTrigger) {
+	workflowWG.Add(1)
+	go func() {
+		defer workflowWG.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[error] handleRegeneratePersona goroutine panic recovered for noteID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
+			}
+		}()
+		gemini.HandleRegeneratePersona(ctx, client, trig.Widget)
+	}()
+}
+
+// handleInterviewQuestion answers the question on an "Interview: <persona>" note as that persona alone
+func handleInterviewQuestion(ctx context.Context, client *canvusapi.Client, trig canvus.EventTrigger) {
+	workflowWG.Add(1)
+	go func() {
+		defer workflowWG.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[error] handleInterviewQuestion goroutine panic recovered for noteID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
+			}
+		}()
+		gemini.HandleInterviewQuestion(ctx, client, trig.Widget, currentConfig().Workflow.ChatTokenLimit)
+	}()
+}
+
+// handleWidgetDeleted cancels the workflow of a Qnote deleted while it was being answered
+func handleWidgetDeleted(client *canvusapi.Client, trig canvus.EventTrigger) {
+	if !gemini.IsQuestionActive(trig.Widget.ID) {
+		return
+	}
+	log.Printf("[main] Qnote %s deleted while its workflow was running", trig.Widget.ID)
+	workflowWG.Add(1)
+	go func() {
+		defer workflowWG.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[error] handleWidgetDeleted goroutine panic recovered for noteID=%s: %v\n%s", trig.Widget.ID, r, debug.Stack())
+			}
+		}()
+		gemini.CancelQuestion(client, trig.Widget.ID, false)
 	}()
 }
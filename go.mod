@@ -7,6 +7,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	google.golang.org/genai v1.34.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
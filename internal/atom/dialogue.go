@@ -0,0 +1,26 @@
+package atom
+
+import "strings"
+
+// dialogueReplySuffix ends the title of a persona's reply in a conversation between two personas
+const dialogueReplySuffix = " Dialogue Reply"
+
+// DialogueReplyTitle returns the title of a conversation reply by the persona named name
+func DialogueReplyTitle(name string) string {
+	return name + dialogueReplySuffix
+}
+
+// IsDialogueReplyTitle reports whether title is that of a conversation reply
+func IsDialogueReplyTitle(title string) bool {
+	return strings.HasSuffix(title, dialogueReplySuffix)
+}
+
+// DialogueAnchorName returns the name of the anchor grouping a conversation
+// between two personas about the question in note qnoteID, the same whichever
+// of them spoke first
+func DialogueAnchorName(first, second, qnoteID string) string {
+	if strings.ToLower(second) < strings.ToLower(first) {
+		first, second = second, first
+	}
+	return "Dialogue: " + first + " & " + second + " (question " + qnoteID + ")"
+}
@@ -0,0 +1,287 @@
+package atom
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// DiversityIssue is a persona to replace so a set meets its diversity
+// constraints, with what the replacement must be
+type DiversityIssue struct {
+	Persona int      // zero-based position in the set
+	Needs   []string // e.g. "sector: Retail", "attitude: sceptic"
+}
+
+// DiversityRules describes d as instructions for generating count personas
+func DiversityRules(d types.Diversity, count int) []string {
+	var rules []string
+	if d.DistinctSectors {
+		rules = append(rules, "Each persona comes from a different market sector.")
+	}
+	coverage := func(field string, values []string) {
+		if len(values) > 0 {
+			rules = append(rules, fmt.Sprintf("Each persona's %s is one of %s, and together they cover %d of them.", field, strings.Join(values, ", "), min(len(values), count)))
+		}
+	}
+	coverage("sector", d.Sectors)
+	if d.MinAgeSpread > 0 {
+		rules = append(rules, fmt.Sprintf("The oldest persona is at least %d years older than the youngest.", d.MinAgeSpread))
+	}
+	coverage("region", d.Regions)
+	coverage("company size", d.CompanySizes)
+	switch strings.ToLower(d.Market) {
+	case types.MarketB2B:
+		rules = append(rules, "Every persona buys for a business (market B2B).")
+	case types.MarketB2C:
+		rules = append(rules, "Every persona buys as an individual consumer (market B2C).")
+	case types.MarketMixed:
+		rules = append(rules, "The set mixes business buyers (B2B) and consumers (B2C), with at least one of each.")
+	}
+	if d.MinSceptics > 0 {
+		rules = append(rules, fmt.Sprintf("At least %d persona(s) are sceptical of the offering (attitude sceptic).", d.MinSceptics))
+	}
+	if d.MinEnthusiasts > 0 {
+		rules = append(rules, fmt.Sprintf("At least %d persona(s) are enthusiastic about it (attitude enthusiast).", d.MinEnthusiasts))
+	}
+	return rules
+}
+
+// diversityCheck collects the problems of a set of personas and the personas to replace
+type diversityCheck struct {
+	personas []types.Persona
+	problems []string
+	needs    map[int][]string
+}
+
+// flag asks for persona i to be replaced by one meeting need
+func (c *diversityCheck) flag(i int, need string) {
+	c.needs[i] = append(c.needs[i], need)
+}
+
+// donor picks the persona to replace among candidates, preferring one already
+// being replaced and then the last, so earlier personas are kept
+func (c *diversityCheck) donor(candidates []int) (int, bool) {
+	for k := len(candidates) - 1; k >= 0; k-- {
+		if len(c.needs[candidates[k]]) > 0 {
+			return candidates[k], true
+		}
+	}
+	if len(candidates) == 0 {
+		return -1, false
+	}
+	return candidates[len(candidates)-1], true
+}
+
+// without returns list without i
+func without(list []int, i int) []int {
+	out := make([]int, 0, len(list))
+	for _, v := range list {
+		if v != i {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// cover checks one attribute: with allowed set, every persona must take one of
+// the values and together cover as many as there are personas; with distinct,
+// no two personas may share a value
+func (c *diversityCheck) cover(field string, value func(types.Persona) string, allowed []string, distinct bool) {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, a := range allowed {
+		allowedSet[strings.ToLower(strings.TrimSpace(a))] = true
+	}
+	first := make(map[string]int)
+	var bad, dups []int
+	for i, p := range c.personas {
+		v := strings.ToLower(strings.TrimSpace(value(p)))
+		if len(allowed) > 0 && !allowedSet[v] {
+			bad = append(bad, i)
+			continue
+		}
+		if _, dup := first[v]; dup {
+			dups = append(dups, i)
+			continue
+		}
+		first[v] = i
+	}
+
+	assigned := make(map[int]bool)
+	if len(allowed) > 0 {
+		short := min(len(allowed), len(c.personas)) - len(first)
+		for _, a := range allowed {
+			if short <= 0 {
+				break
+			}
+			if _, covered := first[strings.ToLower(strings.TrimSpace(a))]; covered {
+				continue
+			}
+			i, ok := c.donor(append(append([]int{}, bad...), dups...))
+			if !ok {
+				break
+			}
+			bad, dups = without(bad, i), without(dups, i)
+			assigned[i] = true
+			short--
+			c.problems = append(c.problems, fmt.Sprintf("no persona has %s %q", field, a))
+			c.flag(i, fmt.Sprintf("%s: %s", field, a))
+		}
+	}
+	for _, i := range bad {
+		c.problems = append(c.problems, fmt.Sprintf("persona %d: %s %q is not one of %s", i+1, field, value(c.personas[i]), strings.Join(allowed, ", ")))
+		c.flag(i, fmt.Sprintf("%s: one of %s", field, strings.Join(allowed, ", ")))
+	}
+	if !distinct {
+		return
+	}
+	for _, i := range dups {
+		if assigned[i] {
+			continue
+		}
+		v := value(c.personas[i])
+		c.problems = append(c.problems, fmt.Sprintf("persona %d: %s %q is the same as persona %d's", i+1, field, v, first[strings.ToLower(strings.TrimSpace(v))]+1))
+		var used []string
+		for j, p := range c.personas {
+			if j != i {
+				used = append(used, value(p))
+			}
+		}
+		c.flag(i, fmt.Sprintf("%s: other than %s", field, strings.Join(used, ", ")))
+	}
+}
+
+// ageSpread checks that the personas' ages span at least minSpread years
+func (c *diversityCheck) ageSpread(minSpread int) {
+	ages := make(map[int]int, len(c.personas))
+	lo, hi := -1, -1
+	for i, p := range c.personas {
+		n, err := strconv.Atoi(ageNumberRegex.FindString(string(p.Age)))
+		if err != nil {
+			continue
+		}
+		ages[i] = n
+		if lo < 0 || n < ages[lo] {
+			lo = i
+		}
+		if hi < 0 || n > ages[hi] {
+			hi = i
+		}
+	}
+	if len(ages) < 2 || ages[hi]-ages[lo] >= minSpread {
+		return
+	}
+	c.problems = append(c.problems, fmt.Sprintf("ages span %d years, less than %d", ages[hi]-ages[lo], minSpread))
+	var candidates []int
+	for i := range c.personas {
+		if _, ok := ages[i]; ok && i != lo && i != hi {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = []int{hi}
+	}
+	i, _ := c.donor(candidates)
+	if older := ages[lo] + minSpread; older <= maxPersonaAge {
+		c.flag(i, fmt.Sprintf("age: at least %d", older))
+	} else {
+		c.flag(i, fmt.Sprintf("age: at most %d", ages[hi]-minSpread))
+	}
+}
+
+// market checks the B2B/B2C mix
+func (c *diversityCheck) market(market string) {
+	switch strings.ToLower(market) {
+	case types.MarketB2B, types.MarketB2C:
+		want := strings.ToUpper(market)
+		for i, p := range c.personas {
+			if !strings.EqualFold(p.Market, want) {
+				c.problems = append(c.problems, fmt.Sprintf("persona %d: market %q is not %s", i+1, p.Market, want))
+				c.flag(i, "market: "+want)
+			}
+		}
+	case types.MarketMixed:
+		var b2b, b2c []int
+		for i, p := range c.personas {
+			if strings.EqualFold(p.Market, types.MarketB2C) {
+				b2c = append(b2c, i)
+			} else {
+				b2b = append(b2b, i)
+			}
+		}
+		if len(c.personas) < 2 {
+			return
+		}
+		if len(b2c) == 0 {
+			i, _ := c.donor(b2b)
+			c.problems = append(c.problems, "no persona is a B2C consumer")
+			c.flag(i, "market: B2C")
+		} else if len(b2b) == 0 {
+			i, _ := c.donor(b2c)
+			c.problems = append(c.problems, "no persona is a B2B buyer")
+			c.flag(i, "market: B2B")
+		}
+	}
+}
+
+// attitudes checks there are enough sceptics and enthusiasts, converting neutral
+// personas first and then those of the other attitude the set can spare
+func (c *diversityCheck) attitudes(minSceptics, minEnthusiasts int) {
+	byAttitude := make(map[string][]int)
+	for i, p := range c.personas {
+		a := strings.ToLower(p.Attitude)
+		if a != types.AttitudeSceptic && a != types.AttitudeEnthusiast {
+			a = types.AttitudeNeutral
+		}
+		byAttitude[a] = append(byAttitude[a], i)
+	}
+	need := func(attitude, other string, minimum, otherMinimum int) {
+		for short := minimum - len(byAttitude[attitude]); short > 0; short-- {
+			candidates := byAttitude[types.AttitudeNeutral]
+			if len(candidates) == 0 && len(byAttitude[other]) > otherMinimum {
+				candidates = byAttitude[other]
+			}
+			i, ok := c.donor(candidates)
+			if !ok {
+				return
+			}
+			byAttitude[types.AttitudeNeutral] = without(byAttitude[types.AttitudeNeutral], i)
+			byAttitude[other] = without(byAttitude[other], i)
+			byAttitude[attitude] = append(byAttitude[attitude], i)
+			c.problems = append(c.problems, fmt.Sprintf("fewer than %d persona(s) have attitude %s", minimum, attitude))
+			c.flag(i, "attitude: "+attitude)
+		}
+	}
+	need(types.AttitudeSceptic, types.AttitudeEnthusiast, minSceptics, minEnthusiasts)
+	need(types.AttitudeEnthusiast, types.AttitudeSceptic, minEnthusiasts, minSceptics)
+}
+
+// CheckDiversity checks a set of personas against d. It returns the problems
+// found and the personas to replace, in order, with what each replacement must be.
+func CheckDiversity(personas []types.Persona, d types.Diversity) ([]string, []DiversityIssue) {
+	c := &diversityCheck{personas: personas, needs: make(map[int][]string)}
+	if d.DistinctSectors || len(d.Sectors) > 0 {
+		c.cover("sector", func(p types.Persona) string { return p.Sector }, d.Sectors, d.DistinctSectors)
+	}
+	if d.MinAgeSpread > 0 {
+		c.ageSpread(d.MinAgeSpread)
+	}
+	if len(d.Regions) > 0 {
+		c.cover("region", func(p types.Persona) string { return p.Region }, d.Regions, false)
+	}
+	if len(d.CompanySizes) > 0 {
+		c.cover("company size", func(p types.Persona) string { return p.CompanySize }, d.CompanySizes, false)
+	}
+	c.market(d.Market)
+	c.attitudes(d.MinSceptics, d.MinEnthusiasts)
+
+	issues := make([]DiversityIssue, 0, len(c.needs))
+	for i, needs := range c.needs {
+		issues = append(issues, DiversityIssue{Persona: i, Needs: needs})
+	}
+	sort.Slice(issues, func(a, b int) bool { return issues[a].Persona < issues[b].Persona })
+	return c.problems, issues
+}
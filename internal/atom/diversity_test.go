@@ -0,0 +1,114 @@
+package atom
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+func TestCheckDiversity(t *testing.T) {
+	tests := []struct {
+		name     string
+		personas []types.Persona
+		d        types.Diversity
+		want     []DiversityIssue
+		problems int
+	}{
+		{
+			name: "meets every rule",
+			personas: []types.Persona{
+				{Sector: "Retail", Age: "25", Market: "B2B", Attitude: "sceptic"},
+				{Sector: "Health", Age: "40", Market: "B2B", Attitude: "enthusiast"},
+				{Sector: "Finance", Age: "55", Market: "b2b", Attitude: "neutral"},
+				{Sector: "Tech", Age: "60 years", Market: "B2B"},
+			},
+			d: types.Diversity{DistinctSectors: true, MinAgeSpread: 20, Market: "B2B", MinSceptics: 1, MinEnthusiasts: 1},
+		},
+		{
+			name:     "a shared sector replaces the later persona",
+			personas: []types.Persona{{Sector: "Retail"}, {Sector: "retail"}, {Sector: "Health"}},
+			d:        types.Diversity{DistinctSectors: true},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"sector: other than Retail, Health"}}},
+			problems: 1,
+		},
+		{
+			name:     "an allowed sector no persona covers",
+			personas: []types.Persona{{Sector: "Retail"}, {Sector: "Retail"}, {Sector: "Retail"}},
+			d:        types.Diversity{Sectors: []string{"Retail", "Health"}},
+			want:     []DiversityIssue{{Persona: 2, Needs: []string{"sector: Health"}}},
+			problems: 1,
+		},
+		{
+			name:     "a sector outside the allowed list",
+			personas: []types.Persona{{Sector: "retail"}, {Sector: "Health"}, {Sector: "Mining"}},
+			d:        types.Diversity{Sectors: []string{"Retail", "Health"}},
+			want:     []DiversityIssue{{Persona: 2, Needs: []string{"sector: one of Retail, Health"}}},
+			problems: 1,
+		},
+		{
+			name:     "ages too close replace a middle persona",
+			personas: []types.Persona{{Age: "30"}, {Age: "35"}, {Age: "40"}},
+			d:        types.Diversity{MinAgeSpread: 20},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"age: at least 50"}}},
+			problems: 1,
+		},
+		{
+			name:     "ages too close near the oldest allowed",
+			personas: []types.Persona{{Age: "90"}, {Age: "95"}},
+			d:        types.Diversity{MinAgeSpread: 20},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"age: at most 75"}}},
+			problems: 1,
+		},
+		{
+			name:     "a single market",
+			personas: []types.Persona{{Market: "B2C"}, {Market: "b2b"}},
+			d:        types.Diversity{Market: "b2c"},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"market: B2C"}}},
+			problems: 1,
+		},
+		{
+			name:     "a mixed market without consumers",
+			personas: []types.Persona{{Market: "B2B"}, {Market: "B2B"}},
+			d:        types.Diversity{Market: "mixed"},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"market: B2C"}}},
+			problems: 1,
+		},
+		{
+			name:     "neutral personas become sceptics first",
+			personas: []types.Persona{{Attitude: "enthusiast"}, {Attitude: "neutral"}, {}},
+			d:        types.Diversity{MinSceptics: 1},
+			want:     []DiversityIssue{{Persona: 2, Needs: []string{"attitude: sceptic"}}},
+			problems: 1,
+		},
+		{
+			name:     "a spare enthusiast becomes a sceptic",
+			personas: []types.Persona{{Attitude: "Enthusiast"}, {Attitude: "enthusiast"}},
+			d:        types.Diversity{MinSceptics: 1, MinEnthusiasts: 1},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"attitude: sceptic"}}},
+			problems: 1,
+		},
+		{
+			name:     "one persona takes several needs",
+			personas: []types.Persona{{Sector: "Retail", Age: "30"}, {Sector: "Retail", Age: "35"}},
+			d:        types.Diversity{DistinctSectors: true, MinAgeSpread: 20},
+			want:     []DiversityIssue{{Persona: 1, Needs: []string{"sector: other than Retail", "age: at least 50"}}},
+			problems: 2,
+		},
+		{
+			name:     "no constraints",
+			personas: []types.Persona{{Sector: "Retail"}, {Sector: "Retail"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, issues := CheckDiversity(tt.personas, tt.d)
+			if len(problems) != tt.problems {
+				t.Errorf("problems = %q, want %d", problems, tt.problems)
+			}
+			if len(issues) != len(tt.want) || len(issues) > 0 && !reflect.DeepEqual(issues, tt.want) {
+				t.Errorf("issues = %+v, want %+v", issues, tt.want)
+			}
+		})
+	}
+}
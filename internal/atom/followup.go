@@ -0,0 +1,23 @@
+package atom
+
+import "strings"
+
+// answerSuffixes end the titles of the notes holding a persona's answers, longest first
+var answerSuffixes = []string{" Followup Answer", " Interview Answer", " Meta Answer", " Answer"}
+
+// FollowupAnswerTitle returns the title of a follow-up answer by the persona named name
+func FollowupAnswerTitle(name string) string {
+	return name + " Followup Answer"
+}
+
+// PersonaAnswerName reads the persona name in the title of an answer note,
+// "Jane Doe Answer", "Jane Doe Meta Answer", "Jane Doe Followup Answer" and so on
+func PersonaAnswerName(title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	for _, suffix := range answerSuffixes {
+		if name, ok := strings.CutSuffix(title, suffix); ok && strings.TrimSpace(name) != "" {
+			return strings.TrimSpace(name), true
+		}
+	}
+	return "", false
+}
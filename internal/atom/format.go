@@ -2,63 +2,339 @@ package atom
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
+	"unicode"
 
 	"github.com/jaypaulb/AI-personas/internal/types"
 )
 
+// Persona note labels for the coverage and psychographic fields, which follow the
+// core fields and are left out when empty
+const (
+	sectorLabel            = "🏭 Sector: "
+	regionLabel            = "🗺 Region: "
+	companySizeLabel       = "🏢 Company Size: "
+	marketLabel            = "🤝 Market: "
+	attitudeLabel          = "🌡 Attitude: "
+	painPointsLabel        = "😣 Pain Points: "
+	objectionsLabel        = "🤔 Objections: "
+	budgetLabel            = "💰 Budget: "
+	decisionAuthorityLabel = "🗳 Decision Authority: "
+	techSavvinessLabel     = "💻 Tech-Savviness: "
+	channelsLabel          = "📣 Channels: "
+	brandAffinitiesLabel   = "🏷 Brand Affinities: "
+	personalityLabel       = "🧠 Personality: "
+)
+
 // FormatPersonaNote formats a persona for display in a Canvus note
 func FormatPersonaNote(p types.Persona) string {
-	return fmt.Sprintf(
+	text := fmt.Sprintf(
 		"🧑 Name: %s\n\n💼 Role: %s\n\n📝 Description: %s\n\n🏫 Background: %s\n\n🎯 Goals: %s\n\n🎂 Age: %s\n\n⚧ Sex: %s\n\n🌍 Race: %s",
 		p.Name, p.Role, p.Description, p.Background, string(p.Goals), string(p.Age), p.Sex, p.Race,
 	)
+	var b strings.Builder
+	b.WriteString(text)
+	for _, field := range []struct{ label, value string }{
+		{sectorLabel, p.Sector},
+		{regionLabel, p.Region},
+		{companySizeLabel, p.CompanySize},
+		{marketLabel, p.Market},
+		{attitudeLabel, p.Attitude},
+		{painPointsLabel, p.PainPoints.String()},
+		{objectionsLabel, p.Objections.String()},
+		{budgetLabel, p.Budget},
+		{decisionAuthorityLabel, p.DecisionAuthority},
+		{techSavvinessLabel, p.TechSavviness},
+		{channelsLabel, p.Channels.String()},
+		{brandAffinitiesLabel, p.BrandAffinities.String()},
+		{personalityLabel, p.Personality.String()},
+	} {
+		if field.value != "" {
+			b.WriteString("\n\n" + field.label + field.value)
+		}
+	}
+	for _, f := range p.Extra {
+		if f.Label != "" {
+			b.WriteString("\n\n" + f.Label + ": " + f.Value)
+		} else {
+			b.WriteString("\n\n" + f.Value)
+		}
+	}
+	return b.String()
+}
+
+// personaNoteKeys maps normalized note labels to persona fields. Lines starting
+// with a Big Five trait are read into the personality as well.
+var personaNoteKeys = map[string]string{
+	"name":               "name",
+	"role":               "role",
+	"job":                "role",
+	"description":        "description",
+	"background":         "background",
+	"goals":              "goals",
+	"goal":               "goals",
+	"age":                "age",
+	"sex":                "sex",
+	"gender":             "sex",
+	"race":               "race",
+	"ethnicity":          "race",
+	"sector":             "sector",
+	"industry":           "sector",
+	"region":             "region",
+	"location":           "region",
+	"company size":       "company_size",
+	"market":             "market",
+	"attitude":           "attitude",
+	"pain points":        "pain_points",
+	"objections":         "objections",
+	"budget":             "budget",
+	"income":             "budget",
+	"decision authority": "decision_authority",
+	"tech savviness":     "tech_savviness",
+	"tech savvy":         "tech_savviness",
+	"channels":           "channels",
+	"preferred channels": "channels",
+	"brand affinities":   "brand_affinities",
+	"brands":             "brand_affinities",
+	"personality":        "personality",
+	"big five":           "personality",
+	"openness":           "personality",
+	"conscientiousness":  "personality",
+	"extraversion":       "personality",
+	"agreeableness":      "personality",
+	"neuroticism":        "personality",
+}
+
+// maxNoteLabelWords is the longest label of an unknown field; longer text before
+// a colon is treated as part of the previous field's value
+const maxNoteLabelWords = 3
+
+// normalizeNoteLabel strips leading emoji and symbols from a label and lower-cases it,
+// so "🎯 Goals", "goals" and "Tech-Savviness" all match their field
+func normalizeNoteLabel(label string) string {
+	label = strings.TrimLeftFunc(label, func(r rune) bool { return !unicode.IsLetter(r) })
+	label = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' {
+			return ' '
+		}
+		return unicode.ToLower(r)
+	}, label)
+	return strings.Join(strings.Fields(label), " ")
 }
 
-// ParsePersonaNote parses a persona note text into a Persona struct
+// isWordLabel reports whether a normalized label is only letters and spaces, so
+// text such as "Goal 2: ..." continues the previous field instead of starting one
+func isWordLabel(label string) bool {
+	for _, r := range label {
+		if !unicode.IsLetter(r) && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// listNoteKeys are the fields whose value may run over several lines of items,
+// such as "1. Reduce costs: by a tenth"
+var listNoteKeys = map[string]bool{
+	"goals":            true,
+	"pain_points":      true,
+	"objections":       true,
+	"channels":         true,
+	"brand_affinities": true,
+}
+
+// isListItem reports whether a line starts with a number or bullet
+func isListItem(line string) bool {
+	for _, r := range line {
+		return unicode.IsDigit(r) || strings.ContainsRune("-*•·", r)
+	}
+	return false
+}
+
+// ParsePersonaNote parses a persona note text into a Persona struct. Fields are read
+// line by line as "Label: value" in any order, with or without their emoji; lines
+// without a label continue the previous field. Lines with a label that is not a
+// persona field are kept in Extra, as is each paragraph added after them, unless
+// they are items of a list field such as Goals: numbered or bulleted lines, or
+// lines not set apart from the list by a blank line.
 func ParsePersonaNote(text string) types.Persona {
-	p := types.Persona{}
-	// Use regex to extract fields
-	re := regexp.MustCompile(`(?m)^🧑 Name: (.*)[\s\S]*^💼 Role: (.*)[\s\S]*^📝 Description: (.*)[\s\S]*^🏫 Background: (.*)[\s\S]*^🎯 Goals: (.*)[\s\S]*^🎂 Age: (.*)[\s\S]*^⚧ Sex: (.*)[\s\S]*^🌍 Race: (.*)$`)
-	matches := re.FindStringSubmatch(text)
-	if len(matches) == 9 {
-		p.Name = matches[1]
-		p.Role = matches[2]
-		p.Description = matches[3]
-		p.Background = matches[4]
-		p.Goals = types.GoalsString(matches[5])
-		p.Age = types.AgeString(matches[6])
-		p.Sex = matches[7]
-		p.Race = matches[8]
+	values := make(map[string][]string)
+	var p types.Persona
+	key := ""
+	extra := -1 // index in p.Extra of the field being continued, if key is ""
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = true
+			continue
+		}
+		newParagraph := blank
+		blank = false
+		if label, value, ok := strings.Cut(line, ":"); ok {
+			norm := normalizeNoteLabel(label)
+			if k, known := personaNoteKeys[norm]; known {
+				key, extra = k, -1
+				if k == "personality" && norm != "personality" && norm != "big five" {
+					value = line // a trait line such as "Openness: high; Neuroticism: low"
+				}
+				values[key] = append(values[key], strings.TrimSpace(value))
+				continue
+			}
+			item := isListItem(line) || listNoteKeys[key] && !newParagraph
+			if words := len(strings.Fields(norm)); words > 0 && words <= maxNoteLabelWords && isWordLabel(norm) && !item {
+				key = ""
+				p.Extra = append(p.Extra, types.NoteField{Label: strings.TrimSpace(label), Value: strings.TrimSpace(value)})
+				extra = len(p.Extra) - 1
+				continue
+			}
+		}
+		switch {
+		case key != "":
+			values[key] = append(values[key], line)
+		case extra >= 0 && !newParagraph:
+			p.Extra[extra].Value = strings.TrimSpace(p.Extra[extra].Value + " " + line)
+		default:
+			p.Extra = append(p.Extra, types.NoteField{Value: line})
+			extra = len(p.Extra) - 1
+		}
+	}
+
+	text1 := func(k string) string {
+		return strings.Join(values[k], " ")
+	}
+	list := func(k string) types.StringList {
+		var l types.StringList
+		for _, v := range values[k] {
+			l = append(l, types.ParseStringList(v)...)
+		}
+		return l
 	}
+	p.Name = text1("name")
+	p.Role = text1("role")
+	p.Description = text1("description")
+	p.Background = text1("background")
+	p.Goals = types.GoalsString(strings.TrimSpace(strings.Join(values["goals"], "\n")))
+	p.Age = types.AgeString(text1("age"))
+	p.Sex = text1("sex")
+	p.Race = text1("race")
+	p.Sector = text1("sector")
+	p.Region = text1("region")
+	p.CompanySize = text1("company_size")
+	p.Market = text1("market")
+	p.Attitude = text1("attitude")
+	p.PainPoints = list("pain_points")
+	p.Objections = list("objections")
+	p.Budget = text1("budget")
+	p.DecisionAuthority = text1("decision_authority")
+	p.TechSavviness = text1("tech_savviness")
+	p.Channels = list("channels")
+	p.BrandAffinities = list("brand_affinities")
+	p.Personality = types.ParsePersonality(strings.Join(values["personality"], "; "))
 	return p
 }
 
-// GenerateSystemPrompt returns a detailed system prompt for a persona in a focus group
-func GenerateSystemPrompt(persona types.Persona, businessContext string) string {
-	return fmt.Sprintf(`Assume the role of the following persona for a business focus group. You are a client or potential client of the business. You are in a general purpose focus group for the business. Here is the business outline:
-
-%s
-
-Persona:
-Name: %s
-Role: %s
-Description: %s
-Background: %s
-Goals: %s
-Age: %s
-Sex: %s
-Race: %s
-
-When asked a question or provided with some info, you must only respond as the persona assigned and in the voice of that persona. Your responses should be short and sweet and structured as if given verbally. You should not repeat the question or reiterate points from the question as this would not be natural for a conversational style interaction verbally. Do not start your answer by restating the question. Do not use phrases like 'As a persona...' or 'If I were...'. Just answer as if you are the person.`,
-		businessContext,
-		persona.Name,
-		persona.Role,
-		persona.Description,
-		persona.Background,
-		persona.Goals,
-		persona.Age,
-		persona.Sex,
-		persona.Race,
-	)
+// personaNoteCoreFields are the fields every persona note must have, by their note label
+func personaNoteCoreFields(p types.Persona) []struct{ label, value string } {
+	return []struct{ label, value string }{
+		{"Name", p.Name},
+		{"Role", p.Role},
+		{"Description", p.Description},
+		{"Background", p.Background},
+		{"Goals", string(p.Goals)},
+		{"Age", string(p.Age)},
+		{"Sex", p.Sex},
+		{"Race", p.Race},
+	}
+}
+
+// ChangedPersonaFields returns the note labels of the fields that differ between old and p
+func ChangedPersonaFields(old, p types.Persona) []string {
+	fields := func(p types.Persona) []struct{ label, value string } {
+		extra := make([]string, 0, len(p.Extra))
+		for _, f := range p.Extra {
+			extra = append(extra, f.Label+": "+f.Value)
+		}
+		return append(personaNoteCoreFields(p), []struct{ label, value string }{
+			{"Sector", p.Sector},
+			{"Region", p.Region},
+			{"Company Size", p.CompanySize},
+			{"Market", p.Market},
+			{"Attitude", p.Attitude},
+			{"Pain Points", p.PainPoints.String()},
+			{"Objections", p.Objections.String()},
+			{"Budget", p.Budget},
+			{"Decision Authority", p.DecisionAuthority},
+			{"Tech-Savviness", p.TechSavviness},
+			{"Channels", p.Channels.String()},
+			{"Brand Affinities", p.BrandAffinities.String()},
+			{"Personality", p.Personality.String()},
+			{"Other", strings.Join(extra, "\n")},
+		}...)
+	}
+	before, after := fields(old), fields(p)
+	var changed []string
+	for i := range after {
+		if before[i].value != after[i].value {
+			changed = append(changed, after[i].label)
+		}
+	}
+	return changed
+}
+
+// MissingPersonaFields returns the labels of the core fields that are empty in p.
+// The coverage and psychographic fields are optional, since notes created before them lack them.
+func MissingPersonaFields(p types.Persona) []string {
+	var missing []string
+	for _, f := range personaNoteCoreFields(p) {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.label)
+		}
+	}
+	return missing
+}
+
+// MergePersona returns p with its empty fields filled in from fallback. Extra is
+// taken from p only, since it reflects what is written on the note.
+func MergePersona(p, fallback types.Persona) types.Persona {
+	str := func(v *string, fb string) {
+		if strings.TrimSpace(*v) == "" {
+			*v = fb
+		}
+	}
+	list := func(v *types.StringList, fb types.StringList) {
+		if len(*v) == 0 {
+			*v = fb
+		}
+	}
+	str(&p.Name, fallback.Name)
+	str(&p.Role, fallback.Role)
+	str(&p.Description, fallback.Description)
+	str(&p.Background, fallback.Background)
+	if strings.TrimSpace(string(p.Goals)) == "" {
+		p.Goals = fallback.Goals
+	}
+	if strings.TrimSpace(string(p.Age)) == "" {
+		p.Age = fallback.Age
+	}
+	str(&p.Sex, fallback.Sex)
+	str(&p.Race, fallback.Race)
+	str(&p.Sector, fallback.Sector)
+	str(&p.Region, fallback.Region)
+	str(&p.CompanySize, fallback.CompanySize)
+	str(&p.Market, fallback.Market)
+	str(&p.Attitude, fallback.Attitude)
+	list(&p.PainPoints, fallback.PainPoints)
+	list(&p.Objections, fallback.Objections)
+	str(&p.Budget, fallback.Budget)
+	str(&p.DecisionAuthority, fallback.DecisionAuthority)
+	str(&p.TechSavviness, fallback.TechSavviness)
+	list(&p.Channels, fallback.Channels)
+	list(&p.BrandAffinities, fallback.BrandAffinities)
+	str(&p.Personality.Openness, fallback.Personality.Openness)
+	str(&p.Personality.Conscientiousness, fallback.Personality.Conscientiousness)
+	str(&p.Personality.Extraversion, fallback.Personality.Extraversion)
+	str(&p.Personality.Agreeableness, fallback.Personality.Agreeableness)
+	str(&p.Personality.Neuroticism, fallback.Personality.Neuroticism)
+	return p
 }
@@ -0,0 +1,90 @@
+package atom
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+func TestPersonaNoteRoundTrip(t *testing.T) {
+	full := types.Persona{
+		Name:              "Maria Lopez",
+		Role:              "Operations Manager",
+		Description:       "Runs a regional logistics team.",
+		Background:        "Ten years in freight; MBA from Madrid.",
+		Goals:             "1. Reduce costs: cut overtime by a tenth\n2. Growth: expand into EU\n- Hiring: two planners",
+		Age:               "42",
+		Sex:               "Female",
+		Race:              "Hispanic",
+		Sector:            "Logistics",
+		Region:            "Europe",
+		CompanySize:       "mid-size",
+		Market:            "B2B",
+		Attitude:          "sceptic",
+		PainPoints:        types.StringList{"manual scheduling", "driver churn"},
+		Objections:        types.StringList{"price"},
+		Budget:            "€50k a year",
+		DecisionAuthority: "final approver",
+		TechSavviness:     "medium",
+		Channels:          types.StringList{"LinkedIn", "trade shows"},
+		BrandAffinities:   types.StringList{"SAP"},
+		Personality:       types.Personality{Openness: "high", Conscientiousness: "high", Extraversion: "low", Agreeableness: "medium", Neuroticism: "low"},
+		Extra:             []types.NoteField{{Label: "⭐ Favourite quote", Value: "Measure twice"}, {Value: "Met at the Lisbon workshop"}},
+	}
+	// The core fields only, so an unknown field follows a list field
+	core := types.Persona{
+		Name:       "Ken Sato",
+		Role:       "CTO",
+		Goals:      "Growth: double the team",
+		Age:        "35",
+		Channels:   types.StringList{"podcasts"},
+		Extra:      []types.NoteField{{Label: "Hobbies", Value: "golf"}},
+		PainPoints: types.StringList{"legacy code"},
+	}
+	for name, p := range map[string]types.Persona{"full": full, "core": core} {
+		t.Run(name, func(t *testing.T) {
+			got := ParsePersonaNote(FormatPersonaNote(p))
+			if !reflect.DeepEqual(got, p) {
+				t.Errorf("round trip changed the persona\n got: %+v\nwant: %+v", got, p)
+			}
+		})
+	}
+}
+
+func TestParsePersonaNoteListItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantGoals string
+		wantExtra []types.NoteField
+	}{
+		{
+			name:      "labelled goal items stay in goals",
+			text:      "🎯 Goals: Growth: expand into EU\nRetention: keep churn low",
+			wantGoals: "Growth: expand into EU\nRetention: keep churn low",
+		},
+		{
+			name:      "numbered and bulleted items stay in goals",
+			text:      "Goals:\n\n1. Reduce costs: by a tenth\n\n• Hiring: two planners",
+			wantGoals: "1. Reduce costs: by a tenth\n• Hiring: two planners",
+		},
+		{
+			name:      "a field set apart by a blank line ends the list",
+			text:      "Goals: Growth: expand into EU\n\nHobbies: golf",
+			wantGoals: "Growth: expand into EU",
+			wantExtra: []types.NoteField{{Label: "Hobbies", Value: "golf"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePersonaNote(tt.text)
+			if string(got.Goals) != tt.wantGoals {
+				t.Errorf("Goals = %q, want %q", got.Goals, tt.wantGoals)
+			}
+			if !reflect.DeepEqual(got.Extra, tt.wantExtra) {
+				t.Errorf("Extra = %+v, want %+v", got.Extra, tt.wantExtra)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package atom
+
+import "strings"
+
+// interviewPrefix starts the title of a note holding a question for one persona
+const interviewPrefix = "Interview:"
+
+// InterviewTitle returns the title of a note asking the persona named name a question
+func InterviewTitle(name string) string {
+	return interviewPrefix + " " + name
+}
+
+// ParseInterviewTitle reads the persona reference in an "Interview: <persona>"
+// title: a persona name or a persona note title such as "Internal Persona 2"
+func ParseInterviewTitle(title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	if len(title) < len(interviewPrefix) || !strings.EqualFold(title[:len(interviewPrefix)], interviewPrefix) {
+		return "", false
+	}
+	ref := strings.TrimSpace(title[len(interviewPrefix):])
+	return ref, ref != ""
+}
+
+// InterviewQuestionTitle returns the title of an answered interview question to the persona named name
+func InterviewQuestionTitle(name string) string {
+	return name + " Interview Question"
+}
+
+// InterviewAnswerTitle returns the title of an interview answer by the persona named name
+func InterviewAnswerTitle(name string) string {
+	return name + " Interview Answer"
+}
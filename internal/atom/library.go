@@ -0,0 +1,67 @@
+package atom
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LibraryNote is the request written on a Save_Persona or Import_Personas note
+type LibraryNote struct {
+	Personas []string // "all", a slot number, "Persona N" or a persona name
+	Tags     []string
+	IDs      []string // persona library entry IDs
+}
+
+// splitItems splits a comma or semicolon separated list, dropping empty items
+func splitItems(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseLibraryNote reads a Save_Persona or Import_Personas note. "Tags:" and
+// "IDs:" lines list tags and library entry IDs; any other line names personas.
+// Items on a line are separated by commas or semicolons.
+func ParseLibraryNote(text string) LibraryNote {
+	var n LibraryNote
+	for _, line := range strings.Split(text, "\n") {
+		value := line
+		label := ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			label, value = normalizeNoteLabel(line[:i]), line[i+1:]
+		}
+		switch label {
+		case "tag", "tags", "industry", "industries":
+			n.Tags = append(n.Tags, splitItems(value)...)
+		case "id", "ids":
+			n.IDs = append(n.IDs, splitItems(value)...)
+		case "persona", "personas":
+			n.Personas = append(n.Personas, splitItems(value)...)
+		default:
+			// "Persona 2: Jane Doe" copied from a note title names a persona too
+			n.Personas = append(n.Personas, splitItems(line)...)
+		}
+	}
+	return n
+}
+
+// PersonaSlot reads a persona slot reference, "2" or "Persona 2", returning the
+// zero-based slot
+func PersonaSlot(ref string) (int, bool) {
+	ref = strings.TrimSpace(ref)
+	if len(ref) > len("persona") && strings.EqualFold(ref[:len("persona")], "persona") {
+		ref = strings.TrimSpace(ref[len("persona"):])
+	}
+	if i := strings.Index(ref, ":"); i >= 0 {
+		ref = strings.TrimSpace(ref[:i])
+	}
+	n, err := strconv.Atoi(ref)
+	if err != nil || n < 1 || n > 4 {
+		return 0, false
+	}
+	return n - 1, true
+}
@@ -0,0 +1,160 @@
+package atom
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// panelWords maps the words naming a panel in notes and questions to the panel
+var panelWords = map[string]types.Panel{
+	"customer":  types.PanelCustomer,
+	"customers": types.PanelCustomer,
+	"client":    types.PanelCustomer,
+	"clients":   types.PanelCustomer,
+	"internal":  types.PanelInternal,
+	"staff":     types.PanelInternal,
+	"employee":  types.PanelInternal,
+	"employees": types.PanelInternal,
+	"partner":   types.PanelPartner,
+	"partners":  types.PanelPartner,
+	"investor":  types.PanelInvestor,
+	"investors": types.PanelInvestor,
+}
+
+// ParsePanel reads a panel name such as "internal" or "Investors"
+func ParsePanel(s string) (types.Panel, bool) {
+	p, ok := panelWords[strings.ToLower(strings.TrimSpace(s))]
+	return p, ok
+}
+
+// PanelAnchorName returns the name of the anchor holding a panel's persona notes
+func PanelAnchorName(p types.Panel) string {
+	switch p {
+	case types.PanelInternal:
+		return "Internal Personas"
+	case types.PanelPartner:
+		return "Partner Personas"
+	case types.PanelInvestor:
+		return "Investor Personas"
+	}
+	return "Personas"
+}
+
+// PanelNotePrefix returns the start of a panel's persona note titles, before the
+// slot number: "Persona" for customers, "Internal Persona" and so on for the others
+func PanelNotePrefix(p types.Panel) string {
+	switch p {
+	case types.PanelInternal:
+		return "Internal Persona"
+	case types.PanelPartner:
+		return "Partner Persona"
+	case types.PanelInvestor:
+		return "Investor Persona"
+	}
+	return "Persona"
+}
+
+// PersonaSlotLabel names slot (0-3) of a panel as its note titles do, e.g. "Internal Persona 2"
+func PersonaSlotLabel(p types.Panel, slot int) string {
+	return PanelNotePrefix(p) + " " + strconv.Itoa(slot+1)
+}
+
+// PersonaNoteTitle returns the title of the persona note in slot (0-3) of a panel
+func PersonaNoteTitle(p types.Panel, slot int, name string) string {
+	return PersonaSlotLabel(p, slot) + ": " + name
+}
+
+// ParsePersonaNoteTitle reads a persona note title, "Internal Persona 2: Jane Doe",
+// returning the panel, the zero-based slot and what follows the colon
+func ParsePersonaNoteTitle(title string) (types.Panel, int, string, bool) {
+	head, name, ok := strings.Cut(strings.TrimSpace(title), ":")
+	head = strings.TrimSpace(head)
+	i := strings.LastIndex(head, " ")
+	if !ok || i < 0 {
+		return "", 0, "", false
+	}
+	n, err := strconv.Atoi(head[i+1:])
+	if err != nil || n < 1 || n > 4 {
+		return "", 0, "", false
+	}
+	for _, p := range types.Panels {
+		if head[:i] == PanelNotePrefix(p) {
+			return p, n - 1, strings.TrimSpace(name), true
+		}
+	}
+	return "", 0, "", false
+}
+
+// CreatePersonasPanel reads the title of a note asking for personas,
+// "Create_Personas" for customers or "Create_Internal_Personas" and so on
+func CreatePersonasPanel(title string) (types.Panel, bool) {
+	title = strings.TrimSpace(title)
+	if title == "Create_Personas" {
+		return types.PanelCustomer, true
+	}
+	word, ok := strings.CutPrefix(title, "Create_")
+	if !ok {
+		return "", false
+	}
+	if word, ok = strings.CutSuffix(word, "_Personas"); !ok {
+		return "", false
+	}
+	p, ok := ParsePanel(word)
+	if !ok || p == types.PanelCustomer {
+		return "", false
+	}
+	return p, true
+}
+
+// ParsePanelTarget reads the panels a question is put to from a leading
+// "Internal:" or "[investors]" label; "All:" puts it to every panel. Without a
+// label panels is nil. Returns the question without the label.
+func ParsePanelTarget(question string) (panels []types.Panel, rest string) {
+	text := strings.TrimSpace(question)
+	var label string
+	switch {
+	case strings.HasPrefix(text, "["):
+		end := strings.Index(text, "]")
+		if end < 0 {
+			return nil, question
+		}
+		label, rest = text[1:end], text[end+1:]
+	default:
+		i := strings.Index(text, ":")
+		if i < 0 {
+			return nil, question
+		}
+		label, rest = text[:i], text[i+1:]
+	}
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "all" || label == "all panels" || label == "everyone" {
+		return types.Panels, strings.TrimSpace(rest)
+	}
+	seen := make(map[types.Panel]bool)
+	for _, word := range strings.FieldsFunc(label, func(r rune) bool { return r == ',' || r == '+' || r == '&' || r == '/' }) {
+		p, ok := ParsePanel(word)
+		if !ok {
+			return nil, question // an ordinary question that happens to contain a colon
+		}
+		if !seen[p] {
+			seen[p] = true
+			panels = append(panels, p)
+		}
+	}
+	if len(panels) == 0 {
+		return nil, question
+	}
+	return panels, strings.TrimSpace(rest)
+}
+
+// PanelDiversity returns the diversity constraints for a panel's personas. Only
+// customers must span sectors, regions, company sizes and markets; every panel
+// keeps the age spread and the mix of sceptics and enthusiasts.
+func PanelDiversity(d types.Diversity, p types.Panel) types.Diversity {
+	if p == types.PanelCustomer || p == "" {
+		return d
+	}
+	return types.Diversity{MinAgeSpread: d.MinAgeSpread, MinSceptics: d.MinSceptics, MinEnthusiasts: d.MinEnthusiasts}
+}
@@ -0,0 +1,43 @@
+package atom
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+func TestParsePanelTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		panels   []types.Panel
+		rest     string
+	}{
+		{"unlabelled", "How much would you pay?", nil, "How much would you pay?"},
+		{"unknown label", "Note: prices rise in May. Would you still buy?", nil, "Note: prices rise in May. Would you still buy?"},
+		{"colon later in the question", "What do you think of plan B: the cheaper one?", nil, "What do you think of plan B: the cheaper one?"},
+		{"panel label", "Internal: Can we support this?", []types.Panel{types.PanelInternal}, "Can we support this?"},
+		{"upper-case label", "INTERNAL: Can we support this?", []types.Panel{types.PanelInternal}, "Can we support this?"},
+		{"bracketed mixed-case label", "[Investors] Is this worth funding?", []types.Panel{types.PanelInvestor}, "Is this worth funding?"},
+		{"label synonym", "  staff:   Would you use it?  ", []types.Panel{types.PanelInternal}, "Would you use it?"},
+		{"all panels", "All: What should we build next?", types.Panels, "What should we build next?"},
+		{"all panels bracketed", "[Everyone] What should we build next?", types.Panels, "What should we build next?"},
+		{"combined labels", "Partners, customers: Is the price fair?", []types.Panel{types.PanelPartner, types.PanelCustomer}, "Is the price fair?"},
+		{"combined labels repeat a panel", "[Investor & investors + Staff] Thoughts?", []types.Panel{types.PanelInvestor, types.PanelInternal}, "Thoughts?"},
+		{"combined labels with an unknown word", "Partners/suppliers: Is the price fair?", nil, "Partners/suppliers: Is the price fair?"},
+		{"unclosed bracket", "[Investors Is this worth funding?", nil, "[Investors Is this worth funding?"},
+		{"empty label", ": Why?", nil, ": Why?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panels, rest := ParsePanelTarget(tt.question)
+			if !reflect.DeepEqual(panels, tt.panels) {
+				t.Errorf("panels = %v, want %v", panels, tt.panels)
+			}
+			if rest != tt.rest {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
@@ -1,8 +1,9 @@
 package atom
 
 import (
-	"os"
 	"strings"
+
+	"github.com/jaypaulb/AI-personas/internal/i18n"
 )
 
 // BuildConnectorPayload creates a Canvus connector payload between two widgets
@@ -26,21 +27,17 @@ func BuildConnectorPayload(srcID, dstID string) map[string]interface{} {
 	}
 }
 
-// GetAnswerGenerationMessage returns the appropriate wait message based on the model type
-func GetAnswerGenerationMessage() string {
-	model := os.Getenv("GEMINI_MODEL_CHAT")
-	if model == "" {
-		model = "gemini-2.5-flash" // Default
-	}
+// GetAnswerGenerationMessage returns the wait message in lang for the chat model
+func GetAnswerGenerationMessage(model, lang string) string {
 	modelLower := strings.ToLower(model)
 
 	if strings.Contains(modelLower, "flash-lite") {
-		return "Generating answers, please wait... This can take up to 30 seconds."
+		return i18n.T(lang, i18n.AnswersWaitSeconds, 30)
 	} else if strings.Contains(modelLower, "flash") {
-		return "Generating answers, please wait... This can take up to 60 seconds."
+		return i18n.T(lang, i18n.AnswersWaitSeconds, 60)
 	} else if strings.Contains(modelLower, "pro") {
-		return "Generating answers, please wait... This could take a few minutes as the model thinks about its answers."
+		return i18n.T(lang, i18n.AnswersWaitMinutes)
 	}
 	// Default message if model type can't be determined
-	return "Generating answers, please wait..."
+	return i18n.T(lang, i18n.AnswersWait)
 }
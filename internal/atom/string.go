@@ -27,6 +27,13 @@ func IsQuestion(text string) bool {
 	return false
 }
 
+// EndsWithQuestionMark reports whether text ends with "?" or the full-width "？"
+// used in Japanese, ignoring surrounding whitespace
+func EndsWithQuestionMark(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasSuffix(text, "?") || strings.HasSuffix(text, "？")
+}
+
 // StripMarkdownCodeBlock removes markdown code block delimiters from text
 func StripMarkdownCodeBlock(text string) string {
 	text = strings.TrimSpace(text)
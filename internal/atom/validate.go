@@ -0,0 +1,161 @@
+package atom
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// Persona age limits; personas are adult customers and decision-makers
+const (
+	minPersonaAge = 18
+	maxPersonaAge = 100
+)
+
+var ageNumberRegex = regexp.MustCompile(`\d+`)
+
+// singleLine collapses runs of whitespace, including newlines, into single spaces
+// so a field stays on its own line of a persona note
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// personalityLevels are the accepted Big Five trait levels
+var personalityLevels = map[string]bool{"low": true, "medium": true, "high": true}
+
+// markets maps the accepted spellings of a persona's market to the form written on notes
+var markets = map[string]string{"b2b": "B2B", "b2c": "B2C"}
+
+// attitudes maps the accepted spellings of a persona's attitude to its canonical form
+var attitudes = map[string]string{
+	"sceptic": types.AttitudeSceptic, "skeptic": types.AttitudeSceptic, "sceptical": types.AttitudeSceptic, "skeptical": types.AttitudeSceptic,
+	"neutral":    types.AttitudeNeutral,
+	"enthusiast": types.AttitudeEnthusiast, "enthusiastic": types.AttitudeEnthusiast,
+}
+
+// singleLineList tidies each item of a list, dropping empty ones. Semicolons
+// separate items in a persona note, so they are replaced inside an item.
+func singleLineList(l types.StringList) types.StringList {
+	var out types.StringList
+	for _, item := range l {
+		if item = singleLine(strings.ReplaceAll(item, ";", ",")); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// ValidatePersona returns p with its fields tidied and the problems that make it unusable
+func ValidatePersona(p types.Persona) (types.Persona, []string) {
+	p.Name = singleLine(p.Name)
+	p.Role = singleLine(p.Role)
+	p.Description = singleLine(p.Description)
+	p.Background = singleLine(p.Background)
+	p.Goals = types.GoalsString(strings.TrimSpace(string(p.Goals)))
+	p.Age = types.AgeString(singleLine(string(p.Age)))
+	p.Sex = singleLine(p.Sex)
+	p.Race = singleLine(p.Race)
+	p.Sector = singleLine(p.Sector)
+	p.Region = singleLine(p.Region)
+	p.CompanySize = singleLine(p.CompanySize)
+	p.Market = singleLine(p.Market)
+	if m, ok := markets[strings.ToLower(p.Market)]; ok {
+		p.Market = m
+	}
+	p.Attitude = strings.ToLower(singleLine(p.Attitude))
+	if a, ok := attitudes[p.Attitude]; ok {
+		p.Attitude = a
+	}
+	p.PainPoints = singleLineList(p.PainPoints)
+	p.Objections = singleLineList(p.Objections)
+	p.Budget = singleLine(p.Budget)
+	p.DecisionAuthority = singleLine(p.DecisionAuthority)
+	p.TechSavviness = singleLine(p.TechSavviness)
+	p.Channels = singleLineList(p.Channels)
+	p.BrandAffinities = singleLineList(p.BrandAffinities)
+	p.Personality.Openness = strings.ToLower(singleLine(p.Personality.Openness))
+	p.Personality.Conscientiousness = strings.ToLower(singleLine(p.Personality.Conscientiousness))
+	p.Personality.Extraversion = strings.ToLower(singleLine(p.Personality.Extraversion))
+	p.Personality.Agreeableness = strings.ToLower(singleLine(p.Personality.Agreeableness))
+	p.Personality.Neuroticism = strings.ToLower(singleLine(p.Personality.Neuroticism))
+
+	var problems []string
+	for _, field := range []struct{ name, value string }{
+		{"name", p.Name},
+		{"role", p.Role},
+		{"description", p.Description},
+		{"background", p.Background},
+		{"goals", string(p.Goals)},
+		{"age", string(p.Age)},
+		{"sex", p.Sex},
+		{"race", p.Race},
+		{"sector", p.Sector},
+		{"region", p.Region},
+		{"company_size", p.CompanySize},
+		{"market", p.Market},
+		{"attitude", p.Attitude},
+		{"pain_points", p.PainPoints.String()},
+		{"objections", p.Objections.String()},
+		{"budget", p.Budget},
+		{"decision_authority", p.DecisionAuthority},
+		{"tech_savviness", p.TechSavviness},
+		{"channels", p.Channels.String()},
+		{"brand_affinities", p.BrandAffinities.String()},
+	} {
+		if field.value == "" {
+			problems = append(problems, field.name+" is missing")
+		}
+	}
+	if p.Age != "" {
+		n, err := strconv.Atoi(ageNumberRegex.FindString(string(p.Age)))
+		if err != nil || n < minPersonaAge || n > maxPersonaAge {
+			problems = append(problems, fmt.Sprintf("age %q is not a number between %d and %d", p.Age, minPersonaAge, maxPersonaAge))
+		}
+	}
+	if _, ok := markets[strings.ToLower(p.Market)]; p.Market != "" && !ok {
+		problems = append(problems, fmt.Sprintf("market %q is not B2B or B2C", p.Market))
+	}
+	if _, ok := attitudes[p.Attitude]; p.Attitude != "" && !ok {
+		problems = append(problems, fmt.Sprintf("attitude %q is not sceptic, neutral or enthusiast", p.Attitude))
+	}
+	for _, trait := range p.Personality.Traits() {
+		if !personalityLevels[trait[1]] {
+			problems = append(problems, fmt.Sprintf("personality %s %q is not low, medium or high", strings.ToLower(trait[0]), trait[1]))
+		}
+	}
+	return p, problems
+}
+
+// ValidatePersonas checks a generated set of personas, expecting count of them.
+// It returns the usable personas, tidied and in order, and the problems found,
+// each prefixed with the persona's position.
+func ValidatePersonas(personas []types.Persona, count int) ([]types.Persona, []string) {
+	var valid []types.Persona
+	var problems []string
+	names := make(map[string]int, len(personas))
+	for i, p := range personas {
+		p, personaProblems := ValidatePersona(p)
+		key := strings.ToLower(p.Name)
+		if first, dup := names[key]; dup && key != "" {
+			personaProblems = append(personaProblems, fmt.Sprintf("name %q is already used by persona %d", p.Name, first))
+		} else {
+			names[key] = i + 1
+		}
+		if i >= count {
+			continue
+		}
+		for _, problem := range personaProblems {
+			problems = append(problems, fmt.Sprintf("persona %d: %s", i+1, problem))
+		}
+		if len(personaProblems) == 0 {
+			valid = append(valid, p)
+		}
+	}
+	if len(personas) != count {
+		problems = append(problems, fmt.Sprintf("expected %d personas, got %d", count, len(personas)))
+	}
+	return valid, problems
+}
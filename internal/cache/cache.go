@@ -0,0 +1,136 @@
+// Package cache provides a content-addressed on-disk cache for LLM responses,
+// so repeated prompts (demo retries, re-running Create_Personas on an unchanged
+// canvas) are answered without another API call.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Default cache settings
+const (
+	DefaultDir = ".cache/llm"
+	DefaultTTL = 7 * 24 * time.Hour
+)
+
+// entry is the on-disk representation of a cached response
+type entry struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Cache stores responses as JSON files named by the SHA-256 of their key parts
+type Cache struct {
+	Dir     string
+	TTL     time.Duration
+	Enabled bool
+}
+
+// New creates a cache rooted at dir whose entries expire after ttl (0 means never)
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl, Enabled: true}
+}
+
+// Disabled returns a cache that never hits and never stores
+func Disabled() *Cache {
+	return &Cache{}
+}
+
+// Key hashes the given parts into a cache key. Parts are length-prefixed so
+// that different splits of the same text never collide.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s;", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashText returns a short stable hash of text, for use as a key part
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *Cache) Get(key string) (string, bool) {
+	if c == nil || !c.Enabled {
+		return "", false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return "", false
+	}
+	if c.TTL > 0 && time.Since(e.CreatedAt) > c.TTL {
+		os.Remove(c.path(key))
+		return "", false
+	}
+	return e.Value, true
+}
+
+// Put stores value under key, replacing any existing entry
+func (c *Cache) Put(key, value string) error {
+	if c == nil || !c.Enabled {
+		return nil
+	}
+	p := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	data, err := json.Marshal(entry{Key: key, Value: value, CreatedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	// Write to a temp file and rename so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(p), "entry_*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
+
+// --- Global instance shared by all LLM calls ---
+var (
+	globalCache     *Cache
+	globalCacheOnce sync.Once
+)
+
+// SetGlobalCache replaces the process-wide cache. Call it at startup, before any LLM call.
+func SetGlobalCache(c *Cache) {
+	globalCacheOnce.Do(func() {})
+	globalCache = c
+}
+
+// GetGlobalCache returns the process-wide cache, using DefaultDir and DefaultTTL unless SetGlobalCache was called
+func GetGlobalCache() *Cache {
+	globalCacheOnce.Do(func() {
+		globalCache = New(DefaultDir, DefaultTTL)
+	})
+	return globalCache
+}
@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"log"
-	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -36,8 +36,22 @@ const (
 	TriggerCreatePersonasNote    = types.TriggerCreatePersonasNote
 	TriggerQnoteQuestionDetected = types.TriggerQnoteQuestionDetected
 	TriggerConnectorCreated      = types.TriggerConnectorCreated
+	TriggerCancelAIQuestion      = types.TriggerCancelAIQuestion
+	TriggerWidgetDeleted         = types.TriggerWidgetDeleted
+	TriggerPersonaNoteEdited     = types.TriggerPersonaNoteEdited
+	TriggerSavePersonaNote       = types.TriggerSavePersonaNote
+	TriggerImportPersonasNote    = types.TriggerImportPersonasNote
+	TriggerRegeneratePersona     = types.TriggerRegeneratePersona
+	TriggerInterviewQuestion     = types.TriggerInterviewQuestion
 )
 
+// personaNoteTitleRegex matches the titles of persona notes, "Persona N: <name>",
+// or "Internal Persona N: <name>" and so on for the other panels
+var personaNoteTitleRegex = regexp.MustCompile(`^(?:(?:Internal|Partner|Investor) )?Persona \d+: `)
+
+// regenerateTitleRegex matches a persona note retitled to ask for a new persona, "Persona N: REGENERATE"
+var regenerateTitleRegex = regexp.MustCompile(`(?i)^(?:(?:Internal|Partner|Investor) )?Persona \d+: REGENERATE$`)
+
 // QuestionHandlerEntry holds a handler and expected color for Qnote detection
 type QuestionHandlerEntry struct {
 	Color   string
@@ -52,9 +66,8 @@ type EventMonitorConfig struct {
 
 // DefaultEventMonitorConfig returns the default configuration
 func DefaultEventMonitorConfig() EventMonitorConfig {
-	debugMode := os.Getenv("DEBUG") == "1"
 	return EventMonitorConfig{
-		DebugMode:        debugMode,
+		DebugMode:        false,
 		DebounceDuration: 1 * time.Second,
 	}
 }
@@ -220,6 +233,12 @@ func (em *EventMonitor) processWidgetEvent(raw map[string]interface{}, triggers
 		Data:  raw,
 	}
 
+	// Deleted widgets arrive with state "deleted"; they must not fire creation triggers
+	if state, _ := raw["state"].(string); state == "deleted" {
+		triggers <- EventTrigger{Type: TriggerWidgetDeleted, Widget: widget}
+		return
+	}
+
 	// Flexible BAC_Complete image trigger (case-insensitive, ignores .png)
 	imageTitle := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(title), ".png"))
 	if widType == "Image" && imageTitle == "bac_complete" {
@@ -237,8 +256,14 @@ func (em *EventMonitor) processWidgetEvent(raw map[string]interface{}, triggers
 		return
 	}
 
-	// Detect Create_Personas note
-	if widType == "Note" && strings.TrimSpace(title) == "Create_Personas" {
+	// Detect a Qnote retitled to Cancel_AI_Question
+	if widType == "Note" && strings.EqualFold(strings.TrimSpace(title), "Cancel_AI_Question") {
+		triggers <- EventTrigger{Type: TriggerCancelAIQuestion, Widget: widget}
+		return
+	}
+
+	// Detect Create_Personas note, or Create_Internal_Personas and so on for the other panels
+	if _, ok := atom.CreatePersonasPanel(title); widType == "Note" && ok {
 		triggers <- EventTrigger{Type: TriggerCreatePersonasNote, Widget: widget}
 		return
 	}
@@ -249,10 +274,65 @@ func (em *EventMonitor) processWidgetEvent(raw map[string]interface{}, triggers
 		return
 	}
 
+	// Detect Save_Persona and Import_Personas notes, once typing pauses
+	if widType == "Note" && strings.EqualFold(strings.TrimSpace(title), "Save_Persona") {
+		em.debounce(widget, func(latest WidgetEvent) {
+			triggers <- EventTrigger{Type: TriggerSavePersonaNote, Widget: latest}
+		})
+		return
+	}
+	if widType == "Note" && strings.EqualFold(strings.TrimSpace(title), "Import_Personas") {
+		em.debounce(widget, func(latest WidgetEvent) {
+			triggers <- EventTrigger{Type: TriggerImportPersonasNote, Widget: latest}
+		})
+		return
+	}
+
+	// Detect questions put to a single persona, once typing pauses
+	if _, ok := atom.ParseInterviewTitle(title); widType == "Note" && ok {
+		em.debounce(widget, func(latest WidgetEvent) {
+			if atom.EndsWithQuestionMark(latest.Text) {
+				triggers <- EventTrigger{Type: TriggerInterviewQuestion, Widget: latest}
+			}
+		})
+		return
+	}
+
+	// Detect edits to persona notes and requests to regenerate one, once typing pauses
+	if widType == "Note" && personaNoteTitleRegex.MatchString(strings.TrimSpace(title)) && !strings.HasSuffix(strings.TrimSpace(title), ": FAILED") {
+		em.debounce(widget, func(latest WidgetEvent) {
+			if regenerateTitleRegex.MatchString(strings.TrimSpace(latest.Title)) {
+				triggers <- EventTrigger{Type: TriggerRegeneratePersona, Widget: latest}
+				return
+			}
+			triggers <- EventTrigger{Type: TriggerPersonaNoteEdited, Widget: latest}
+		})
+		return
+	}
+
 	// Handle Qnote question detection with debouncing
 	em.handleQnoteQuestionDetection(widget, raw, triggers)
 }
 
+// debounce calls fire with the latest event for the widget once no event for it
+// has arrived for DebounceDuration
+func (em *EventMonitor) debounce(widget WidgetEvent, fire func(WidgetEvent)) {
+	em.latestEvents.Store(widget.ID, widget)
+
+	if timerRaw, loaded := em.debounceTimers.LoadOrStore(widget.ID, nil); loaded && timerRaw != nil {
+		timerRaw.(*time.Timer).Stop()
+	}
+
+	timer := time.AfterFunc(em.Config.DebounceDuration, func() {
+		val, ok := em.latestEvents.Load(widget.ID)
+		if !ok {
+			return
+		}
+		fire(val.(WidgetEvent))
+	})
+	em.debounceTimers.Store(widget.ID, timer)
+}
+
 // handleQnoteQuestionDetection handles the debounced question detection for Qnotes
 func (em *EventMonitor) handleQnoteQuestionDetection(widget WidgetEvent, raw map[string]interface{}, triggers chan<- EventTrigger) {
 	if widget.Type != "Note" || widget.ID == "" || !strings.EqualFold(widget.Title, "New_AI_Question") {
@@ -273,26 +353,13 @@ func (em *EventMonitor) handleQnoteQuestionDetection(widget WidgetEvent, raw map
 		return
 	}
 
-	// Debounce logic: store latest event and reset timer
-	em.latestEvents.Store(widget.ID, widget)
-
-	if timerRaw, loaded := em.debounceTimers.LoadOrStore(widget.ID, nil); loaded && timerRaw != nil {
-		timerRaw.(*time.Timer).Stop()
-	}
-
-	timer := time.AfterFunc(em.Config.DebounceDuration, func() {
-		// On debounce expiry, check if latest event is a question
-		val, ok := em.latestEvents.Load(widget.ID)
-		if !ok {
-			return
-		}
-		latestWidget := val.(WidgetEvent)
+	// Debounce logic: on expiry, check if the latest event is a question
+	em.debounce(widget, func(latestWidget WidgetEvent) {
 		if IsQuestion(latestWidget.Text) {
 			triggers <- EventTrigger{Type: TriggerQnoteQuestionDetected, Widget: latestWidget}
 			entry.Handler(latestWidget)
 		}
 	})
-	em.debounceTimers.Store(widget.ID, timer)
 }
 
 // --- Backward compatibility functions ---
@@ -0,0 +1,305 @@
+// Package checkpoint persists the progress of question workflows on disk, so a
+// restart can resume an in-flight question (or clean up after it) instead of
+// leaving an amber Qnote and orphaned answer notes on the canvas.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDir is where checkpoints are stored unless configured otherwise
+const DefaultDir = ".state/workflows"
+
+// Status is the lifecycle state of a workflow
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Step names a workflow stage that has fully completed
+type Step string
+
+const (
+	StepAnswers     Step = "answers"
+	StepAnswerNotes Step = "answer_notes"
+	StepMetaAnswers Step = "meta_answers"
+	StepMetaNotes   Step = "meta_notes"
+	StepConnectors  Step = "connectors"
+	StepAnchor      Step = "anchor"
+)
+
+// PersonaProgress holds what has been produced for one persona
+type PersonaProgress struct {
+	Name         string `json:"name"`
+	Answer       string `json:"answer,omitempty"`
+	AnswerNoteID string `json:"answer_note_id,omitempty"`
+	MetaAnswer   string `json:"meta_answer,omitempty"`
+	MetaNoteID   string `json:"meta_note_id,omitempty"`
+	// PromptVersion identifies the prompt templates the answers were generated with
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+// Record is the checkpoint of one Qnote's workflow
+type Record struct {
+	QnoteID     string            `json:"qnote_id"`
+	Canvas      string            `json:"canvas"`
+	Question    string            `json:"question,omitempty"`
+	Status      Status            `json:"status"`
+	Steps       []Step            `json:"steps,omitempty"`
+	HelperID    string            `json:"helper_id,omitempty"`
+	Personas    []PersonaProgress `json:"personas,omitempty"`
+	Connectors  map[string]string `json:"connectors,omitempty"` // "src->dst" -> connector ID
+	AnchorID    string            `json:"anchor_id,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	StartedAt   time.Time         `json:"started_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// Done returns true if step has completed
+func (r *Record) Done(step Step) bool {
+	for _, s := range r.Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete marks step as completed
+func (r *Record) Complete(step Step) {
+	if !r.Done(step) {
+		r.Steps = append(r.Steps, step)
+	}
+}
+
+// Persona returns the progress entry for name, adding one if missing
+func (r *Record) Persona(name string) *PersonaProgress {
+	for i := range r.Personas {
+		if r.Personas[i].Name == name {
+			return &r.Personas[i]
+		}
+	}
+	r.Personas = append(r.Personas, PersonaProgress{Name: name})
+	return &r.Personas[len(r.Personas)-1]
+}
+
+// ConnectorKey is the Connectors map key for a connector from src to dst
+func ConnectorKey(src, dst string) string {
+	return src + "->" + dst
+}
+
+// CreatedWidgets returns the IDs of notes, connectors and the anchor created by the workflow (excluding the helper note)
+func (r *Record) CreatedWidgets() (notes, connectors []string, anchor string) {
+	for _, p := range r.Personas {
+		if p.AnswerNoteID != "" {
+			notes = append(notes, p.AnswerNoteID)
+		}
+		if p.MetaNoteID != "" {
+			notes = append(notes, p.MetaNoteID)
+		}
+	}
+	for _, id := range r.Connectors {
+		connectors = append(connectors, id)
+	}
+	sort.Strings(connectors)
+	return notes, connectors, r.AnchorID
+}
+
+func (r *Record) clone() Record {
+	c := *r
+	c.Steps = append([]Step(nil), r.Steps...)
+	c.Personas = append([]PersonaProgress(nil), r.Personas...)
+	if r.Connectors != nil {
+		c.Connectors = make(map[string]string, len(r.Connectors))
+		for k, v := range r.Connectors {
+			c.Connectors[k] = v
+		}
+	}
+	if r.CompletedAt != nil {
+		t := *r.CompletedAt
+		c.CompletedAt = &t
+	}
+	return c
+}
+
+// Store keeps one JSON file per Qnote under Dir
+type Store struct {
+	Dir string
+
+	// State - owned by this organism
+	mu      sync.Mutex
+	records map[string]*Record
+	loaded  bool
+}
+
+// New creates a store rooted at dir
+func New(dir string) *Store {
+	return &Store{Dir: dir, records: make(map[string]*Record)}
+}
+
+// loadLocked reads all records from disk on first use. Caller must hold s.mu.
+func (s *Store) loadLocked() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[checkpoint] Failed to read %s: %v", s.Dir, err)
+		}
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.Dir, e.Name()))
+		if err != nil {
+			log.Printf("[checkpoint] Failed to read %s: %v", e.Name(), err)
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil || rec.QnoteID == "" {
+			log.Printf("[checkpoint] Skipping unreadable checkpoint %s: %v", e.Name(), err)
+			continue
+		}
+		s.records[rec.QnoteID] = &rec
+	}
+}
+
+// saveLocked writes rec to disk atomically. Caller must hold s.mu.
+func (s *Store) saveLocked(rec *Record) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint dir: %w", err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	p := filepath.Join(s.Dir, rec.QnoteID+".json")
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Begin returns the running record for qnoteID, starting a new one if there is
+// none. resumed is true if an earlier run's progress was found.
+func (s *Store) Begin(canvas, qnoteID string) (rec Record, resumed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	if r, ok := s.records[qnoteID]; ok && r.Status == StatusRunning {
+		return r.clone(), true
+	}
+	now := time.Now()
+	r := &Record{QnoteID: qnoteID, Canvas: canvas, Status: StatusRunning, StartedAt: now, UpdatedAt: now}
+	s.records[qnoteID] = r
+	if err := s.saveLocked(r); err != nil {
+		log.Printf("[checkpoint] %v", err)
+	}
+	return r.clone(), false
+}
+
+// Update applies fn to the record for qnoteID and persists it. It is a no-op if there is no record.
+func (s *Store) Update(qnoteID string, fn func(*Record)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	r, ok := s.records[qnoteID]
+	if !ok {
+		return
+	}
+	fn(r)
+	r.UpdatedAt = time.Now()
+	if err := s.saveLocked(r); err != nil {
+		log.Printf("[checkpoint] %v", err)
+	}
+}
+
+// Finish marks the workflow as ended with status (and an optional error message)
+func (s *Store) Finish(qnoteID string, status Status, errMsg string) {
+	s.Update(qnoteID, func(r *Record) {
+		now := time.Now()
+		r.Status = status
+		r.Error = errMsg
+		r.CompletedAt = &now
+	})
+}
+
+// Get returns a copy of the record for qnoteID
+func (s *Store) Get(qnoteID string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	r, ok := s.records[qnoteID]
+	if !ok {
+		return Record{}, false
+	}
+	return r.clone(), true
+}
+
+// List returns copies of all records for canvas (all canvases if empty), oldest first
+func (s *Store) List(canvas string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	var out []Record
+	for _, r := range s.records {
+		if canvas == "" || r.Canvas == canvas {
+			out = append(out, r.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// Incomplete returns the records for canvas that are still marked running
+func (s *Store) Incomplete(canvas string) []Record {
+	var out []Record
+	for _, r := range s.List(canvas) {
+		if r.Status == StatusRunning {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// --- Global instance shared by all workflows ---
+var (
+	globalStore     *Store
+	globalStoreOnce sync.Once
+)
+
+// SetGlobalStore replaces the process-wide checkpoint store. Call it at startup, before any workflow runs.
+func SetGlobalStore(s *Store) {
+	globalStoreOnce.Do(func() {})
+	globalStore = s
+}
+
+// GetGlobalStore returns the process-wide checkpoint store, in DefaultDir unless SetGlobalStore was called
+func GetGlobalStore() *Store {
+	globalStoreOnce.Do(func() {
+		globalStore = New(DefaultDir)
+	})
+	return globalStore
+}
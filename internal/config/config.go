@@ -0,0 +1,553 @@
+// Package config loads the application configuration into a typed struct.
+// Values come from the built-in defaults, then an optional YAML file
+// (CONFIG_FILE, or config.yaml if present), then environment variables, so
+// existing .env setups keep working. The result is validated once at startup
+// and handed to each component.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/cache"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/library"
+	"github.com/jaypaulb/AI-personas/internal/personastore"
+	"github.com/jaypaulb/AI-personas/internal/prompts"
+	"github.com/jaypaulb/AI-personas/internal/queue"
+	"github.com/jaypaulb/AI-personas/internal/types"
+	"github.com/jaypaulb/AI-personas/internal/usage"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when CONFIG_FILE is unset and the file exists
+const DefaultFile = "config.yaml"
+
+// DefaultModel is used for persona generation and chat unless configured
+const DefaultModel = "gemini-2.5-flash"
+
+// DefaultQuestionTimeout is how long a Qnote waits for its question text
+const DefaultQuestionTimeout = 5 * time.Minute
+
+// Recovery modes accepted by workflow.recovery
+var recoveryModes = []string{"resume", "cleanup", "off"}
+
+// Log levels accepted by log_level
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// Config is the complete application configuration
+type Config struct {
+	Canvus   CanvusConfig   `yaml:"canvus"`
+	Gemini   GeminiConfig   `yaml:"gemini"`
+	OpenAI   OpenAIConfig   `yaml:"openai"`
+	Web      WebConfig      `yaml:"web"`
+	Workflow WorkflowConfig `yaml:"workflow"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Usage    UsageConfig    `yaml:"usage"`
+	Prompts  PromptsConfig  `yaml:"prompts"`
+	Personas PersonasConfig `yaml:"personas"`
+	Debug    bool           `yaml:"debug"`
+	LogLevel string         `yaml:"log_level"`
+}
+
+// CanvusConfig selects the canvases to serve and the default MCS connection
+type CanvusConfig struct {
+	Server             string `yaml:"server"`
+	APIKey             string `yaml:"api_key"`
+	CanvasID           string `yaml:"canvas_id"`
+	CanvasIDs          string `yaml:"canvas_ids"`
+	CanvasesFile       string `yaml:"canvases_file"`
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// GeminiConfig configures the Gemini models used for personas and answers
+type GeminiConfig struct {
+	APIKey        string  `yaml:"api_key"`
+	PersonasModel string  `yaml:"personas_model"`
+	ChatModel     string  `yaml:"chat_model"`
+	Temperature   float64 `yaml:"temperature"`
+	RateLimit     int     `yaml:"rate_limit"` // requests per minute, 0 = unlimited
+	RateBurst     int     `yaml:"rate_burst"` // 0 = a quarter of the limit
+}
+
+// OpenAIConfig configures persona image generation
+type OpenAIConfig struct {
+	APIKey    string `yaml:"api_key"`
+	RateLimit int    `yaml:"rate_limit"`
+	RateBurst int    `yaml:"rate_burst"`
+}
+
+// WebConfig configures the question page server
+type WebConfig struct {
+	Port      string `yaml:"port"`
+	PublicURL string `yaml:"public_url"`
+	// AdminToken must be sent as a bearer token to the library and persona
+	// endpoints; they are disabled without one
+	AdminToken string `yaml:"admin_token"`
+}
+
+// WorkflowConfig holds the question workflow tunables
+type WorkflowConfig struct {
+	ChatTokenLimit  int           `yaml:"chat_token_limit"`
+	QuestionTimeout time.Duration `yaml:"question_timeout"`
+	Concurrency     int           `yaml:"concurrency"`
+	Recovery        string        `yaml:"recovery"`
+	CheckpointDir   string        `yaml:"checkpoint_dir"`
+	PersonaDir      string        `yaml:"persona_dir"`
+	LibraryDir      string        `yaml:"library_dir"`
+	CostNotes       bool          `yaml:"cost_notes"`
+	// Keep a persona's conversation when an edit to its note re-seeds its chat
+	PersonaEditKeepHistory bool `yaml:"persona_edit_keep_history"`
+	// Replies in a conversation started by connecting two personas' answers
+	DialogueTurns int `yaml:"dialogue_turns"`
+	// Language of helper notes and persona answers: a language code or "auto" to
+	// detect it from the business notes. Canvases in the registry can set their own.
+	Language string `yaml:"language"`
+}
+
+// CacheConfig configures the on-disk LLM response cache
+type CacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Dir     string        `yaml:"dir"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+// UsageConfig configures cost accounting and spending caps (0 disables a cap)
+type UsageConfig struct {
+	PriceTableFile string  `yaml:"price_table_file"`
+	StateFile      string  `yaml:"state_file"` // canvas and daily totals, kept across restarts
+	CanvasTokens   int     `yaml:"budget_canvas_tokens"`
+	CanvasCost     float64 `yaml:"budget_canvas_cost_usd"`
+	DailyTokens    int     `yaml:"budget_daily_tokens"`
+	DailyCost      float64 `yaml:"budget_daily_cost_usd"`
+}
+
+// PromptsConfig selects the prompt templates. Canvases in the registry can
+// override them further with prompts_dir.
+type PromptsConfig struct {
+	// Dir holds .tmpl files replacing the built-in prompts of the same name
+	Dir string `yaml:"dir"`
+}
+
+// PersonasConfig configures persona generation
+type PersonasConfig struct {
+	// Diversity constraints checked after generation; personas breaking them are regenerated
+	Diversity types.Diversity `yaml:"diversity"`
+}
+
+// Markets accepted by personas.diversity.market
+var diversityMarkets = []string{"", types.MarketB2B, types.MarketB2C, types.MarketMixed}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Gemini: GeminiConfig{
+			PersonasModel: DefaultModel,
+			ChatModel:     DefaultModel,
+			Temperature:   0.7,
+		},
+		Web: WebConfig{Port: "8080"},
+		Workflow: WorkflowConfig{
+			ChatTokenLimit:         256,
+			QuestionTimeout:        DefaultQuestionTimeout,
+			Concurrency:            queue.DefaultConcurrency,
+			Recovery:               "resume",
+			CheckpointDir:          checkpoint.DefaultDir,
+			PersonaDir:             personastore.DefaultDir,
+			LibraryDir:             library.DefaultDir,
+			PersonaEditKeepHistory: true,
+			DialogueTurns:          4,
+			Language:               i18n.Auto,
+		},
+		Personas: PersonasConfig{
+			Diversity: types.Diversity{
+				DistinctSectors: true,
+				MinAgeSpread:    15,
+				MinSceptics:     1,
+				MinEnthusiasts:  1,
+			},
+		},
+		Usage: UsageConfig{StateFile: usage.DefaultStateFile},
+		Cache: CacheConfig{
+			Enabled: true,
+			Dir:     cache.DefaultDir,
+			TTL:     cache.DefaultTTL,
+		},
+		LogLevel: "info",
+	}
+}
+
+// ResolvePath returns path, else CONFIG_FILE, else DefaultFile if it exists, else ""
+func ResolvePath(path string) string {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		if _, err := os.Stat(DefaultFile); err == nil {
+			path = DefaultFile
+		}
+	}
+	return path
+}
+
+// Load builds the configuration from the defaults, the YAML file at path (or
+// CONFIG_FILE, or config.yaml if it exists) and the environment, then validates it
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path = ResolvePath(path); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the YAML file at path onto cfg. Unknown keys are rejected so typos are caught.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides cfg with the environment variables that configured the app
+// before the config file existed. Empty variables are ignored.
+func (c *Config) applyEnv() error {
+	e := &envReader{}
+	e.str("CANVUS_SERVER", &c.Canvus.Server)
+	e.str("CANVUS_API_KEY", &c.Canvus.APIKey)
+	e.str("CANVAS_ID", &c.Canvus.CanvasID)
+	e.str("CANVAS_IDS", &c.Canvus.CanvasIDs)
+	e.str("CANVASES_FILE", &c.Canvus.CanvasesFile)
+	e.str("CANVUS_CA_FILE", &c.Canvus.CAFile)
+	e.boolean("CANVUS_INSECURE_SKIP_VERIFY", &c.Canvus.InsecureSkipVerify)
+
+	e.str("GEMINI_API_KEY", &c.Gemini.APIKey)
+	e.str("GEMINI_MODEL_PERSONAS", &c.Gemini.PersonasModel)
+	e.str("GEMINI_MODEL_CHAT", &c.Gemini.ChatModel)
+	e.float("LLM_TEMP", &c.Gemini.Temperature)
+	e.integer("GEMINI_RATE_LIMIT", &c.Gemini.RateLimit)
+	e.integer("GEMINI_RATE_BURST", &c.Gemini.RateBurst)
+
+	e.str("OPENAI_API_KEY", &c.OpenAI.APIKey)
+	e.integer("OPENAI_RATE_LIMIT", &c.OpenAI.RateLimit)
+	e.integer("OPENAI_RATE_BURST", &c.OpenAI.RateBurst)
+
+	// PORT wins over the older WEB_PORT
+	e.str("WEB_PORT", &c.Web.Port)
+	e.str("PORT", &c.Web.Port)
+	e.str("PUBLIC_WEB_URL", &c.Web.PublicURL)
+	e.str("WEB_ADMIN_TOKEN", &c.Web.AdminToken)
+
+	e.integer("CHAT_TOKEN_LIMIT", &c.Workflow.ChatTokenLimit)
+	e.duration("QUESTION_TIMEOUT", &c.Workflow.QuestionTimeout)
+	e.integer("WORKFLOW_CONCURRENCY", &c.Workflow.Concurrency)
+	e.str("WORKFLOW_RECOVERY", &c.Workflow.Recovery)
+	e.str("CHECKPOINT_DIR", &c.Workflow.CheckpointDir)
+	e.str("PERSONA_DIR", &c.Workflow.PersonaDir)
+	e.str("LIBRARY_DIR", &c.Workflow.LibraryDir)
+	e.boolean("COST_NOTES", &c.Workflow.CostNotes)
+	e.boolean("PERSONA_EDIT_KEEP_HISTORY", &c.Workflow.PersonaEditKeepHistory)
+	e.integer("DIALOGUE_TURNS", &c.Workflow.DialogueTurns)
+	e.str("CANVAS_LANGUAGE", &c.Workflow.Language)
+
+	d := &c.Personas.Diversity
+	e.boolean("PERSONA_DISTINCT_SECTORS", &d.DistinctSectors)
+	e.list("PERSONA_SECTORS", &d.Sectors)
+	e.integer("PERSONA_MIN_AGE_SPREAD", &d.MinAgeSpread)
+	e.list("PERSONA_REGIONS", &d.Regions)
+	e.list("PERSONA_COMPANY_SIZES", &d.CompanySizes)
+	e.str("PERSONA_MARKET", &d.Market)
+	e.integer("PERSONA_MIN_SCEPTICS", &d.MinSceptics)
+	e.integer("PERSONA_MIN_ENTHUSIASTS", &d.MinEnthusiasts)
+
+	e.boolean("LLM_CACHE", &c.Cache.Enabled)
+	e.str("LLM_CACHE_DIR", &c.Cache.Dir)
+	e.duration("LLM_CACHE_TTL", &c.Cache.TTL)
+
+	e.str("PRICE_TABLE_FILE", &c.Usage.PriceTableFile)
+	e.str("USAGE_STATE_FILE", &c.Usage.StateFile)
+	e.integer("BUDGET_CANVAS_TOKENS", &c.Usage.CanvasTokens)
+	e.float("BUDGET_CANVAS_COST", &c.Usage.CanvasCost)
+	e.integer("BUDGET_DAILY_TOKENS", &c.Usage.DailyTokens)
+	e.float("BUDGET_DAILY_COST", &c.Usage.DailyCost)
+
+	e.str("PROMPTS_DIR", &c.Prompts.Dir)
+
+	e.boolean("DEBUG", &c.Debug)
+	e.str("LOG_LEVEL", &c.LogLevel)
+	return errors.Join(e.errs...)
+}
+
+// Validate checks that every value is within its allowed range
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Gemini.Temperature >= 0 && c.Gemini.Temperature <= 2, "gemini.temperature (LLM_TEMP) must be between 0 and 2, got %v", c.Gemini.Temperature)
+	check(c.Gemini.PersonasModel != "", "gemini.personas_model (GEMINI_MODEL_PERSONAS) must not be empty")
+	check(c.Gemini.ChatModel != "", "gemini.chat_model (GEMINI_MODEL_CHAT) must not be empty")
+	check(c.Gemini.RateLimit >= 0 && c.Gemini.RateBurst >= 0, "gemini rate limit and burst must not be negative")
+	check(c.OpenAI.RateLimit >= 0 && c.OpenAI.RateBurst >= 0, "openai rate limit and burst must not be negative")
+
+	port, err := strconv.Atoi(c.Web.Port)
+	check(err == nil && port > 0 && port <= 65535, "web.port (PORT) must be a port number, got %q", c.Web.Port)
+
+	check(c.Workflow.ChatTokenLimit >= 16 && c.Workflow.ChatTokenLimit <= 100000, "workflow.chat_token_limit (CHAT_TOKEN_LIMIT) must be between 16 and 100000, got %d", c.Workflow.ChatTokenLimit)
+	check(c.Workflow.QuestionTimeout >= 10*time.Second && c.Workflow.QuestionTimeout <= 24*time.Hour, "workflow.question_timeout (QUESTION_TIMEOUT) must be between 10s and 24h, got %v", c.Workflow.QuestionTimeout)
+	check(c.Workflow.Concurrency >= 1 && c.Workflow.Concurrency <= 64, "workflow.concurrency (WORKFLOW_CONCURRENCY) must be between 1 and 64, got %d", c.Workflow.Concurrency)
+	check(c.Workflow.DialogueTurns >= 1 && c.Workflow.DialogueTurns <= 12, "workflow.dialogue_turns (DIALOGUE_TURNS) must be between 1 and 12, got %d", c.Workflow.DialogueTurns)
+	languages := strings.Join(i18n.Languages(), ", ")
+	check(i18n.Supported(c.Workflow.Language), "workflow.language (CANVAS_LANGUAGE) must be auto or one of %s, got %q", languages, c.Workflow.Language)
+	for _, canvas := range c.Canvases() {
+		check(canvas.Language == "" || i18n.Supported(canvas.Language), "canvas %s: language must be auto or one of %s, got %q", canvas.Name, languages, canvas.Language)
+	}
+	check(oneOf(c.Workflow.Recovery, recoveryModes), "workflow.recovery (WORKFLOW_RECOVERY) must be one of %s, got %q", strings.Join(recoveryModes, ", "), c.Workflow.Recovery)
+	check(c.Workflow.CheckpointDir != "", "workflow.checkpoint_dir (CHECKPOINT_DIR) must not be empty")
+	check(c.Workflow.PersonaDir != "", "workflow.persona_dir (PERSONA_DIR) must not be empty")
+	check(c.Workflow.LibraryDir != "", "workflow.library_dir (LIBRARY_DIR) must not be empty")
+
+	d := c.Personas.Diversity
+	check(d.MinAgeSpread >= 0 && d.MinAgeSpread <= 82, "personas.diversity.min_age_spread (PERSONA_MIN_AGE_SPREAD) must be between 0 and 82, got %d", d.MinAgeSpread)
+	check(oneOf(d.Market, diversityMarkets), "personas.diversity.market (PERSONA_MARKET) must be b2b, b2c, mixed or empty, got %q", d.Market)
+	check(d.MinSceptics >= 0 && d.MinEnthusiasts >= 0 && d.MinSceptics+d.MinEnthusiasts <= 4, "personas.diversity.min_sceptics and min_enthusiasts (PERSONA_MIN_SCEPTICS, PERSONA_MIN_ENTHUSIASTS) must not be negative or add up to more than 4")
+
+	check(!c.Cache.Enabled || c.Cache.Dir != "", "cache.dir (LLM_CACHE_DIR) must not be empty")
+	check(c.Cache.TTL >= 0, "cache.ttl (LLM_CACHE_TTL) must not be negative")
+
+	check(c.Usage.CanvasTokens >= 0 && c.Usage.DailyTokens >= 0, "token budgets must not be negative")
+	check(c.Usage.CanvasCost >= 0 && c.Usage.DailyCost >= 0, "cost budgets must not be negative")
+
+	if _, err := c.LoadPrompts(); err != nil {
+		errs = append(errs, fmt.Errorf("prompts (PROMPTS_DIR): %w", err))
+	}
+
+	check(oneOf(c.LogLevel, logLevels), "log_level (LOG_LEVEL) must be one of %s, got %q", strings.Join(logLevels, ", "), c.LogLevel)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// oneOf reports whether v case-insensitively matches one of allowed
+func oneOf(v string, allowed []string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(v, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Masked returns a copy of the configuration with API keys masked, for printing
+func (c *Config) Masked() *Config {
+	m := *c
+	m.Canvus.APIKey = atom.MaskKey(c.Canvus.APIKey)
+	m.Gemini.APIKey = atom.MaskKey(c.Gemini.APIKey)
+	m.OpenAI.APIKey = atom.MaskKey(c.OpenAI.APIKey)
+	m.Web.AdminToken = atom.MaskKey(c.Web.AdminToken)
+	return &m
+}
+
+// String renders the effective configuration as YAML with API keys masked
+func (c *Config) String() string {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Masked()); err != nil {
+		return fmt.Sprintf("<unprintable config: %v>", err)
+	}
+	enc.Close()
+	return b.String()
+}
+
+// Registry builds the canvas registry from canvases_file, canvas_ids or canvas_id
+// (first one set wins), using the default server, key and TLS options for
+// canvases that do not set their own
+func (c CanvusConfig) Registry() (*canvusapi.Registry, error) {
+	var registry *canvusapi.Registry
+	var err error
+	switch {
+	case c.CanvasesFile != "":
+		registry, err = canvusapi.LoadRegistry(c.CanvasesFile)
+	case c.CanvasIDs != "":
+		registry, err = canvusapi.ParseCanvasList(c.CanvasIDs)
+	case c.CanvasID != "":
+		registry, err = canvusapi.NewRegistry([]canvusapi.Canvas{{Name: canvusapi.DefaultCanvasName, CanvasID: c.CanvasID}})
+	default:
+		return nil, fmt.Errorf("no canvas configured: set canvus.canvas_id, canvus.canvas_ids or canvus.canvases_file (CANVAS_ID, CANVAS_IDS or CANVASES_FILE)")
+	}
+	if err != nil {
+		return nil, err
+	}
+	registry.SetDefaults(c.Defaults())
+	return registry, nil
+}
+
+// LoadPrompts parses the prompt templates: prompts.dir over the built-in set, and
+// each registered canvas's prompts_dir over that. A canvas registry that fails to
+// load is left for the caller of Registry to report.
+func (c *Config) LoadPrompts() (*prompts.Sets, error) {
+	return prompts.Load(c.Prompts.Dir, c.canvasPromptDirs())
+}
+
+// canvasPromptDirs maps registered canvas names to their prompts_dir
+func (c *Config) canvasPromptDirs() map[string]string {
+	dirs := make(map[string]string)
+	for _, canvas := range c.Canvases() {
+		if canvas.PromptsDir != "" {
+			dirs[canvas.Name] = canvas.PromptsDir
+		}
+	}
+	return dirs
+}
+
+// CanvasLanguages maps each registered canvas name to its language code, which
+// is i18n.Auto if neither the canvas nor workflow.language sets one
+func (c *Config) CanvasLanguages() map[string]string {
+	languages := make(map[string]string)
+	for _, canvas := range c.Canvases() {
+		lang := canvas.Language
+		if lang == "" {
+			lang = c.Workflow.Language
+		}
+		languages[canvas.Name] = i18n.Normalize(lang)
+	}
+	return languages
+}
+
+// Canvases returns the registered canvases, or nil if the registry fails to load
+// (the error is left for the caller of Registry to report)
+func (c *Config) Canvases() []canvusapi.Canvas {
+	registry, err := c.Canvus.Registry()
+	if err != nil {
+		return nil
+	}
+	return registry.Canvases()
+}
+
+// PromptDirs returns prompts.dir and every registered canvas's prompts_dir that is set
+func (c *Config) PromptDirs() []string {
+	var dirs []string
+	if c.Prompts.Dir != "" {
+		dirs = append(dirs, c.Prompts.Dir)
+	}
+	for _, dir := range c.canvasPromptDirs() {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// Defaults returns the connection settings used for canvases that do not set their own
+func (c CanvusConfig) Defaults() canvusapi.Defaults {
+	return canvusapi.Defaults{
+		Server: c.Server,
+		APIKey: c.APIKey,
+		TLS:    canvusapi.TLSOptions{CAFile: c.CAFile, InsecureSkipVerify: c.InsecureSkipVerify},
+	}
+}
+
+// envReader parses environment overrides, collecting errors instead of silently falling back
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+func (e *envReader) str(key string, dst *string) {
+	if v, ok := e.lookup(key); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) integer(key string, dst *int) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a whole number", key, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) float(key string, dst *float64) {
+	if v, ok := e.lookup(key); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a number", key, v))
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envReader) boolean(key string, dst *bool) {
+	if v, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a boolean (use 1/0 or true/false)", key, v))
+			return
+		}
+		*dst = b
+	}
+}
+
+// list reads a comma separated list, dropping empty items
+func (e *envReader) list(key string, dst *[]string) {
+	if v, ok := e.lookup(key); ok {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+// duration accepts a number of seconds or a Go duration string such as "5m"
+func (e *envReader) duration(key string, dst *time.Duration) {
+	if v, ok := e.lookup(key); ok {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			*dst = time.Duration(seconds) * time.Second
+			return
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a duration (seconds or e.g. 5m)", key, v))
+			return
+		}
+		*dst = d
+	}
+}
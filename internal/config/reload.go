@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultReloadInterval is how often a Reloader checks its files for changes
+const DefaultReloadInterval = 2 * time.Second
+
+// Reloader loads a new configuration on SIGHUP or when one of its files changes
+// and passes it to the OnReload callbacks. A configuration that fails to load
+// or validate is logged and the current one is kept.
+type Reloader struct {
+	load     func() (*Config, error)
+	files    []string
+	interval time.Duration
+
+	// State - owned by this organism
+	mu        sync.Mutex
+	current   *Config
+	callbacks []func(old, cfg *Config)
+	modTimes  map[string]time.Time
+}
+
+// NewReloader creates a Reloader starting from current that calls load to read
+// a new configuration and watches files, which may be directories (missing
+// files are watched for creation)
+func NewReloader(current *Config, load func() (*Config, error), files ...string) *Reloader {
+	r := &Reloader{
+		load:     load,
+		files:    files,
+		interval: DefaultReloadInterval,
+		current:  current,
+		modTimes: make(map[string]time.Time),
+	}
+	for _, f := range files {
+		r.modTimes[f] = modTime(f)
+	}
+	return r
+}
+
+// OnReload registers fn to be called with the old and new configuration after
+// each successful reload
+func (r *Reloader) OnReload(fn func(old, cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks = append(r.callbacks, fn)
+}
+
+// Current returns the configuration last loaded
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the configuration and, if it is valid, applies it
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cfg, err := r.load()
+	if err != nil {
+		log.Printf("[config] Reload failed, keeping the current configuration: %v", err)
+		return err
+	}
+	old := r.current
+	r.current = cfg
+	for _, fn := range r.callbacks {
+		fn(old, cfg)
+	}
+	log.Printf("[config] Configuration reloaded")
+	return nil
+}
+
+// Start reloads on SIGHUP and whenever a watched file changes, until ctx is cancelled
+func (r *Reloader) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(r.interval)
+	go func() {
+		defer signal.Stop(hup)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				log.Printf("[config] Received SIGHUP, reloading configuration")
+				r.Reload()
+			case <-ticker.C:
+				if changed := r.changedFiles(); len(changed) > 0 {
+					log.Printf("[config] %v changed, reloading configuration", changed)
+					r.Reload()
+				}
+			}
+		}
+	}()
+}
+
+// changedFiles returns the watched files whose modification time changed since the last check
+func (r *Reloader) changedFiles() []string {
+	var changed []string
+	for _, f := range r.files {
+		t := modTime(f)
+		if !t.Equal(r.modTimes[f]) {
+			r.modTimes[f] = t
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// modTime returns the file's modification time, or the zero time if it does not
+// exist. For a directory it is the latest time of the directory and its files.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	latest := info.ModTime()
+	if info.IsDir() {
+		entries, _ := os.ReadDir(path)
+		for _, e := range entries {
+			if fi, err := e.Info(); err == nil && fi.ModTime().After(latest) {
+				latest = fi.ModTime()
+			}
+		}
+	}
+	return latest
+}
+
+// RestartRequired returns the settings that differ between old and cfg but only
+// take effect after a restart
+func RestartRequired(old, cfg *Config) []string {
+	var changed []string
+	check := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			changed = append(changed, name)
+		}
+	}
+	check("canvus", old.Canvus, cfg.Canvus)
+	check("web", old.Web, cfg.Web)
+	check("cache", old.Cache, cfg.Cache)
+	check("workflow.concurrency", old.Workflow.Concurrency, cfg.Workflow.Concurrency)
+	check("workflow.recovery", old.Workflow.Recovery, cfg.Workflow.Recovery)
+	check("workflow.checkpoint_dir", old.Workflow.CheckpointDir, cfg.Workflow.CheckpointDir)
+	check("workflow.persona_dir", old.Workflow.PersonaDir, cfg.Workflow.PersonaDir)
+	check("workflow.library_dir", old.Workflow.LibraryDir, cfg.Workflow.LibraryDir)
+	check("usage.price_table_file", old.Usage.PriceTableFile, cfg.Usage.PriceTableFile)
+	check("usage.state_file", old.Usage.StateFile, cfg.Usage.StateFile)
+	return changed
+}
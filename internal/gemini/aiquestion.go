@@ -2,11 +2,10 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"runtime/debug"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,34 +13,32 @@ import (
 	"github.com/jaypaulb/AI-personas/canvusapi"
 	"github.com/jaypaulb/AI-personas/internal/atom"
 	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
 	"github.com/jaypaulb/AI-personas/internal/timing"
+	"github.com/jaypaulb/AI-personas/internal/usage"
 )
 
 // MinRequiredAnswers is the minimum number of answers required for partial success
 const MinRequiredAnswers = 1
 
-// DefaultQuestionTimeout is the default timeout for waiting for question text (5 minutes)
-const DefaultQuestionTimeout = 5 * time.Minute
-
 // TimeoutHelperColor is the amber color for timeout helper notes
 const TimeoutHelperColor = "#ff9800ff"
 
-// getQuestionTimeout returns the configured question timeout from env var or default
-func getQuestionTimeout() time.Duration {
-	timeoutStr := os.Getenv("QUESTION_TIMEOUT")
-	if timeoutStr == "" {
-		return DefaultQuestionTimeout
-	}
-	// Try parsing as seconds first
-	if seconds, err := strconv.Atoi(timeoutStr); err == nil {
-		return time.Duration(seconds) * time.Second
-	}
-	// Try parsing as duration string (e.g., "5m", "300s")
-	if duration, err := time.ParseDuration(timeoutStr); err == nil {
-		return duration
-	}
-	log.Printf("[getQuestionTimeout] Invalid QUESTION_TIMEOUT value '%s', using default %v", timeoutStr, DefaultQuestionTimeout)
-	return DefaultQuestionTimeout
+// BudgetHelperColor is the red background color for budget refusal helper notes
+const BudgetHelperColor = "#f44336ff"
+
+// CostNoteColor is the light grey background color for per-question cost notes
+const CostNoteColor = "#f5f5f5ff"
+
+// costNotesEnabled returns true if workflow.cost_notes is set, enabling a "Cost" note after each Q&A
+func costNotesEnabled(ctx context.Context) bool {
+	return configFrom(ctx).Workflow.CostNotes
+}
+
+// getQuestionTimeout returns the configured question timeout
+func getQuestionTimeout(ctx context.Context) time.Duration {
+	return configFrom(ctx).Workflow.QuestionTimeout
 }
 
 // QuestionWorkflow manages the Q&A workflow state
@@ -51,6 +48,7 @@ type QuestionWorkflow struct {
 	processingList sync.Map // qnoteID -> true
 	waitChans      sync.Map // noteID -> chan struct{}
 	helperNotes    sync.Map // qnoteID -> helperNoteID
+	active         sync.Map // qnoteID -> *activeWorkflow
 }
 
 // NewQuestionWorkflow creates a new QuestionWorkflow instance
@@ -114,6 +112,7 @@ var answeredNotes = &globalQuestionWorkflow.answeredNotes
 var qnoteProcessingList = &globalQuestionWorkflow.processingList
 var qnoteWaitChans = &globalQuestionWorkflow.waitChans
 var qnoteHelperNotes = &globalQuestionWorkflow.helperNotes
+var activeWorkflows = &globalQuestionWorkflow.active
 
 // IsQnoteProcessing checks if the Qnote is already being processed.
 func IsQnoteProcessing(qnoteID string) bool {
@@ -183,7 +182,7 @@ func CheckQuestionPresent(qnoteID string, client *canvusapi.Client) bool {
 	}
 	currText, _ := qWidget["text"].(string)
 	trimmedText := strings.TrimSpace(currText)
-	if strings.HasSuffix(trimmedText, "?") {
+	if atom.EndsWithQuestionMark(trimmedText) {
 		return true
 	}
 	return false
@@ -213,8 +212,6 @@ func EnsureHelperNoteForQuestionWithCache(qnoteID string, client *canvusapi.Clie
 	qSize, _ := qWidget["size"].(map[string]interface{})
 	qw := qSize["width"].(float64)
 	qh := qSize["height"].(float64)
-	helperTitle := "Helper: Please enter a question for this note"
-
 	var widgets []map[string]interface{}
 	if cachedWidgets != nil {
 		widgets = cachedWidgets
@@ -236,7 +233,7 @@ func EnsureHelperNoteForQuestionWithCache(qnoteID string, client *canvusapi.Clie
 	for _, w := range widgets {
 		typeStr, _ := w["widget_type"].(string)
 		title, _ := w["title"].(string)
-		if typeStr == "Note" && title == helperTitle {
+		if typeStr == "Note" && i18n.Is(title, i18n.HelperQuestionTitle) {
 			helperID, _ = w["id"].(string)
 			found = true
 			break
@@ -245,9 +242,10 @@ func EnsureHelperNoteForQuestionWithCache(qnoteID string, client *canvusapi.Clie
 	if !found {
 		helperX := qx - 1.2*qw
 		helperY := qy - 0.33*qh
+		lang := canvasLanguage(client, widgets)
 		noteMeta := map[string]interface{}{
-			"title":            helperTitle,
-			"text":             "Please enter a question in the main note to begin the Q&A process.",
+			"title":            i18n.T(lang, i18n.HelperQuestionTitle),
+			"text":             i18n.T(lang, i18n.HelperQuestionText),
 			"location":         map[string]interface{}{"x": helperX, "y": helperY},
 			"size":             map[string]interface{}{"width": qw, "height": qh * 0.7},
 			"background_color": "#e0e0e0",
@@ -283,8 +281,6 @@ func OnQuestionDetected(qnoteID string, client *canvusapi.Client, chatTokenLimit
 // If cachedWidgets is provided, it will be used instead of fetching widgets again.
 func OnQuestionDetectedWithCache(qnoteID string, client *canvusapi.Client, chatTokenLimit int, cachedWidgets []map[string]interface{}) {
 	// Update helper note to 'Processing Question'
-	helperTitle := "Helper: Please enter a question for this note"
-
 	var widgets []map[string]interface{}
 	var err error
 	if cachedWidgets != nil {
@@ -302,10 +298,10 @@ func OnQuestionDetectedWithCache(qnoteID string, client *canvusapi.Client, chatT
 		for _, w := range widgets {
 			typeStr, _ := w["widget_type"].(string)
 			title, _ := w["title"].(string)
-			if typeStr == "Note" && title == helperTitle {
+			if typeStr == "Note" && i18n.Is(title, i18n.HelperQuestionTitle) {
 				noteID2, _ := w["id"].(string)
 				update := map[string]interface{}{
-					"text": "Processing Question...",
+					"text": i18n.T(canvasLanguage(client, widgets), i18n.HelperProcessing),
 				}
 				if _, err := client.UpdateNote(noteID2, update); err != nil {
 					log.Printf("[warn] UpdateNote failed for helper note %s: %v", noteID2, err)
@@ -324,9 +320,9 @@ func OnQuestionDetectedWithCache(qnoteID string, client *canvusapi.Client, chatT
 	AnswerQuestionWithCache(qnoteID, client, chatTokenLimit, widgets)
 }
 
-// getAnswerGenerationMessage returns the appropriate wait message based on the model type
-func getAnswerGenerationMessage() string {
-	return atom.GetAnswerGenerationMessage()
+// getAnswerGenerationMessage returns the wait message in lang for the workflow's chat model
+func getAnswerGenerationMessage(ctx context.Context, lang string) string {
+	return atom.GetAnswerGenerationMessage(configFrom(ctx).Gemini.ChatModel, lang)
 }
 
 // AnswerQuestion handles persona answers, meta-answers, note creation, and connectors.
@@ -344,18 +340,14 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		workflowTimer.StopAndLog(true)
 	}()
 
-	ctx := context.Background()
+	ctx := usage.WithScope(questionContext(qnoteID), usage.Scope{Canvas: client.CanvasID, Question: qnoteID})
 	defer func() {
 		qnoteProcessingList.Delete(qnoteID)
 	}()
 	qWidget, _ := client.GetNote(qnoteID, false)
 	currText, _ := qWidget["text"].(string)
 
-	// Get the appropriate wait message based on model type
-	waitMessage := getAnswerGenerationMessage()
-
 	// Create or update helper note to show "Generating answers, please wait..."
-	helperTitle := "Helper: Please enter a question for this note"
 	qLoc, _ := qWidget["location"].(map[string]interface{})
 	qSize, _ := qWidget["size"].(map[string]interface{})
 	qx := qLoc["x"].(float64)
@@ -379,11 +371,15 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		getWidgetsTimer.StopAndLog(err == nil)
 	}
 
+	// Get the appropriate wait message based on model type and canvas language
+	lang := canvasLanguage(client, widgets)
+	waitMessage := getAnswerGenerationMessage(ctx, lang)
+
 	if err == nil && widgets != nil {
 		for _, w := range widgets {
 			typeStr, _ := w["widget_type"].(string)
 			title, _ := w["title"].(string)
-			if typeStr == "Note" && title == helperTitle {
+			if typeStr == "Note" && i18n.Is(title, i18n.HelperQuestionTitle) {
 				helperID, _ = w["id"].(string)
 				// Update existing helper note
 				update := map[string]interface{}{
@@ -402,7 +398,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		helperX := qx - 1.2*qw
 		helperY := qy - 0.33*qh
 		noteMeta := map[string]interface{}{
-			"title":            helperTitle,
+			"title":            i18n.T(lang, i18n.HelperQuestionTitle),
 			"text":             waitMessage,
 			"location":         map[string]interface{}{"x": helperX, "y": helperY},
 			"size":             map[string]interface{}{"width": qw, "height": qh * 0.7},
@@ -420,31 +416,67 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		}
 	}
 
+	// Checkpoint progress so a restart can resume this workflow instead of orphaning it
+	store := checkpoint.GetGlobalStore()
+	rec, resumed := store.Begin(client.CanvasID, qnoteID)
+	if resumed {
+		log.Printf("[checkpoint] Resuming Qnote %s (completed steps: %v)", qnoteID, rec.Steps)
+	}
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.HelperID = helperID })
+
 	geminiClient, err := NewClient(ctx)
 	if err != nil {
+		log.Printf("[AnswerQuestion] Failed to create Gemini client: %v", err)
+		store.Finish(qnoteID, checkpoint.StatusFailed, err.Error())
 		return
 	}
-	// Ensure personas exist and get their IDs (pass cached widgets)
-	if _, ok := PersonaNoteIDs.Load(qnoteID); !ok {
-		err = CreatePersonasWithCache(ctx, qnoteID, client, widgets)
-		if err != nil {
-			log.Printf("[AnswerQuestion] CreatePersonas failed: %v", err)
-			return
+	question := currText
+	if idx := strings.Index(question, "-->"); idx != -1 {
+		question = question[idx+3:]
+	}
+	question = strings.TrimSpace(strings.Split(question, "Please wait")[0])
+
+	// A leading panel label ("Internal:", "All:") puts the question to other panels than the customers.
+	// The record keeps the label so a reset Qnote is put to the same panels when asked again.
+	asked := question
+	targets, question := atom.ParsePanelTarget(question)
+	panels, missingAnchors := questionPanels(targets, widgets)
+	if len(panels) == 0 {
+		log.Printf("[AnswerQuestion] No anchor on the canvas for the panels of Qnote %s: %v", qnoteID, missingAnchors)
+		if _, err := client.UpdateNote(helperID, map[string]interface{}{"text": i18n.T(lang, i18n.PanelMissingText, strings.Join(missingAnchors, ", "))}); err != nil {
+			log.Printf("[warn] UpdateNote failed for helper note %s: %v", helperID, err)
 		}
+		store.Finish(qnoteID, checkpoint.StatusFailed, "no anchor for the question's panels")
+		return
 	}
-	personas, err := FetchPersonasFromNotes(qnoteID, client)
-	if err != nil || len(personas) < MinRequiredPersonas {
-		// Try to recreate personas if not enough are available (pass cached widgets)
-		err = CreatePersonasWithCache(ctx, qnoteID, client, widgets)
+	// Ensure each panel's personas exist and get their IDs (pass cached widgets)
+	var personas []Persona
+	var panelOf, slotOf []int // each persona's index in panels and position within its panel
+	var personaErrors []error
+	for g, panel := range panels {
+		panelPersonas, err := ensurePanelPersonas(ctx, qnoteID, client, widgets, panel)
 		if err != nil {
-			log.Printf("[AnswerQuestion] CreatePersonas failed: %v", err)
-			return
+			log.Printf("[AnswerQuestion] No %s personas: %v", panel, err)
+			personaErrors = append(personaErrors, err)
+			continue
 		}
-		personas, err = FetchPersonasFromNotes(qnoteID, client)
-		if err != nil || len(personas) < MinRequiredPersonas {
-			log.Printf("[AnswerQuestion] Could not fetch minimum required personas (%d) after CreatePersonas: %v", MinRequiredPersonas, err)
-			return
+		for slot, p := range panelPersonas {
+			personas = append(personas, p)
+			panelOf = append(panelOf, g)
+			slotOf = append(slotOf, slot)
+		}
+	}
+	if len(personas) == 0 {
+		log.Printf("[AnswerQuestion] No personas to answer Qnote %s", qnoteID)
+		if budgetErr := budgetError(personaErrors); budgetErr != nil {
+			stopForBudget(client, qnoteID, budgetErr)
+		}
+		reason := "no personas to answer"
+		if err := errors.Join(personaErrors...); err != nil {
+			reason = err.Error()
 		}
+		store.Finish(qnoteID, checkpoint.StatusFailed, reason)
+		return
 	}
 
 	numPersonas := len(personas)
@@ -459,27 +491,36 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		scale = s
 	}
 	sessionManager := NewSessionManager(geminiClient.GenaiClient())
+	defer trackSessions(client.CanvasID, sessionManager)()
 	// --- Persona Q&A Workflow ---
-	question := currText
-	if idx := strings.Index(question, "-->"); idx != -1 {
-		question = question[idx+3:]
-	}
-	question = strings.TrimSpace(strings.Split(question, "Please wait")[0])
 
 	// Get business context (pass cached widgets to avoid redundant fetch)
 	businessContextStr, _, err := getBusinessContextWithCache(ctx, qnoteID, client, widgets)
 	if err != nil {
 		log.Printf("[AnswerQuestion] Failed to get business context: %v", err)
-		return // Or handle this error appropriately
+		if errors.Is(err, usage.ErrBudgetExceeded) {
+			stopForBudget(client, qnoteID, err)
+		}
+		store.Finish(qnoteID, checkpoint.StatusFailed, err.Error())
+		return
 	}
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.Question = asked })
 
 	spacing := (qw * scale) / 5.0
 	log.Printf("[AnswerQuestion] Spacing set to %.4f units (qw=%.4f * scale=%.4f / 5.0)", spacing, qw, scale)
-	// Layout: center (Q), top (A1), right (A2), bottom (A3), left (A4), then diagonals for meta
+	// Layout: center (Q), top (A1), right (A2), bottom (A3), left (A4), then diagonals for meta.
+	// Each further panel gets the same grid, panelSpan cells to the right of the last.
+	cell := (qw * scale) + spacing
+	panelX := func(i int) float64 { return float64(panelOf[i]*panelSpan) * cell }
 	answerPositions := [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} // top, right, bottom, left
 	metaPositions := [][2]int{{1, -1}, {1, 1}, {-1, 1}, {-1, -1}} // top-right, bottom-right, bottom-left, top-left
 	answerNoteIDs := make([]string, numPersonas)
 	metaNoteIDs := make([]string, numPersonas)
+	// Progress from an interrupted run, reused instead of regenerated
+	prev := make([]checkpoint.PersonaProgress, numPersonas)
+	for i, p := range personas {
+		prev[i] = *rec.Persona(p.Name)
+	}
 
 	// 1. Generate persona answers in parallel (all Gemini API calls simultaneously)
 	answerGenTimer := timing.Start("answer_question_persona_answers")
@@ -493,6 +534,14 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 	for i, p := range personas {
 		go func(i int, p Persona) {
 			defer ansWg.Done()
+			ctx := usage.WithPersona(ctx, p.Name)
+			if prev[i].Answer != "" {
+				if err := geminiClient.RestoreAnswer(ctx, p, question, prev[i].Answer, sessionManager, businessContextStr); err != nil {
+					log.Printf("[warn] Failed to restore answer history for persona %s: %v", p.Name, err)
+				}
+				answers[i] = prev[i].Answer
+				return
+			}
 			answer, err := geminiClient.AnswerQuestion(ctx, p, question, sessionManager, businessContextStr)
 			if err != nil {
 				answerErrorsMu.Lock()
@@ -502,7 +551,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				return
 			}
 			if len(answer) > chatTokenLimit {
-				succinctPrompt := "Please rephrase your answer in a much more succinct, short, and verbal way. Limit your response to " + fmt.Sprintf("%d", chatTokenLimit) + " characters."
+				succinctPrompt := renderSuccinctPrompt(ctx, chatTokenLimit)
 				answer, err = geminiClient.AnswerQuestion(ctx, p, succinctPrompt, sessionManager, businessContextStr)
 				if err != nil {
 					answerErrorsMu.Lock()
@@ -513,6 +562,11 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				}
 			}
 			answers[i] = answer
+			store.Update(qnoteID, func(r *checkpoint.Record) {
+				pp := r.Persona(p.Name)
+				pp.Answer = answer
+				pp.PromptVersion = promptVersion(ctx)
+			})
 		}(i, p)
 	}
 	ansWg.Wait()
@@ -528,7 +582,13 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		}
 	}
 
+	if ctx.Err() != nil {
+		log.Printf("[AnswerQuestion] Workflow for Qnote %s stopped after answer generation: %v", qnoteID, ctx.Err())
+		return
+	}
+
 	// Check for minimum required answers
+	budgetErr := budgetError(answerErrors)
 	if successfulAnswers < MinRequiredAnswers {
 		log.Printf("[AnswerQuestion] ERROR: Failed to generate minimum required answers. Got %d/%d (minimum: %d)", successfulAnswers, numPersonas, MinRequiredAnswers)
 		// Log all errors
@@ -537,8 +597,17 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				log.Printf("[AnswerQuestion] Answer error %d: %v", i+1, err)
 			}
 		}
+		if budgetErr != nil {
+			stopForBudget(client, qnoteID, budgetErr)
+			store.Finish(qnoteID, checkpoint.StatusFailed, budgetErr.Error())
+		}
 		return
 	}
+	// Some personas were refused by the spending caps; say why their answers are missing
+	if budgetErr != nil {
+		createBudgetHelperNote(client, qnoteID, budgetErr)
+	}
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.Complete(checkpoint.StepAnswers) })
 
 	if successfulAnswers < numPersonas {
 		log.Printf("[AnswerQuestion] WARN: Partial success - generated %d/%d answers. Proceeding with available answers.", successfulAnswers, numPersonas)
@@ -557,15 +626,21 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				answerNoteIDs[i] = ""
 				return
 			}
-			pos := answerPositions[i%len(answerPositions)]
-			ansX := qx + float64(pos[0])*((qw*scale)+spacing)
+			if id := prev[i].AnswerNoteID; id != "" {
+				if _, err := client.GetNote(id, false); err == nil {
+					answerNoteIDs[i] = id
+					return
+				}
+			}
+			pos := answerPositions[slotOf[i]%len(answerPositions)]
+			ansX := qx + panelX(i) + float64(pos[0])*((qw*scale)+spacing)
 			ansY := qy + float64(pos[1])*((qh*scale)+spacing)
 			noteMeta := map[string]interface{}{
 				"title":            p.Name + " Answer",
 				"text":             answers[i],
 				"location":         map[string]interface{}{"x": ansX, "y": ansY},
 				"size":             map[string]interface{}{"width": qw, "height": qh},
-				"background_color": colors[i%len(colors)],
+				"background_color": colors[slotOf[i]%len(colors)],
 				"scale":            scale,
 			}
 			singleNoteTimer := timing.Start(fmt.Sprintf("answer_question_create_answer_note_%d", i+1))
@@ -585,10 +660,16 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 			}
 			singleNoteTimer.StopAndLog(true)
 			answerNoteIDs[i] = ansNoteID
+			store.Update(qnoteID, func(r *checkpoint.Record) { r.Persona(p.Name).AnswerNoteID = ansNoteID })
 		}(i, p)
 	}
 	ansNoteWg.Wait()
 	answerNoteTimer.StopAndLog(true)
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.Complete(checkpoint.StepAnswerNotes) })
+	if ctx.Err() != nil {
+		log.Printf("[AnswerQuestion] Workflow for Qnote %s stopped after creating answer notes: %v", qnoteID, ctx.Err())
+		return
+	}
 
 	// 3. Generate meta-answers in parallel (all Gemini API calls simultaneously)
 	metaGenTimer := timing.Start("answer_question_meta_answers")
@@ -607,8 +688,9 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				return
 			}
 			others := []string{}
+			// Personas react to the others on their own panel
 			for j, ans := range answers {
-				if i != j && ans != "" && answerErrors[j] == nil {
+				if i != j && panelOf[i] == panelOf[j] && ans != "" && answerErrors[j] == nil {
 					others = append(others, fmt.Sprintf("%s said: %s", personas[j].Name, ans))
 				}
 			}
@@ -617,7 +699,15 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				metaAnswers[i] = "No other responses to react to."
 				return
 			}
-			metaPrompt := fmt.Sprintf("Thank you %s for the interesting answer. Does what you heard from the others change what you think in any way? You heard: %s", p.Name, strings.Join(others, "; "))
+			ctx := usage.WithPersona(ctx, p.Name)
+			metaPrompt := renderMetaPrompt(ctx, p.Name, others)
+			if prev[i].MetaAnswer != "" {
+				if err := geminiClient.RestoreAnswer(ctx, p, metaPrompt, prev[i].MetaAnswer, sessionManager, businessContextStr); err != nil {
+					log.Printf("[warn] Failed to restore meta-answer history for persona %s: %v", p.Name, err)
+				}
+				metaAnswers[i] = prev[i].MetaAnswer
+				return
+			}
 			metaAnswer, err := geminiClient.AnswerQuestion(ctx, p, metaPrompt, sessionManager, businessContextStr)
 			if err != nil {
 				metaErrorsMu.Lock()
@@ -627,7 +717,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				return
 			}
 			if len(metaAnswer) > chatTokenLimit {
-				succinctPrompt := "Please rephrase your answer in a much more succinct, short, and verbal way. Limit your response to " + fmt.Sprintf("%d", chatTokenLimit) + " characters."
+				succinctPrompt := renderSuccinctPrompt(ctx, chatTokenLimit)
 				metaAnswer, err = geminiClient.AnswerQuestion(ctx, p, succinctPrompt, sessionManager, businessContextStr)
 				if err != nil {
 					metaErrorsMu.Lock()
@@ -638,6 +728,13 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				}
 			}
 			metaAnswers[i] = metaAnswer
+			store.Update(qnoteID, func(r *checkpoint.Record) {
+				pp := r.Persona(p.Name)
+				pp.MetaAnswer = metaAnswer
+				if pp.PromptVersion == "" {
+					pp.PromptVersion = promptVersion(ctx)
+				}
+			})
 		}(i, p)
 	}
 	metaWg.Wait()
@@ -655,6 +752,14 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 	if successfulMeta < numPersonas {
 		log.Printf("[AnswerQuestion] WARN: Generated %d/%d meta-answers", successfulMeta, numPersonas)
 	}
+	if metaBudgetErr := budgetError(metaErrors); metaBudgetErr != nil && budgetErr == nil {
+		createBudgetHelperNote(client, qnoteID, metaBudgetErr)
+	}
+	if ctx.Err() != nil {
+		log.Printf("[AnswerQuestion] Workflow for Qnote %s stopped after meta-answer generation: %v", qnoteID, ctx.Err())
+		return
+	}
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.Complete(checkpoint.StepMetaAnswers) })
 
 	// 4. Create meta answer notes in parallel (all note creations simultaneously)
 	metaNoteTimer := timing.Start("answer_question_create_meta_notes")
@@ -668,15 +773,21 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				metaNoteIDs[i] = ""
 				return
 			}
-			metaPos := metaPositions[i%len(metaPositions)]
-			metaX := qx + float64(metaPos[0])*((qw*scale)+spacing)
+			if id := prev[i].MetaNoteID; id != "" {
+				if _, err := client.GetNote(id, false); err == nil {
+					metaNoteIDs[i] = id
+					return
+				}
+			}
+			metaPos := metaPositions[slotOf[i]%len(metaPositions)]
+			metaX := qx + panelX(i) + float64(metaPos[0])*((qw*scale)+spacing)
 			metaY := qy + float64(metaPos[1])*((qh*scale)+spacing)
 			metaMeta := map[string]interface{}{
 				"title":            p.Name + " Meta Answer",
 				"text":             metaAnswers[i],
 				"location":         map[string]interface{}{"x": metaX, "y": metaY},
 				"size":             map[string]interface{}{"width": qw, "height": qh},
-				"background_color": colors[i%len(colors)],
+				"background_color": colors[slotOf[i]%len(colors)],
 				"scale":            scale,
 			}
 			singleMetaNoteTimer := timing.Start(fmt.Sprintf("answer_question_create_meta_note_%d", i+1))
@@ -696,10 +807,16 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 			}
 			singleMetaNoteTimer.StopAndLog(true)
 			metaNoteIDs[i] = metaNoteID
+			store.Update(qnoteID, func(r *checkpoint.Record) { r.Persona(p.Name).MetaNoteID = metaNoteID })
 		}(i, p)
 	}
 	metaNoteWg.Wait()
 	metaNoteTimer.StopAndLog(true)
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.Complete(checkpoint.StepMetaNotes) })
+	if ctx.Err() != nil {
+		log.Printf("[AnswerQuestion] Workflow for Qnote %s stopped after creating meta notes: %v", qnoteID, ctx.Err())
+		return
+	}
 
 	// 5. Create connectors in parallel: question -> answer, answer -> meta answer (matching layout)
 	connectorTimer := timing.Start("answer_question_create_connectors")
@@ -713,8 +830,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		connWg.Add(1)
 		go func(i int) {
 			defer connWg.Done()
-			connMeta1 := BuildConnectorPayload(qnoteID, answerNoteIDs[i])
-			if _, err := client.CreateConnector(connMeta1); err != nil {
+			if err := createTrackedConnector(client, qnoteID, rec.Connectors, qnoteID, answerNoteIDs[i]); err != nil {
 				log.Printf("[AnswerQuestion] ERROR: Failed to create connector from question to answer %d: %v", i+1, err)
 				return
 			}
@@ -724,8 +840,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 			if metaNoteIDs[i] == "" {
 				return
 			}
-			connMeta2 := BuildConnectorPayload(answerNoteIDs[i], metaNoteIDs[i])
-			if _, err := client.CreateConnector(connMeta2); err != nil {
+			if err := createTrackedConnector(client, qnoteID, rec.Connectors, answerNoteIDs[i], metaNoteIDs[i]); err != nil {
 				log.Printf("[AnswerQuestion] ERROR: Failed to create connector from answer to meta-answer %d: %v", i+1, err)
 				return
 			}
@@ -737,6 +852,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 	connWg.Wait()
 	timing.LogOperationWithDetails(connectorTimer.Name(), connectorTimer.Duration(), true, fmt.Sprintf("connectors_created=%d", connectorCount))
 	connectorTimer.Stop()
+	store.Update(qnoteID, func(r *checkpoint.Record) { r.Complete(checkpoint.StepConnectors) })
 
 	// --- Create anchor for answer/meta notes ---
 	allNoteIDs := []string{}
@@ -750,7 +866,7 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 			allNoteIDs = append(allNoteIDs, id)
 		}
 	}
-	if len(allNoteIDs) > 0 {
+	if len(allNoteIDs) > 0 && rec.AnchorID == "" {
 		anchorTimer := timing.Start("answer_question_create_anchor")
 
 		// Note: This GetWidgets call needs fresh data to get the newly created notes' positions
@@ -799,6 +915,11 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 				}
 				if anchorResp, err := client.CreateAnchor(anchorPayload); err == nil {
 					log.Printf("[anchor] Created anchor for Qnote %s: %v", qnoteID, anchorResp)
+					anchorID, _ := anchorResp["id"].(string)
+					store.Update(qnoteID, func(r *checkpoint.Record) {
+						r.AnchorID = anchorID
+						r.Complete(checkpoint.StepAnchor)
+					})
 					anchorTimer.StopAndLog(true)
 				} else {
 					log.Printf("[anchor] Failed to create anchor for Qnote %s: %v", qnoteID, err)
@@ -821,6 +942,10 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 		log.Printf("[warn] UpdateNote failed setting green color for Qnote %s: %v", qnoteID, err)
 	}
 	answeredNotes.Store(qnoteID, true)
+	store.Finish(qnoteID, checkpoint.StatusDone, "")
+	if costNotesEnabled(ctx) {
+		createCostNote(client, qnoteID, qx+float64((len(panels)-1)*panelSpan)*cell, qy, qw, qh, scale, spacing)
+	}
 	// Delete the helper note associated with this Qnote (by tracked ID)
 	if val, ok := qnoteHelperNotes.Load(qnoteID); ok {
 		helperID := val.(string)
@@ -833,6 +958,30 @@ func AnswerQuestionWithCache(qnoteID string, client *canvusapi.Client, chatToken
 	log.Printf("[step] AnswerQuestion completed for noteID: %s (answers: %d/%d, meta: %d/%d)", qnoteID, successfulAnswers, numPersonas, successfulMeta, numPersonas)
 }
 
+// createCostNote places a small "Cost" note to the right of the Q&A grid summarising the question's usage
+func createCostNote(client *canvusapi.Client, qnoteID string, qx, qy, qw, qh, scale, spacing float64) {
+	tracker := usage.GetGlobalTracker()
+	qUsage, ok := tracker.Question(qnoteID)
+	if !ok {
+		log.Printf("[createCostNote] No usage recorded for Qnote %s", qnoteID)
+		return
+	}
+	cUsage, _ := tracker.Canvas(client.CanvasID)
+	noteMeta := map[string]interface{}{
+		"title":            "Cost",
+		"text":             usage.FormatCostNote(qUsage, cUsage),
+		"location":         map[string]interface{}{"x": qx + 2*((qw*scale)+spacing), "y": qy},
+		"size":             map[string]interface{}{"width": qw, "height": qh},
+		"background_color": CostNoteColor,
+		"scale":            scale * 0.5,
+	}
+	if _, err := client.CreateNote(noteMeta); err != nil {
+		log.Printf("[createCostNote] Failed to create cost note for Qnote %s: %v", qnoteID, err)
+		return
+	}
+	log.Printf("[createCostNote] Created cost note for Qnote %s ($%.4f)", qnoteID, qUsage.Totals.Cost)
+}
+
 // CleanupAfterAnswer deletes helper notes, stops monitors, and removes from processing list.
 func CleanupAfterAnswer(qnoteID string, client *canvusapi.Client) {
 	log.Printf("[step] CleanupAfterAnswer called for noteID: %s", qnoteID)
@@ -867,7 +1016,6 @@ func EnsureHelperNoteForPersonasWithCache(qnoteID string, client *canvusapi.Clie
 	qSize, _ := qWidget["size"].(map[string]interface{})
 	qw := qSize["width"].(float64)
 	qh := qSize["height"].(float64)
-	helperTitle := "Helper: Generating personas, please wait..."
 
 	var widgets []map[string]interface{}
 	if cachedWidgets != nil {
@@ -890,7 +1038,7 @@ func EnsureHelperNoteForPersonasWithCache(qnoteID string, client *canvusapi.Clie
 	for _, w := range widgets {
 		typeStr, _ := w["widget_type"].(string)
 		title, _ := w["title"].(string)
-		if typeStr == "Note" && title == helperTitle {
+		if typeStr == "Note" && i18n.Is(title, i18n.HelperPersonasTitle) {
 			helperID, _ = w["id"].(string)
 			found = true
 			break
@@ -899,9 +1047,10 @@ func EnsureHelperNoteForPersonasWithCache(qnoteID string, client *canvusapi.Clie
 	if !found {
 		helperX := qx - 1.2*qw
 		helperY := qy - 1.1*qh // Place it above the Qnote
+		lang := canvasLanguage(client, widgets)
 		noteMeta := map[string]interface{}{
-			"title":            helperTitle,
-			"text":             "Personas are being generated. Please wait before proceeding.",
+			"title":            i18n.T(lang, i18n.HelperPersonasTitle),
+			"text":             i18n.T(lang, i18n.HelperPersonasText),
 			"location":         map[string]interface{}{"x": helperX, "y": helperY},
 			"size":             map[string]interface{}{"width": qw, "height": qh * 0.7},
 			"background_color": "#e0e0e0",
@@ -934,18 +1083,18 @@ func createTimeoutHelperNote(client *canvusapi.Client, qnoteID string, timeout t
 		log.Printf("[createTimeoutHelperNote] Failed to get Qnote %s: %v", qnoteID, err)
 		return
 	}
-	qLoc, _ := qWidget["location"].(map[string]interface{})
-	qx := qLoc["x"].(float64)
-	qy := qLoc["y"].(float64)
-	qSize, _ := qWidget["size"].(map[string]interface{})
-	qw := qSize["width"].(float64)
-	qh := qSize["height"].(float64)
+	qx, qy, qw, qh, ok := widgetBox(qWidget)
+	if !ok {
+		log.Printf("[createTimeoutHelperNote] Note %s has no location or size", qnoteID)
+		return
+	}
 
 	helperX := qx - 1.2*qw
 	helperY := qy - 0.33*qh
+	lang := canvasLanguage(client, nil)
 	noteMeta := map[string]interface{}{
-		"title":            "Question Wait Timed Out",
-		"text":             fmt.Sprintf("The system waited %v for a question to be entered, but none was detected.\n\nPlease enter your question (ending with ?) in the note, then create a new 'New_AI_Question' trigger note to restart the Q&A process.", timeout),
+		"title":            i18n.T(lang, i18n.TimeoutTitle),
+		"text":             i18n.T(lang, i18n.TimeoutText, timeout),
 		"location":         map[string]interface{}{"x": helperX, "y": helperY},
 		"size":             map[string]interface{}{"width": qw, "height": qh * 0.7},
 		"background_color": TimeoutHelperColor,
@@ -963,10 +1112,70 @@ func createTimeoutHelperNote(client *canvusapi.Client, qnoteID string, timeout t
 	log.Printf("[createTimeoutHelperNote] Created timeout helper note %s for Qnote %s", helperID, qnoteID)
 }
 
+// createBudgetHelperNote creates a helper note explaining that a workflow was stopped by the spending caps
+func createBudgetHelperNote(client *canvusapi.Client, qnoteID string, budgetErr error) {
+	qWidget, err := client.GetNote(qnoteID, false)
+	if err != nil {
+		log.Printf("[createBudgetHelperNote] Failed to get note %s: %v", qnoteID, err)
+		return
+	}
+	qx, qy, qw, qh, ok := widgetBox(qWidget)
+	if !ok {
+		log.Printf("[createBudgetHelperNote] Note %s has no location or size", qnoteID)
+		return
+	}
+
+	helperX := qx - 1.2*qw
+	helperY := qy - 0.33*qh
+	lang := canvasLanguage(client, nil)
+	noteMeta := map[string]interface{}{
+		"title":            i18n.T(lang, i18n.BudgetTitle),
+		"text":             i18n.T(lang, i18n.BudgetText, budgetErr),
+		"location":         map[string]interface{}{"x": helperX, "y": helperY},
+		"size":             map[string]interface{}{"width": qw, "height": qh * 0.7},
+		"background_color": BudgetHelperColor,
+	}
+	helperNote, err := client.CreateNote(noteMeta)
+	if err != nil {
+		log.Printf("[createBudgetHelperNote] Failed to create budget helper note: %v", err)
+		return
+	}
+	helperID, _ := helperNote["id"].(string)
+	connMeta := BuildConnectorPayload(helperID, qnoteID)
+	if _, err := client.CreateConnector(connMeta); err != nil {
+		log.Printf("[warn] CreateConnector failed for budget helper note: %v", err)
+	}
+	log.Printf("[createBudgetHelperNote] Created budget helper note %s for note %s", helperID, qnoteID)
+}
+
+// budgetError returns the first error in errs refused by the spending caps, or nil
+func budgetError(errs []error) error {
+	for _, err := range errs {
+		if errors.Is(err, usage.ErrBudgetExceeded) {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopForBudget ends a Q&A workflow refused by the spending caps, replacing the
+// wait helper note with an explanation of why no answers appeared
+func stopForBudget(client *canvusapi.Client, qnoteID string, budgetErr error) {
+	log.Printf("[budget] Stopping workflow for Qnote %s: %v", qnoteID, budgetErr)
+	if val, ok := qnoteHelperNotes.Load(qnoteID); ok {
+		helperID := val.(string)
+		if err := client.DeleteNote(helperID); err != nil {
+			log.Printf("[warn] DeleteNote failed for helper note %s: %v", helperID, err)
+		}
+		qnoteHelperNotes.Delete(qnoteID)
+	}
+	createBudgetHelperNote(client, qnoteID, budgetErr)
+}
+
 // WaitForQuestionText waits for a question to be entered in the note, with timeout.
 // Returns true if question was detected, false if timed out.
 func WaitForQuestionText(ctx context.Context, noteID string, client *canvusapi.Client) bool {
-	timeout := getQuestionTimeout()
+	timeout := getQuestionTimeout(ctx)
 	log.Printf("[WaitForQuestionText] Starting to wait for question in note %s (timeout: %v)", noteID, timeout)
 
 	// Create a context with timeout
@@ -986,7 +1195,7 @@ func WaitForQuestionText(ctx context.Context, noteID string, client *canvusapi.C
 					continue
 				}
 				currText, _ := qWidget["text"].(string)
-				if strings.HasSuffix(strings.TrimSpace(currText), "?") {
+				if atom.EndsWithQuestionMark(currText) {
 					log.Printf("[WaitForQuestionText] Detected question in note %s: %q", noteID, currText)
 					close(ch)
 					return
@@ -1019,6 +1228,10 @@ func HandleAIQuestion(ctx context.Context, client *canvusapi.Client, trig canvus
 	if IsQnoteProcessing(noteID) {
 		return
 	}
+	ctx, finish := startWorkflow(withSettings(ctx, client.Name), client.CanvasID, noteID)
+	defer finish()
+	store := checkpoint.GetGlobalStore()
+	store.Begin(client.CanvasID, noteID)
 
 	// Fetch widgets once at the start of the workflow for caching
 	getWidgetsTimer := timing.Start("handle_ai_question_get_widgets_initial")
@@ -1033,8 +1246,18 @@ func HandleAIQuestion(ctx context.Context, client *canvusapi.Client, trig canvus
 
 	if !CheckPersonasPresentWithCache(noteID, client, widgets) {
 		EnsureHelperNoteForPersonasWithCache(noteID, client, widgets)
-		err := CreatePersonasWithCache(ctx, noteID, client, widgets)
+		checkpointHelperNote(noteID)
+		err := errors.New("persona generation cancelled while queued")
+		runQueued(ctx, client, noteID, func(waited bool) {
+			if waited {
+				widgets = nil // let persona creation fetch fresh widgets
+			}
+			err = CreatePersonasWithCache(ctx, noteID, client, widgets)
+		})
 		if err != nil {
+			if ctx.Err() == nil {
+				store.Finish(noteID, checkpoint.StatusFailed, err.Error())
+			}
 			// Remove the helper note if persona generation failed
 			if val, ok := qnoteHelperNotes.Load(noteID); ok {
 				helperID := val.(string)
@@ -1053,6 +1276,7 @@ func HandleAIQuestion(ctx context.Context, client *canvusapi.Client, trig canvus
 			return
 		}
 		if !CheckPersonasPresentWithCache(noteID, client, widgets) {
+			store.Finish(noteID, checkpoint.StatusFailed, "personas missing after persona creation")
 			if val, ok := qnoteHelperNotes.Load(noteID); ok {
 				helperID := val.(string)
 				if err := client.DeleteNote(helperID); err != nil {
@@ -1075,13 +1299,20 @@ func HandleAIQuestion(ctx context.Context, client *canvusapi.Client, trig canvus
 	}
 	if !CheckQuestionPresent(noteID, client) {
 		EnsureHelperNoteForQuestionWithCache(noteID, client, widgets)
+		checkpointHelperNote(noteID)
 
 		// Use the new WaitForQuestionText with timeout
 		questionDetected := WaitForQuestionText(ctx, noteID, client)
 
 		if !questionDetected {
+			if ctx.Err() != nil {
+				// Shutting down: keep the checkpoint so the wait resumes after restart
+				qnoteProcessingList.Delete(noteID)
+				return
+			}
 			// Timeout occurred - create timeout helper note and cleanup
-			createTimeoutHelperNote(client, noteID, getQuestionTimeout())
+			createTimeoutHelperNote(client, noteID, getQuestionTimeout(ctx))
+			store.Finish(noteID, checkpoint.StatusFailed, "timed out waiting for question")
 
 			// Remove the question helper note
 			if val, ok := qnoteHelperNotes.Load(noteID); ok {
@@ -1103,184 +1334,23 @@ func HandleAIQuestion(ctx context.Context, client *canvusapi.Client, trig canvus
 		// Refresh widgets after waiting for question (state may have changed)
 		widgets, _ = client.GetWidgets(false)
 	}
-	OnQuestionDetectedWithCache(noteID, client, chatTokenLimit, widgets)
-	log.Printf("[step] HandleAIQuestion completed for noteID: %s", noteID)
-	return
-}
-
-// HandleFollowupConnector handles creation of a follow-up answer note when a connector is created from a persona answer note to a question note.
-func HandleFollowupConnector(ctx context.Context, client *canvusapi.Client, connectorEvent canvus.WidgetEvent, chatTokenLimit int) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("[error] HandleFollowupConnector panic: %v\n%s", r, debug.Stack())
+	// A question connected from a persona note, or placed in a persona's column, is an interview with that persona
+	ran := runQueued(ctx, client, noteID, func(waited bool) {
+		if waited {
+			// Refresh widgets after waiting in the queue (state may have changed)
+			widgets, _ = client.GetWidgets(false)
 		}
-	}()
-	log.Printf("[HandleFollowupConnector] called: connectorID=%s", connectorEvent.ID)
-	// Extract src and dst IDs from connector data
-	src, srcOK := connectorEvent.Data["src"].(map[string]interface{})
-	dst, dstOK := connectorEvent.Data["dst"].(map[string]interface{})
-	if !srcOK || !dstOK {
-		log.Printf("[HandleFollowupConnector] src/dst missing in connector data")
-		return
-	}
-	srcID, srcIDOK := src["id"].(string)
-	dstID, dstIDOK := dst["id"].(string)
-	if !srcIDOK || !dstIDOK {
-		log.Printf("[HandleFollowupConnector] srcID/dstID missing or not string")
-		return
-	}
-	// Fetch src and dst widgets (not just notes)
-	srcWidget, err := client.GetWidget(srcID, false)
-	if err != nil {
-		log.Printf("[HandleFollowupConnector] failed to fetch src widget: %v", err)
-		return
-	}
-	dstWidget, err := client.GetWidget(dstID, false)
-	if err != nil {
-		log.Printf("[HandleFollowupConnector] failed to fetch dst widget: %v", err)
-		return
-	}
-	srcType, _ := srcWidget["widget_type"].(string)
-	dstType, _ := dstWidget["widget_type"].(string)
-	if srcType != "Note" {
-		log.Printf("[HandleFollowupConnector] src widget is not a Note (type=%s, id=%s)", srcType, srcID)
-		return
-	}
-	if dstType != "Note" {
-		log.Printf("[HandleFollowupConnector] dst widget is not a Note (type=%s, id=%s)", dstType, dstID)
-		return
-	}
-	// Now fetch as notes
-	srcNote := srcWidget
-	dstNote := dstWidget
-	// Check if src is a persona answer note (title ends with ' Answer' and color matches persona colors)
-	title, _ := srcNote["title"].(string)
-	bg, _ := srcNote["background_color"].(string)
-	personaColors := map[string]bool{"#2196f3ff": true, "#4caf50ff": true, "#ff9800ff": true, "#9c27b0ff": true}
-	if !strings.HasSuffix(title, " Answer") || !personaColors[strings.ToLower(bg)] {
-		log.Printf("[HandleFollowupConnector] src note is not a persona answer note (title/bg)")
-		return
-	}
-	// Check if dst is a note with a question
-	dstText, _ := dstNote["text"].(string)
-	if !strings.HasSuffix(strings.TrimSpace(dstText), "?") {
-		log.Printf("[HandleFollowupConnector] dst note does not contain a question")
-		return
-	}
-	// Improved persona name extraction
-	personaName := title
-	personaName = strings.TrimSuffix(personaName, " Followup Answer")
-	personaName = strings.TrimSuffix(personaName, " Meta Answer")
-	personaName = strings.TrimSuffix(personaName, " Answer")
-	personaName = strings.TrimSpace(personaName)
-	// Get locations and sizes
-	srcLoc, _ := srcNote["location"].(map[string]interface{})
-	srcX, _ := srcLoc["x"].(float64)
-	srcY, _ := srcLoc["y"].(float64)
-	dstLoc, _ := dstNote["location"].(map[string]interface{})
-	dstX, _ := dstLoc["x"].(float64)
-	dstY, _ := dstLoc["y"].(float64)
-	dstSize, _ := dstNote["size"].(map[string]interface{})
-	dstW, _ := dstSize["width"].(float64)
-	dstH, _ := dstSize["height"].(float64)
-	scale := 1.0
-	if s, ok := dstNote["scale"].(float64); ok {
-		scale = s
-	}
-	// Compute vector from dst to src
-	dx := srcX - dstX
-	dy := srcY - dstY
-	// Place follow-up note at same distance from dst as src is from dst
-	fupX := dstX + dx
-	fupY := dstY + dy
-	// Use same size as dst note
-	fupW := dstW
-	fupH := dstH
-	// Helper: If dst note is blank or not a question, create helper note
-	if strings.TrimSpace(dstText) == "" || !strings.HasSuffix(strings.TrimSpace(dstText), "?") {
-		helperTitle := "Helper: Please enter a question for this note"
-		noteMeta := map[string]interface{}{
-			"title":            helperTitle,
-			"text":             "Please enter a question in the note to enable follow-up.",
-			"location":         map[string]interface{}{"x": dstX - 1.2*dstW, "y": dstY - 0.33*dstH},
-			"size":             map[string]interface{}{"width": dstW, "height": dstH * 0.7},
-			"background_color": "#e0e0e0",
-		}
-		if _, err := client.CreateNote(noteMeta); err != nil {
-			log.Printf("[warn] CreateNote failed for followup helper: %v", err)
-		}
-		return
-	}
-	// Generate follow-up answer using the persona
-	personas := []Persona{}
-	geminiClient, err := NewClient(ctx)
-	if err != nil {
-		log.Printf("[HandleFollowupConnector] failed to create Gemini client: %v", err)
-		return
-	}
-	err = CreatePersonas(ctx, dstID, client)
-	if err != nil {
-		log.Printf("[HandleFollowupConnector] CreatePersonas failed: %v", err)
-		return
-	}
-	// Find the persona by name
-	var persona Persona
-	found := false
-	for _, p := range personas {
-		if p.Name == personaName {
-			persona = p
-			found = true
-			break
+		if who, ok := intervieweeFor(client, widgets, noteID); ok {
+			interviewQuestion(ctx, client, noteID, who, chatTokenLimit)
+			return
 		}
-	}
-	if !found {
-		log.Printf("[HandleFollowupConnector] persona not found: %s", personaName)
-		return
-	}
-	// Get business context for followup
-	businessContextStr, _, err := getBusinessContext(ctx, dstID, client)
-	if err != nil {
-		log.Printf("[HandleFollowupConnector] Failed to get business context: %v", err)
-		return // Or handle this error appropriately
-	}
-
-	sessionManager := NewSessionManager(geminiClient.GenaiClient())
-	answer, _ := geminiClient.AnswerQuestion(ctx, persona, dstText, sessionManager, businessContextStr)
-	if len(answer) > chatTokenLimit {
-		succinctPrompt := "Please rephrase your answer in a much more succinct, short, and verbal way. Limit your response to " + fmt.Sprintf("%d", chatTokenLimit) + " characters."
-		answer, _ = geminiClient.AnswerQuestion(ctx, persona, succinctPrompt, sessionManager, businessContextStr)
-	}
-	// Create follow-up answer note
-	fupMeta := map[string]interface{}{
-		"title":            persona.Name + " Followup Answer",
-		"text":             answer,
-		"location":         map[string]interface{}{"x": fupX, "y": fupY},
-		"size":             map[string]interface{}{"width": fupW, "height": fupH},
-		"background_color": bg,
-		"scale":            scale,
-	}
-	fupNote, err := client.CreateNote(fupMeta)
-	if err != nil {
-		log.Printf("[HandleFollowupConnector] failed to create follow-up note: %v", err)
+		OnQuestionDetectedWithCache(noteID, client, chatTokenLimit, widgets)
+	})
+	if !ran {
+		qnoteProcessingList.Delete(noteID)
+		log.Printf("[HandleAIQuestion] Aborted for noteID %s before leaving the queue", noteID)
 		return
 	}
-	fupNoteID, _ := fupNote["id"].(string)
-	if fupNoteID == "" {
-		log.Printf("[HandleFollowupConnector] follow-up note ID missing")
-		return
-	}
-	// Create connector from dst to follow-up note, copying settings from original connector
-	connMeta := connectorEvent.Data
-	connMetaCpy := make(map[string]interface{})
-	for k, v := range connMeta {
-		connMetaCpy[k] = v
-	}
-	// Update src/dst for new connector
-	connMetaCpy["src"] = map[string]interface{}{"id": dstID, "auto_location": true, "tip": "none"}
-	connMetaCpy["dst"] = map[string]interface{}{"id": fupNoteID, "auto_location": true, "tip": "solid-equilateral-triangle"}
-	connMetaCpy["widget_type"] = "Connector"
-	if _, err := client.CreateConnector(connMetaCpy); err != nil {
-		log.Printf("[warn] CreateConnector failed for follow-up: %v", err)
-	}
-	log.Printf("[HandleFollowupConnector] Follow-up answer note and connector created for persona %s", persona.Name)
+	log.Printf("[step] HandleAIQuestion completed for noteID: %s", noteID)
+	return
 }
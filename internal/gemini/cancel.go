@@ -0,0 +1,139 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+)
+
+// CancelledColor is the grey background given to a Qnote whose workflow was cancelled.
+// It is deliberately not white, so the note is not picked up again until a user resets it.
+const CancelledColor = "#bdbdbdff"
+
+// cancelWaitTimeout bounds how long CancelQuestion waits for in-flight calls to return
+const cancelWaitTimeout = 30 * time.Second
+
+// activeWorkflow is a running question workflow that can be cancelled
+type activeWorkflow struct {
+	canvasID string // canvas of the Qnote, so it is only cancelled there
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	cancelling atomic.Bool // set by the first CancelQuestion call
+}
+
+// startWorkflow registers a cancellable context for the workflow of the Qnote on
+// canvasID, pinned to the current settings unless the caller already pinned them.
+// The returned finish func must be called when the workflow returns.
+func startWorkflow(parent context.Context, canvasID, qnoteID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(withSettings(parent, ""))
+	aw := &activeWorkflow{canvasID: canvasID, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	activeWorkflows.Store(qnoteID, aw)
+	return ctx, func() {
+		activeWorkflows.CompareAndDelete(qnoteID, aw)
+		cancel()
+		close(aw.done)
+	}
+}
+
+// questionContext returns the context of the Qnote's running workflow, or
+// context.Background() if it was started outside HandleAIQuestion
+func questionContext(qnoteID string) context.Context {
+	if val, ok := activeWorkflows.Load(qnoteID); ok {
+		return val.(*activeWorkflow).ctx
+	}
+	return context.Background()
+}
+
+// IsQuestionActive returns true if a workflow is running (or queued) for the Qnote
+func IsQuestionActive(qnoteID string) bool {
+	_, ok := activeWorkflows.Load(qnoteID)
+	return ok
+}
+
+// QuestionCanvas returns the ID of the canvas the Qnote's running workflow belongs to
+func QuestionCanvas(qnoteID string) (string, bool) {
+	val, ok := activeWorkflows.Load(qnoteID)
+	if !ok {
+		return "", false
+	}
+	return val.(*activeWorkflow).canvasID, true
+}
+
+// CancelQuestion stops the Qnote's running workflow, aborting pending Gemini calls,
+// then deletes the answer notes, connectors, anchor and helper notes it created.
+// If resetQnote is set the Qnote is retitled New_AI_Question, given back its
+// question text and turned grey. Returns false if no workflow was running for
+// the Qnote on the client's canvas.
+func CancelQuestion(client *canvusapi.Client, qnoteID string, resetQnote bool) bool {
+	val, ok := activeWorkflows.Load(qnoteID)
+	if !ok {
+		return false
+	}
+	aw := val.(*activeWorkflow)
+	if aw.canvasID != client.CanvasID {
+		log.Printf("[cancel] Qnote %s belongs to canvas %s, not %s; not cancelling", qnoteID, aw.canvasID, client.CanvasID)
+		return false
+	}
+	if !aw.cancelling.CompareAndSwap(false, true) {
+		return true // another trigger is already cancelling it
+	}
+	log.Printf("[cancel] Cancelling workflow for Qnote %s", qnoteID)
+	aw.cancel()
+
+	// Let in-flight calls unwind so no notes are created after cleanup
+	select {
+	case <-aw.done:
+	case <-time.After(cancelWaitTimeout):
+		log.Printf("[cancel] Workflow for Qnote %s still running after %v, cleaning up anyway", qnoteID, cancelWaitTimeout)
+	}
+
+	store := checkpoint.GetGlobalStore()
+	rec, _ := store.Get(qnoteID)
+	rec.QnoteID = qnoteID
+	if val, ok := qnoteHelperNotes.Load(qnoteID); ok {
+		if helperID := val.(string); helperID != rec.HelperID {
+			if err := client.DeleteNote(helperID); err != nil {
+				log.Printf("[warn] DeleteNote failed for helper note %s: %v", helperID, err)
+			}
+		}
+		qnoteHelperNotes.Delete(qnoteID)
+	}
+	deleteWorkflowWidgets(client, rec)
+	store.Finish(qnoteID, checkpoint.StatusCancelled, "cancelled by user")
+	qnoteProcessingList.Delete(qnoteID)
+
+	if resetQnote {
+		question := rec.Question
+		if question == "" {
+			if qWidget, err := client.GetNote(qnoteID, false); err == nil {
+				text, _ := qWidget["text"].(string)
+				question = questionFromNoteText(text)
+			}
+		}
+		update := map[string]interface{}{
+			"title":            "New_AI_Question",
+			"text":             question,
+			"background_color": CancelledColor,
+		}
+		if _, err := client.UpdateNote(qnoteID, update); err != nil {
+			log.Printf("[warn] UpdateNote failed resetting cancelled Qnote %s: %v", qnoteID, err)
+		}
+	}
+	log.Printf("[cancel] Workflow for Qnote %s cancelled and cleaned up", qnoteID)
+	return true
+}
+
+// questionFromNoteText strips workflow status text from a Qnote, leaving the original question
+func questionFromNoteText(text string) string {
+	if idx := strings.Index(text, "-->"); idx != -1 {
+		text = text[idx+3:]
+	}
+	return strings.TrimSpace(strings.Split(text, "Please wait")[0])
+}
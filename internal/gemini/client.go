@@ -8,16 +8,18 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/cache"
+	"github.com/jaypaulb/AI-personas/internal/prompts"
+	"github.com/jaypaulb/AI-personas/internal/ratelimit"
 	"github.com/jaypaulb/AI-personas/internal/timing"
 	"github.com/jaypaulb/AI-personas/internal/types"
-	"github.com/joho/godotenv"
+	"github.com/jaypaulb/AI-personas/internal/usage"
 	"google.golang.org/genai"
 )
 
@@ -38,6 +40,9 @@ const (
 	openAIMaxBackoff     = 32 * time.Second
 )
 
+// openAIImageModel is the DALL-E model used when no model is specified in the request
+const openAIImageModel = "dall-e-2"
+
 // httpClientWithTimeout returns an HTTP client with configured timeout
 var httpClientWithTimeout = &http.Client{Timeout: openAIHTTPTimeout}
 
@@ -49,9 +54,9 @@ type Client struct {
 }
 
 func NewClient(ctx context.Context) (*Client, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
+	apiKey := configFrom(ctx).Gemini.APIKey
 	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY not set in environment")
+		return nil, fmt.Errorf("GEMINI_API_KEY not set in environment or config")
 	}
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  apiKey,
@@ -96,51 +101,324 @@ func isGeminiRetryableError(err error) bool {
 		strings.Contains(errStr, "UNAVAILABLE")
 }
 
-// GeneratePersonas calls Gemini to generate 4 personas as a JSON array
+// recordGeminiUsage records the token usage reported in a Gemini response against the scope in ctx
+func recordGeminiUsage(ctx context.Context, model, operation string, resp *genai.GenerateContentResponse) {
+	if resp == nil || resp.UsageMetadata == nil {
+		return
+	}
+	um := resp.UsageMetadata
+	// Thinking tokens are billed as output tokens
+	outputTokens := int(um.CandidatesTokenCount) + int(um.ThoughtsTokenCount)
+	usage.GetGlobalTracker().Record(ctx, model, operation, int(um.PromptTokenCount), outputTokens, 0)
+}
+
+// estimatedOutputTokens is the response size assumed when checking budgets before a text call
+const estimatedOutputTokens = 512
+
+// checkBudget refuses a call whose estimated usage would exceed the configured spending caps
+func checkBudget(ctx context.Context, model string, promptTokens, images int) error {
+	outputTokens := estimatedOutputTokens
+	if images > 0 {
+		outputTokens = 0
+	}
+	if err := usage.GetGlobalTracker().CheckBudget(usage.ScopeFrom(ctx), model, promptTokens, outputTokens, images); err != nil {
+		log.Printf("[budget] Refusing %s call: %v", model, err)
+		return err
+	}
+	return nil
+}
+
+// estimateChatTokens estimates the prompt size of the next message in a chat, including its history
+func estimateChatTokens(chat *genai.Chat, message string) int {
+	tokens := usage.EstimateTokens(message)
+	for _, content := range chat.History(true) {
+		if content == nil {
+			continue
+		}
+		for _, part := range content.Parts {
+			if part != nil {
+				tokens += usage.EstimateTokens(part.Text)
+			}
+		}
+	}
+	return tokens
+}
+
+// personaCount is the number of personas generated for a canvas
+const personaCount = 4
+
+// personaDiversifyAttempts is how many times personas breaking the diversity constraints are replaced
+const personaDiversifyAttempts = 2
+
+// panelPrompts maps each panel to its generation prompt
+var panelPrompts = map[types.Panel]string{
+	types.PanelCustomer: prompts.Personas,
+	types.PanelInternal: prompts.PersonasInternal,
+	types.PanelPartner:  prompts.PersonasPartner,
+	types.PanelInvestor: prompts.PersonasInvestor,
+}
+
+// GeneratePersonas calls Gemini to generate personaCount customer personas
 func (c *Client) GeneratePersonas(ctx context.Context, businessContext string) ([]Persona, error) {
-	prompt := `Given the following business model context, generate exactly 4 diverse personas as a JSON array. These personas should represent POTENTIAL CLIENTS from 4 DIFFERENT MARKET SECTORS who would be interested in the products/services described. They should NOT be employees of the company, but rather external customers, buyers, or decision-makers from different industries or market segments.
+	return c.GeneratePanelPersonas(ctx, businessContext, types.PanelCustomer)
+}
 
-Each persona should have the following fields: name, role, description, background, goals, age, sex, race. The "goals" field should be an array of strings representing their key objectives related to the business context.
+// GeneratePanelPersonas calls Gemini to generate personaCount personas for panel as a JSON array.
+// The response is constrained to the persona schema and validated; when personas
+// are malformed or missing Gemini is asked to repair them, up to personaRepairAttempts
+// times. If some personas are still invalid after that, the valid ones are returned.
+func (c *Client) GeneratePanelPersonas(ctx context.Context, businessContext string, panel types.Panel) ([]Persona, error) {
+	diversity := atom.PanelDiversity(configFrom(ctx).Personas.Diversity, panel)
+	prompt := renderPrompt(ctx, panelPrompts[panel], prompts.PersonasData{
+		BusinessContext: businessContext,
+		Count:           personaCount,
+		Rules:           atom.DiversityRules(diversity, personaCount),
+		Language:        answerLanguage(ctx, businessContext),
+	})
+
+	cfg := configFrom(ctx).Gemini
+	model := cfg.PersonasModel
+	temp := cfg.Temperature
+	config := &genai.GenerateContentConfig{
+		Temperature:      genai.Ptr(float32(temp)),
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   personasSchema(personaCount, diversity),
+	}
+
+	cacheKey := cache.Key("personas", model, strconv.FormatFloat(temp, 'f', 2, 32), cache.HashText(businessContext), prompt)
+	if cached, ok := cache.GetGlobalCache().Get(cacheKey); ok {
+		if personas, problems := parsePersonas(cached, personaCount); len(problems) == 0 {
+			log.Printf("[GeneratePersonas] Using cached %s personas (model=%s, %d personas)", panel, model, len(personas))
+			return onPanel(personas, panel), nil
+		}
+	}
 
-Respond ONLY with the JSON array, no extra text.
+	contents := []*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)}
+	var best []Persona
+	var problems []string
+	for repair := 0; ; repair++ {
+		jsonText, err := c.generatePersonasJSON(ctx, &model, contents, config)
+		if err != nil {
+			if len(best) == 0 {
+				return nil, err
+			}
+			log.Printf("[GeneratePersonas] Repair request failed, keeping %d valid personas: %v", len(best), err)
+			break
+		}
 
-Business Context:
-` + businessContext
+		var personas []Persona
+		personas, problems = parsePersonas(jsonText, personaCount)
+		if len(problems) == 0 {
+			personas = c.diversifyPersonas(ctx, &model, businessContext, panel, personas)
+			if data, err := json.Marshal(personas); err == nil {
+				if err := cache.GetGlobalCache().Put(cacheKey, string(data)); err != nil {
+					log.Printf("[GeneratePersonas] Failed to cache personas: %v", err)
+				}
+			}
+			return onPanel(personas, panel), nil
+		}
+		if len(personas) > len(best) {
+			best = personas
+		}
+		if repair == personaRepairAttempts {
+			break
+		}
 
-	// Get model from environment variable, with sensible default
-	model := os.Getenv("GEMINI_MODEL_PERSONAS")
-	if model == "" {
-		model = "gemini-2.5-flash" // Default to flash for persona generation
+		log.Printf("[GeneratePersonas] Response failed validation, asking for a repair (%d/%d): %s", repair+1, personaRepairAttempts, strings.Join(problems, "; "))
+		repairPrompt := renderPrompt(ctx, prompts.PersonasRepair, prompts.PersonasRepairData{Count: personaCount, Problems: problems})
+		contents = append(contents,
+			genai.NewContentFromText(jsonText, genai.RoleModel),
+			genai.NewContentFromText(repairPrompt, genai.RoleUser),
+		)
 	}
 
-	// Read temperature from env
-	temp := 0.7
-	if v := os.Getenv("LLM_TEMP"); v != "" {
-		if f, err := strconv.ParseFloat(v, 32); err == nil {
-			temp = f
+	if len(best) == 0 {
+		return nil, fmt.Errorf("Gemini returned no valid personas after %d repair attempts: %s", personaRepairAttempts, strings.Join(problems, "; "))
+	}
+	log.Printf("[GeneratePersonas] WARN: Returning %d of %d personas, still invalid after repair: %s", len(best), personaCount, strings.Join(problems, "; "))
+	return onPanel(best, panel), nil
+}
+
+// onPanel marks personas as members of panel
+func onPanel(personas []Persona, panel types.Panel) []Persona {
+	for i := range personas {
+		personas[i] = withPanel(personas[i], panel)
+	}
+	return personas
+}
+
+// withPanel returns p as a member of panel; customers are left unmarked
+func withPanel(p Persona, panel types.Panel) Persona {
+	p.Panel = panel
+	if panel == types.PanelCustomer {
+		p.Panel = ""
+	}
+	return p
+}
+
+// diversifyPersonas replaces the personas that break the diversity constraints,
+// up to personaDiversifyAttempts times, and returns the most diverse set found
+func (c *Client) diversifyPersonas(ctx context.Context, model *string, businessContext string, panel types.Panel, personas []Persona) []Persona {
+	diversity := atom.PanelDiversity(configFrom(ctx).Personas.Diversity, panel)
+	problems, issues := atom.CheckDiversity(personas, diversity)
+	for attempt := 1; len(issues) > 0 && attempt <= personaDiversifyAttempts; attempt++ {
+		log.Printf("[GeneratePersonas] Personas break diversity constraints, replacing %d (%d/%d): %s", len(issues), attempt, personaDiversifyAttempts, strings.Join(problems, "; "))
+		candidate, err := c.replacePersonas(ctx, model, businessContext, panel, personas, issues, problems)
+		if err != nil {
+			log.Printf("[GeneratePersonas] Failed to replace personas: %v", err)
+			continue
+		}
+		candidateProblems, candidateIssues := atom.CheckDiversity(candidate, diversity)
+		if len(candidateProblems) > len(problems) {
+			log.Printf("[GeneratePersonas] Replacement personas are less diverse, keeping the previous set")
+			continue
+		}
+		personas, problems, issues = candidate, candidateProblems, candidateIssues
+	}
+	if len(issues) > 0 {
+		log.Printf("[GeneratePersonas] WARN: Personas still break diversity constraints: %s", strings.Join(problems, "; "))
+	}
+	return personas
+}
+
+// replacePersonas asks Gemini for new personas in place of those in issues,
+// meeting each issue's needs and differing from the personas kept, and returns
+// the updated set
+func (c *Client) replacePersonas(ctx context.Context, model *string, businessContext string, panel types.Panel, personas []Persona, issues []atom.DiversityIssue, problems []string) ([]Persona, error) {
+	diversity := atom.PanelDiversity(configFrom(ctx).Personas.Diversity, panel)
+	data := prompts.PersonasReplaceData{
+		BusinessContext: businessContext,
+		Panel:           panel,
+		Rules:           atom.DiversityRules(diversity, len(personas)),
+		Problems:        problems,
+		Language:        answerLanguage(ctx, businessContext),
+	}
+	replaced := make(map[int]bool, len(issues))
+	for n, issue := range issues {
+		replaced[issue.Persona] = true
+		data.Replace = append(data.Replace, prompts.PersonaReplacement{Number: n + 1, Needs: issue.Needs})
+	}
+	for i, p := range personas {
+		if !replaced[i] {
+			data.Keep = append(data.Keep, p)
 		}
 	}
 	config := &genai.GenerateContentConfig{
-		Temperature: genai.Ptr(float32(temp)),
+		Temperature:      genai.Ptr(float32(configFrom(ctx).Gemini.Temperature)),
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   personasSchema(len(issues), diversity),
+	}
+	contents := []*genai.Content{genai.NewContentFromText(renderPrompt(ctx, prompts.PersonasReplace, data), genai.RoleUser)}
+	jsonText, err := c.generatePersonasJSON(ctx, model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+	replacements, invalid := parsePersonas(jsonText, len(issues))
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("replacement personas are invalid: %s", strings.Join(invalid, "; "))
+	}
+	out := append([]Persona{}, personas...)
+	for n, issue := range issues {
+		out[issue.Persona] = onPanel(replacements[n:n+1], panel)[0]
+	}
+	if _, invalid := atom.ValidatePersonas(out, len(out)); len(invalid) > 0 {
+		return nil, fmt.Errorf("replacement personas clash with the others: %s", strings.Join(invalid, "; "))
+	}
+	return out, nil
+}
+
+// RegeneratePersona asks Gemini for a persona of panel to take old's place beside
+// others: a different person from old and from the others, meeting the diversity
+// constraints as far as the others allow. old may be empty, for a failed slot.
+func (c *Client) RegeneratePersona(ctx context.Context, businessContext string, panel types.Panel, others []Persona, old Persona) (Persona, error) {
+	diversity := atom.PanelDiversity(configFrom(ctx).Personas.Diversity, panel)
+	model := configFrom(ctx).Gemini.PersonasModel
+	// The new persona goes last, so the diversity check prefers to change it over the others
+	slot := len(others)
+	set := append(append([]Persona{}, others...), old)
+	var base []string
+	if old.Name != "" {
+		base = append(base, fmt.Sprintf("a different person from %s (%s), whom it replaces", old.Name, old.Role))
+	}
+	needsOf := func(issues []atom.DiversityIssue) []string {
+		needs := append([]string{}, base...)
+		for _, issue := range issues {
+			if issue.Persona == slot {
+				needs = append(needs, issue.Needs...)
+			}
+		}
+		return needs
+	}
+
+	problems, issues := atom.CheckDiversity(set, diversity)
+	found := false
+	var err error
+	for attempt := 0; attempt <= personaDiversifyAttempts; attempt++ {
+		needs := needsOf(issues)
+		if found && len(needs) == len(base) {
+			break
+		}
+		candidate, replaceErr := c.replacePersonas(ctx, &model, businessContext, panel, set, []atom.DiversityIssue{{Persona: slot, Needs: needs}}, problems)
+		if replaceErr != nil {
+			log.Printf("[RegeneratePersona] Failed to generate a replacement (%d/%d): %v", attempt+1, personaDiversifyAttempts+1, replaceErr)
+			err = replaceErr
+			continue
+		}
+		candidateProblems, candidateIssues := atom.CheckDiversity(candidate, diversity)
+		if found && len(candidateProblems) > len(problems) {
+			log.Printf("[RegeneratePersona] Replacement is less diverse, keeping the previous one")
+			continue
+		}
+		set, problems, issues, found = candidate, candidateProblems, candidateIssues, true
+	}
+	if !found {
+		return Persona{}, err
+	}
+	if len(needsOf(issues)) > len(base) {
+		log.Printf("[RegeneratePersona] WARN: %s still breaks diversity constraints: %s", set[slot].Name, strings.Join(problems, "; "))
+	}
+	return set[slot], nil
+}
+
+// generatePersonasJSON sends a persona generation request, retrying rate limits and
+// transient errors, and returns the response text. model is switched to the
+// fallback model if Gemini does not know it.
+func (c *Client) generatePersonasJSON(ctx context.Context, model *string, contents []*genai.Content, config *genai.GenerateContentConfig) (string, error) {
+	promptTokens := 0
+	for _, content := range contents {
+		for _, part := range content.Parts {
+			promptTokens += usage.EstimateTokens(part.Text)
+		}
+	}
+	if err := checkBudget(ctx, *model, promptTokens, 0); err != nil {
+		return "", err
 	}
 
 	// Start timing the Gemini API call
 	timer := timing.Start("gemini_generate_personas")
-	promptLen := len(prompt)
+	promptLen := len(contents[len(contents)-1].Parts[0].Text)
 
 	var resp *genai.GenerateContentResponse
 	var lastErr error
 
 	// Retry loop with exponential backoff for rate limits
 	for attempt := 1; attempt <= geminiMaxRetries; attempt++ {
-		resp, lastErr = c.genai.Models.GenerateContent(ctx, model, []*genai.Content{{Parts: []*genai.Part{{Text: prompt}}}}, config)
+		if err := ratelimit.ForProvider("gemini").Wait(ctx); err != nil {
+			timer.Stop()
+			return "", err
+		}
+		resp, lastErr = c.genai.Models.GenerateContent(ctx, *model, contents, config)
 
 		if lastErr != nil {
 			// Fallback to gemini-2.5-flash-lite if model not found (only on first attempt)
 			if attempt == 1 && (strings.Contains(lastErr.Error(), "not found") || strings.Contains(lastErr.Error(), "NOT_FOUND")) {
-				log.Printf("[GeneratePersonas] Model %s not found, trying fallback gemini-2.5-flash-lite", model)
-				model = "gemini-2.5-flash-lite"
-				resp, lastErr = c.genai.Models.GenerateContent(ctx, model, []*genai.Content{{Parts: []*genai.Part{{Text: prompt}}}}, config)
+				log.Printf("[GeneratePersonas] Model %s not found, trying fallback gemini-2.5-flash-lite", *model)
+				*model = "gemini-2.5-flash-lite"
+				if err := ratelimit.ForProvider("gemini").Wait(ctx); err != nil {
+					timer.Stop()
+					return "", err
+				}
+				resp, lastErr = c.genai.Models.GenerateContent(ctx, *model, contents, config)
 			}
 		}
 
@@ -167,27 +445,19 @@ Business Context:
 	}
 
 	if lastErr != nil {
-		timing.LogOperationWithDetails(timer.Name(), timer.Duration(), false, fmt.Sprintf("model=%s prompt_len=%d", model, promptLen))
+		timing.LogOperationWithDetails(timer.Name(), timer.Duration(), false, fmt.Sprintf("model=%s prompt_len=%d", *model, promptLen))
 		timer.Stop()
-		return nil, lastErr
+		return "", lastErr
 	}
 
-	timing.LogOperationWithDetails(timer.Name(), timer.Duration(), true, fmt.Sprintf("model=%s prompt_len=%d", model, promptLen))
+	timing.LogOperationWithDetails(timer.Name(), timer.Duration(), true, fmt.Sprintf("model=%s prompt_len=%d", *model, promptLen))
 	timer.Stop()
+	recordGeminiUsage(ctx, *model, "generate_personas", resp)
 
 	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
-	}
-	jsonText := resp.Candidates[0].Content.Parts[0].Text
-
-	// Strip Markdown code block if present
-	jsonText = atom.StripMarkdownCodeBlock(jsonText)
-
-	var personas []Persona
-	if err := json.Unmarshal([]byte(jsonText), &personas); err != nil {
-		return nil, fmt.Errorf("failed to parse Gemini JSON: %w\nRaw: %s", err, jsonText)
+		return "", fmt.Errorf("no response from Gemini")
 	}
-	return personas, nil
+	return resp.Candidates[0].Content.Parts[0].Text, nil
 }
 
 // FormatPersonaNote formats a persona for a Canvus note
@@ -198,8 +468,14 @@ func FormatPersonaNote(p Persona) string {
 // PersonaSession holds a chat session and persona info
 // for multi-turn LLM conversations.
 type PersonaSession struct {
-	Persona *Persona
-	Chat    *genai.Chat
+	Persona         *Persona
+	Chat            *genai.Chat
+	Model           string
+	Config          *genai.GenerateContentConfig
+	BusinessContext string
+
+	// mu serialises turns with Reseed, so a profile edit lands between answers
+	mu sync.Mutex
 }
 
 // SessionManager manages chat sessions for each persona.
@@ -217,9 +493,43 @@ func NewSessionManager(client *genai.Client) *SessionManager {
 	}
 }
 
-// GenerateSystemPrompt returns a detailed system prompt for a persona
+// chatCacheKey builds the response cache key for sending message after the chat's current
+// history. The history includes the system prompt, so the key covers the persona and business context.
+func chatCacheKey(model string, config *genai.GenerateContentConfig, chat *genai.Chat, message string) string {
+	temp := ""
+	if config != nil && config.Temperature != nil {
+		temp = strconv.FormatFloat(float64(*config.Temperature), 'f', 2, 32)
+	}
+	parts := []string{"chat", model, temp}
+	for _, content := range chat.History(true) {
+		if content == nil {
+			continue
+		}
+		for _, part := range content.Parts {
+			if part != nil {
+				parts = append(parts, content.Role+":"+part.Text)
+			}
+		}
+	}
+	parts = append(parts, genai.RoleUser+":"+message)
+	return cache.Key(parts...)
+}
+
+// replayCachedTurn returns a copy of chat with a cached exchange appended to its history,
+// keeping later (uncached) messages in the same conversation consistent.
+func (sm *SessionManager) replayCachedTurn(ctx context.Context, sess *PersonaSession, chat *genai.Chat, message, reply string) (*genai.Chat, error) {
+	history := append([]*genai.Content{}, chat.History(false)...)
+	history = append(history,
+		&genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{Text: message}}},
+		&genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: reply}}},
+	)
+	return sm.client.Chats.Create(ctx, sess.Model, sess.Config, history)
+}
+
+// GenerateSystemPrompt returns the built-in system prompt for a persona
 func GenerateSystemPrompt(persona Persona, businessContext string) string {
-	return atom.GenerateSystemPrompt(persona, businessContext)
+	out, _ := prompts.Default().Render(prompts.System, prompts.SystemData{Persona: persona, BusinessContext: businessContext})
+	return out
 }
 
 // GetOrCreateSession returns the session for a persona, creating it if needed.
@@ -233,21 +543,12 @@ func (sm *SessionManager) GetOrCreateSession(ctx context.Context, persona Person
 	// Start timing session creation
 	timer := timing.Start("gemini_create_session")
 
-	// Read temperature from env
-	temp := 0.7
-	if v := os.Getenv("LLM_TEMP"); v != "" {
-		if f, err := strconv.ParseFloat(v, 32); err == nil {
-			temp = f
-		}
-	}
+	cfg := configFrom(ctx).Gemini
+	temp := cfg.Temperature
 	config := &genai.GenerateContentConfig{
 		Temperature: genai.Ptr(float32(temp)),
 	}
-	// Get model from environment variable for chat sessions
-	model := os.Getenv("GEMINI_MODEL_CHAT")
-	if model == "" {
-		model = "gemini-2.5-flash" // Default to flash for chat sessions
-	}
+	model := cfg.ChatModel
 
 	var chat *genai.Chat
 	var lastErr error
@@ -293,28 +594,126 @@ func (sm *SessionManager) GetOrCreateSession(ctx context.Context, persona Person
 		return nil, lastErr
 	}
 
+	sess := &PersonaSession{
+		Persona:         &persona,
+		Chat:            chat,
+		Model:           model,
+		Config:          config,
+		BusinessContext: businessContext,
+	}
+
 	// Inject system prompt as first message
-	systemPrompt := GenerateSystemPrompt(persona, businessContext)
+	systemPrompt := renderSystemPrompt(ctx, persona, businessContext)
 	promptLen := len(systemPrompt)
-	_, _ = chat.Send(ctx, &genai.Part{Text: systemPrompt})
+	cacheKey := chatCacheKey(model, config, chat, systemPrompt)
+	if cached, ok := cache.GetGlobalCache().Get(cacheKey); ok {
+		if replayed, err := sm.replayCachedTurn(ctx, sess, chat, systemPrompt, cached); err == nil {
+			sess.Chat = replayed
+			log.Printf("[GetOrCreateSession] Using cached system prompt reply for persona %s", persona.Name)
+		}
+	}
+	if sess.Chat == chat {
+		if err := checkBudget(ctx, model, usage.EstimateTokens(systemPrompt), 0); err != nil {
+			timing.LogOperationWithDetails(timer.Name(), timer.Duration(), false, fmt.Sprintf("model=%s persona=%s error=budget", model, persona.Name))
+			timer.Stop()
+			return nil, err
+		}
+		if err := ratelimit.ForProvider("gemini").Wait(ctx); err != nil {
+			timer.Stop()
+			return nil, err
+		}
+		systemResp, err := chat.Send(ctx, &genai.Part{Text: systemPrompt})
+		recordGeminiUsage(ctx, model, "system_prompt", systemResp)
+		if err == nil && systemResp != nil && len(systemResp.Candidates) > 0 && systemResp.Candidates[0].Content != nil && len(systemResp.Candidates[0].Content.Parts) > 0 {
+			if err := cache.GetGlobalCache().Put(cacheKey, systemResp.Candidates[0].Content.Parts[0].Text); err != nil {
+				log.Printf("[GetOrCreateSession] Failed to cache system prompt reply: %v", err)
+			}
+		}
+	}
 
 	timing.LogOperationWithDetails(timer.Name(), timer.Duration(), true, fmt.Sprintf("model=%s persona=%s prompt_len=%d", model, persona.Name, promptLen))
 	timer.Stop()
 
-	sess := &PersonaSession{
-		Persona: &persona,
-		Chat:    chat,
-	}
 	sm.sessions[persona.Name] = sess
 	return sess, nil
 }
 
+// Reseed gives the session of the persona named name (as it was created, or as
+// last re-seeded) a new profile: its chat restarts with the new system prompt
+// and, with keepHistory, the conversation so far. The model's reply to the old
+// system prompt is reused, so no call is made unless the session never got one.
+// The session keeps its key. Returns false if there is no such session.
+func (sm *SessionManager) Reseed(ctx context.Context, name string, persona Persona, keepHistory bool) (bool, error) {
+	sm.mu.Lock()
+	sess, ok := sm.sessions[name]
+	if !ok {
+		for _, s := range sm.sessions {
+			if s.Persona != nil && s.Persona.Name == name {
+				sess, ok = s, true
+				break
+			}
+		}
+	}
+	sm.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	old := sess.Chat.History(false)
+	systemPrompt := renderSystemPrompt(ctx, persona, sess.BusinessContext)
+	var history []*genai.Content
+	if len(old) >= 2 {
+		history = append(history, genai.NewContentFromText(systemPrompt, genai.RoleUser), old[1])
+		if keepHistory {
+			history = append(history, old[2:]...)
+		}
+	}
+	chat, err := sm.client.Chats.Create(ctx, sess.Model, sess.Config, history)
+	if err != nil {
+		return false, err
+	}
+	if len(history) == 0 {
+		if err := checkBudget(ctx, sess.Model, usage.EstimateTokens(systemPrompt), 0); err != nil {
+			return false, err
+		}
+		if err := ratelimit.ForProvider("gemini").Wait(ctx); err != nil {
+			return false, err
+		}
+		resp, err := chat.Send(ctx, &genai.Part{Text: systemPrompt})
+		recordGeminiUsage(ctx, sess.Model, "system_prompt", resp)
+		if err != nil {
+			return false, err
+		}
+	}
+	sess.Persona = &persona
+	sess.Chat = chat
+	log.Printf("[Reseed] Session %s re-seeded with the edited profile of %s (history kept: %v)", name, persona.Name, keepHistory && len(history) > 2)
+	return true, nil
+}
+
 // AnswerQuestion answers a question as a persona, maintaining chat history.
 func (c *Client) AnswerQuestion(ctx context.Context, persona Persona, question string, sm *SessionManager, businessContext string) (string, error) {
 	sess, err := sm.GetOrCreateSession(ctx, persona, businessContext)
 	if err != nil {
 		return "", err
 	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	cacheKey := chatCacheKey(sess.Model, sess.Config, sess.Chat, question)
+	if cached, ok := cache.GetGlobalCache().Get(cacheKey); ok {
+		if replayed, err := sm.replayCachedTurn(ctx, sess, sess.Chat, question, cached); err == nil {
+			sess.Chat = replayed
+			log.Printf("[AnswerQuestion] Using cached answer for persona %s", persona.Name)
+			return cached, nil
+		}
+	}
+
+	if err := checkBudget(ctx, sess.Model, estimateChatTokens(sess.Chat, question), 0); err != nil {
+		return "", err
+	}
 
 	// Start timing the answer generation
 	timer := timing.Start("gemini_answer_question")
@@ -325,6 +724,10 @@ func (c *Client) AnswerQuestion(ctx context.Context, persona Persona, question s
 
 	// Retry loop with exponential backoff for rate limits
 	for attempt := 1; attempt <= geminiMaxRetries; attempt++ {
+		if err := ratelimit.ForProvider("gemini").Wait(ctx); err != nil {
+			timer.Stop()
+			return "", err
+		}
 		resp, lastErr = sess.Chat.Send(ctx, &genai.Part{Text: question})
 
 		if lastErr == nil {
@@ -357,30 +760,55 @@ func (c *Client) AnswerQuestion(ctx context.Context, persona Persona, question s
 
 	timing.LogOperationWithDetails(timer.Name(), timer.Duration(), true, fmt.Sprintf("persona=%s prompt_len=%d", persona.Name, promptLen))
 	timer.Stop()
+	recordGeminiUsage(ctx, sess.Model, "answer_question", resp)
 
 	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
 		return "", fmt.Errorf("no response from Gemini")
 	}
-	return resp.Candidates[0].Content.Parts[0].Text, nil
+	answer := resp.Candidates[0].Content.Parts[0].Text
+	if err := cache.GetGlobalCache().Put(cacheKey, answer); err != nil {
+		log.Printf("[AnswerQuestion] Failed to cache answer for persona %s: %v", persona.Name, err)
+	}
+	return answer, nil
+}
+
+// RestoreAnswer appends a previously generated answer to the persona's chat history
+// without calling the model, so a resumed workflow continues the same conversation.
+func (c *Client) RestoreAnswer(ctx context.Context, persona Persona, question, answer string, sm *SessionManager, businessContext string) error {
+	sess, err := sm.GetOrCreateSession(ctx, persona, businessContext)
+	if err != nil {
+		return err
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	chat, err := sm.replayCachedTurn(ctx, sess, sess.Chat, question, answer)
+	if err != nil {
+		return err
+	}
+	sess.Chat = chat
+	return nil
 }
 
 // GeneratePersonaImage calls Imagen 3 to generate an avatar image for a persona
 // NOTE: This model may incur costs depending on your API tier.
 func (c *Client) GeneratePersonaImage(ctx context.Context, persona Persona) ([]byte, error) {
-	prompt := fmt.Sprintf(
-		"Generate a realistic professional headshot photo of a person for a business persona profile. Name: %s. Role: %s. Description: %s. Background: %s. Goals: %s. The image should be a portrait, neutral background, natural lighting, and suitable for a business context.",
-		persona.Name, persona.Role, persona.Description, persona.Background, persona.Goals,
-	)
+	prompt := renderPrompt(ctx, prompts.Image, prompts.ImageData{Persona: persona})
 	model := "models/imagen-3.0-generate-002"
 	config := &genai.GenerateContentConfig{
 		ResponseModalities: []string{"TEXT", "IMAGE"},
 	}
+	if err := checkBudget(ctx, model, 0, 1); err != nil {
+		return nil, err
+	}
 
 	var resp *genai.GenerateContentResponse
 	var lastErr error
 
 	// Retry loop with exponential backoff for rate limits
 	for attempt := 1; attempt <= geminiMaxRetries; attempt++ {
+		if err := ratelimit.ForProvider("gemini").Wait(ctx); err != nil {
+			return nil, err
+		}
 		resp, lastErr = c.genai.Models.GenerateContent(
 			ctx,
 			model,
@@ -413,6 +841,7 @@ func (c *Client) GeneratePersonaImage(ctx context.Context, persona Persona) ([]b
 	if lastErr != nil {
 		return nil, fmt.Errorf("Imagen image generation failed: %w", lastErr)
 	}
+	usage.GetGlobalTracker().Record(ctx, model, "persona_image", 0, 0, 1)
 
 	for _, part := range resp.Candidates[0].Content.Parts {
 		if part.InlineData != nil && len(part.InlineData.Data) > 0 {
@@ -424,13 +853,15 @@ func (c *Client) GeneratePersonaImage(ctx context.Context, persona Persona) ([]b
 
 // GeneratePersonaImageOpenAI generates a persona image using OpenAI DALL-E
 // Uses exponential backoff with jitter for retries on rate limits and server errors
-func GeneratePersonaImageOpenAI(persona Persona) ([]byte, error) {
-	_ = godotenv.Load("../.env") // Try parent dir for test, fallback to cwd
-	apiKey := os.Getenv("OPENAI_API_KEY")
+func GeneratePersonaImageOpenAI(ctx context.Context, persona Persona) ([]byte, error) {
+	apiKey := configFrom(ctx).OpenAI.APIKey
 	if apiKey == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY not set in environment or .env")
+		return nil, fmt.Errorf("OPENAI_API_KEY not set in environment or config")
 	}
-	prompt := fmt.Sprintf("Business Appropriate Headshot of %s, a %s. %s, %s, %s. The headshot should be tightly cropped, centered on the face, with the full head visible and minimal chest.", persona.Name, persona.Role, persona.Age, persona.Sex, persona.Race)
+	if err := checkBudget(ctx, openAIImageModel, 0, 1); err != nil {
+		return nil, err
+	}
+	prompt := renderPrompt(ctx, prompts.Image, prompts.ImageData{Persona: persona})
 
 	// Start timing the total DALL-E operation
 	totalTimer := timing.Start("openai_dalle_total")
@@ -447,8 +878,12 @@ func GeneratePersonaImageOpenAI(persona Persona) ([]byte, error) {
 	for attempt := 1; attempt <= openAIMaxRetries; attempt++ {
 		// Start timing this API call attempt
 		apiTimer := timing.Start(fmt.Sprintf("openai_dalle_api_attempt_%d", attempt))
+		if err := ratelimit.ForProvider("openai").Wait(ctx); err != nil {
+			apiTimer.StopAndLog(false)
+			return nil, err
+		}
 
-		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonBody))
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 		if err != nil {
 			apiTimer.StopAndLog(false)
 			return nil, fmt.Errorf("Failed to create OpenAI request: %w", err)
@@ -526,6 +961,8 @@ func GeneratePersonaImageOpenAI(persona Persona) ([]byte, error) {
 
 		timing.LogOperationWithDetails(apiTimer.Name(), apiTimer.Duration(), true, fmt.Sprintf("status_code=%d attempt=%d", resp.StatusCode, attempt))
 		apiTimer.Stop()
+		// The image is billed once generated, whether or not the download succeeds
+		usage.GetGlobalTracker().Record(ctx, openAIImageModel, "persona_image", 0, 0, 1)
 
 		var parsed struct {
 			Data []struct {
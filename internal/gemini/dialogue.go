@@ -0,0 +1,202 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/molecule"
+	"github.com/jaypaulb/AI-personas/internal/usage"
+)
+
+// dialogueAnchorPadding is the margin of the dialogue anchor around its notes, as a fraction of a note's width
+const dialogueAnchorPadding = 0.1
+
+// dialogueTurns returns how many replies a conversation between two personas runs to
+func dialogueTurns(ctx context.Context) int {
+	return configFrom(ctx).Workflow.DialogueTurns
+}
+
+// dialogueBoxes returns the top-left corners of turns notes of the given size
+// and scale, in order along the connector from the answer src to the answer dst.
+// They are spread evenly between the two answers, or, if the connector is too
+// short for that, in a row centred on it and set off to one side.
+func dialogueBoxes(src, dst map[string]interface{}, turns int, width, height, scale float64) [][2]float64 {
+	sx, sy, sw, sh, _ := widgetBox(src)
+	dx, dy, dw, dh, _ := widgetBox(dst)
+	ss, ds := molecule.ExtractWidgetScale(src), molecule.ExtractWidgetScale(dst)
+	ax, ay := sx+sw*ss/2, sy+sh*ss/2
+	bx, by := dx+dw*ds/2, dy+dh*ds/2
+	length := math.Hypot(bx-ax, by-ay)
+	ux, uy := 1.0, 0.0
+	if length > 0 {
+		ux, uy = (bx-ax)/length, (by-ay)/length
+	}
+	w, h := width*scale, height*scale
+	// Extent of a note along the connector and across it
+	along := (math.Abs(ux)*w + math.Abs(uy)*h) * 1.1
+	across := (math.Abs(uy)*w + math.Abs(ux)*h) * 1.2
+	step := length / float64(turns+1)
+	var offX, offY float64
+	if step < along {
+		step = along
+		offX, offY = -uy*across, ux*across
+	}
+	mx, my := (ax+bx)/2+offX, (ay+by)/2+offY
+	boxes := make([][2]float64, turns)
+	for k := range boxes {
+		t := (float64(k) - float64(turns-1)/2) * step
+		boxes[k] = [2]float64{mx + ux*t - w/2, my + uy*t - h/2}
+	}
+	return boxes
+}
+
+// handlePersonaDialogue runs a conversation between the personas who wrote the
+// answer notes src and dst, joined by the connector drawn between them. Starting
+// with the src persona replying to the dst answer, they take turns to reply to
+// each other about the original question. Each reply goes in a note in its
+// persona's colour along the connector, and the replies are grouped in an anchor.
+func handlePersonaDialogue(ctx context.Context, client *canvusapi.Client, widgets []map[string]interface{}, g noteGraph, src, dst map[string]interface{}, chatTokenLimit int) {
+	srcTitle, _ := src["title"].(string)
+	dstTitle, _ := dst["title"].(string)
+	lang := canvasLanguage(client, widgets)
+	fail := func(first, second string, err error) {
+		log.Printf("[HandlePersonaDialogue] Dialogue between %s and %s stopped: %v", first, second, err)
+		x, y, width := noteBox(dst)
+		createTransientNote(client, x, y-transientNoteHeight-20, width,
+			i18n.T(lang, i18n.DialogueErrorTitle), i18n.T(lang, i18n.DialogueErrorText, first, second, err), FollowupErrorColor)
+	}
+	first, err := followupPersona(client, widgets, src)
+	if err != nil {
+		fail(srcTitle, dstTitle, err)
+		return
+	}
+	second, err := followupPersona(client, widgets, dst)
+	if err != nil {
+		fail(srcTitle, dstTitle, err)
+		return
+	}
+	if strings.EqualFold(first.Name, second.Name) {
+		log.Printf("[HandlePersonaDialogue] %q and %q are both by %s, ignoring", srcTitle, dstTitle, first.Name)
+		return
+	}
+	// The conversation is about the first question found behind either answer
+	srcHistory, root := followupHistory(ctx, g, src, first.Name)
+	dstHistory, dstRoot := followupHistory(ctx, g, dst, second.Name)
+	if root == nil {
+		root = dstRoot
+	}
+	if root == nil {
+		fail(first.Name, second.Name, fmt.Errorf("no question found connected to either answer"))
+		return
+	}
+	qnoteID, _ := root["id"].(string)
+	question, _ := root["text"].(string)
+	question = strings.TrimSpace(question)
+
+	anchorName := atom.DialogueAnchorName(first.Name, second.Name, qnoteID)
+	for _, w := range widgets {
+		if name, _ := w["anchor_name"].(string); name == anchorName {
+			log.Printf("[HandlePersonaDialogue] %s already on the canvas, ignoring", anchorName)
+			return
+		}
+	}
+	// Keyed by the pair and the question, so connectors drawn both ways start one dialogue
+	key := client.CanvasID + "/" + anchorName
+	if _, busy := followupsInFlight.LoadOrStore(key, true); busy {
+		return
+	}
+	defer followupsInFlight.Delete(key)
+
+	ctx = usage.WithScope(ctx, usage.Scope{Canvas: client.CanvasID, Question: qnoteID})
+	geminiClient, err := NewClient(ctx)
+	if err != nil {
+		fail(first.Name, second.Name, fmt.Errorf("failed to create Gemini client: %w", err))
+		return
+	}
+	businessContextStr, _, err := getBusinessContextWithCache(ctx, qnoteID, client, widgets)
+	if err != nil {
+		fail(first.Name, second.Name, fmt.Errorf("failed to get business context: %w", err))
+		return
+	}
+	// Each persona remembers the conversation that led to its answer
+	sessionManager := NewSessionManager(geminiClient.GenaiClient())
+	defer trackSessions(client.CanvasID, sessionManager)()
+	histories := [][][2]string{srcHistory, dstHistory}
+	for i, p := range []Persona{first, second} {
+		for _, turn := range histories[i] {
+			if err := geminiClient.RestoreAnswer(ctx, p, turn[0], turn[1], sessionManager, businessContextStr); err != nil {
+				fail(first.Name, second.Name, fmt.Errorf("failed to restore the conversation so far: %w", err))
+				return
+			}
+		}
+	}
+	turns := dialogueTurns(ctx)
+	_, _, width, height, _ := widgetBox(src)
+	scale := molecule.ExtractWidgetScale(src)
+	boxes := dialogueBoxes(src, dst, turns, width, height, scale)
+	speakers := []Persona{first, second}
+	colors := []interface{}{src["background_color"], dst["background_color"]}
+	said, _ := dst["text"].(string)
+	var noteIDs []string
+	for k := 0; k < turns; k++ {
+		speaker, listener := speakers[k%2], speakers[(k+1)%2]
+		turnCtx := usage.WithScope(ctx, usage.Scope{Canvas: client.CanvasID, Question: qnoteID, Persona: speaker.Name})
+		reply, err := geminiClient.AnswerQuestion(turnCtx, speaker, renderDialoguePrompt(ctx, listener.Name, question, strings.TrimSpace(said)), sessionManager, businessContextStr)
+		if err == nil && len(reply) > chatTokenLimit {
+			reply, err = geminiClient.AnswerQuestion(turnCtx, speaker, renderSuccinctPrompt(ctx, chatTokenLimit), sessionManager, businessContextStr)
+		}
+		if err != nil {
+			fail(first.Name, second.Name, err)
+			break
+		}
+		note, err := client.CreateNote(map[string]interface{}{
+			"title":            atom.DialogueReplyTitle(speaker.Name),
+			"text":             reply,
+			"location":         map[string]interface{}{"x": boxes[k][0], "y": boxes[k][1]},
+			"size":             map[string]interface{}{"width": width, "height": height},
+			"background_color": colors[k%2],
+			"scale":            scale,
+		})
+		if err != nil {
+			fail(first.Name, second.Name, fmt.Errorf("failed to create reply note: %w", err))
+			break
+		}
+		noteID, _ := note["id"].(string)
+		if len(noteIDs) > 0 {
+			if _, err := client.CreateConnector(BuildConnectorPayload(noteIDs[len(noteIDs)-1], noteID)); err != nil {
+				log.Printf("[warn] CreateConnector failed for dialogue reply: %v", err)
+			}
+		}
+		noteIDs = append(noteIDs, noteID)
+		said = reply
+	}
+	if len(noteIDs) == 0 {
+		return
+	}
+
+	// Group the replies so the conversation can be moved and recognised as one
+	pad := width * scale * dialogueAnchorPadding
+	bb := molecule.BoundingBox{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+	for _, b := range boxes[:len(noteIDs)] {
+		bb.MinX, bb.MinY = math.Min(bb.MinX, b[0]-pad), math.Min(bb.MinY, b[1]-pad)
+		bb.MaxX, bb.MaxY = math.Max(bb.MaxX, b[0]+width*scale+pad), math.Max(bb.MaxY, b[1]+height*scale+pad)
+	}
+	if _, err := client.CreateAnchor(molecule.BuildAnchorPayload(anchorName, bb, noteIDs)); err != nil {
+		log.Printf("[HandlePersonaDialogue] Failed to create anchor %s: %v", anchorName, err)
+	}
+	log.Printf("[HandlePersonaDialogue] %s and %s exchanged %d replies", first.Name, second.Name, len(noteIDs))
+}
+
+// isPersonaDialogue reports whether a connector drawn from a persona's answer
+// note to dst starts a conversation: dst is another persona's answer
+func isPersonaDialogue(srcTitle, dstTitle string) bool {
+	srcName, _ := atom.PersonaAnswerName(srcTitle)
+	dstName, ok := atom.PersonaAnswerName(dstTitle)
+	return ok && !strings.EqualFold(srcName, dstName)
+}
@@ -0,0 +1,380 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/personastore"
+	"github.com/jaypaulb/AI-personas/internal/usage"
+)
+
+// FollowupErrorColor is the amber background color for follow-ups that could not be answered
+const FollowupErrorColor = LibraryErrorColor
+
+// maxFollowupChain bounds how many earlier turns a follow-up reads back along its connectors
+const maxFollowupChain = 20
+
+// followupsInFlight holds the follow-ups being answered, keyed "<answer note ID>/<question note ID>"
+var followupsInFlight sync.Map
+
+// noteGraph indexes the notes of a canvas and the connectors between them
+type noteGraph struct {
+	notes    map[string]map[string]interface{}
+	incoming map[string][]string // note ID -> IDs of the notes connected to it
+	outgoing map[string][]string // note ID -> IDs of the notes it is connected to
+}
+
+// newNoteGraph indexes widgets
+func newNoteGraph(widgets []map[string]interface{}) noteGraph {
+	g := noteGraph{
+		notes:    make(map[string]map[string]interface{}),
+		incoming: make(map[string][]string),
+		outgoing: make(map[string][]string),
+	}
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		switch typeStr {
+		case "Note":
+			id, _ := w["id"].(string)
+			g.notes[id] = w
+		case "Connector":
+			src, _ := w["src"].(map[string]interface{})
+			dst, _ := w["dst"].(map[string]interface{})
+			srcID, _ := src["id"].(string)
+			dstID, _ := dst["id"].(string)
+			if srcID != "" && dstID != "" {
+				g.incoming[dstID] = append(g.incoming[dstID], srcID)
+				g.outgoing[srcID] = append(g.outgoing[srcID], dstID)
+			}
+		}
+	}
+	return g
+}
+
+// from returns the first note connected to id that matches
+func (g noteGraph) from(id string, match func(title string) bool) map[string]interface{} {
+	for _, src := range g.incoming[id] {
+		if note, ok := g.notes[src]; ok {
+			if title, _ := note["title"].(string); match(title) {
+				return note
+			}
+		}
+	}
+	return nil
+}
+
+// isQuestionTitle reports whether a note title is that of a question rather than an answer or a persona
+func isQuestionTitle(title string) bool {
+	_, answer := atom.PersonaAnswerName(title)
+	_, _, _, persona := atom.ParsePersonaNoteTitle(title)
+	return !answer && !persona
+}
+
+// answerBy returns a title matcher for the answer notes of the persona named name
+func answerBy(name string) func(string) bool {
+	return func(title string) bool {
+		n, ok := atom.PersonaAnswerName(title)
+		return ok && strings.EqualFold(n, name)
+	}
+}
+
+// followupHistory returns the conversation that led to the persona's answer note
+// answer, oldest first, read back along the connectors: each answer comes from
+// the question connected to it (a meta answer from the persona's answer), and
+// each question from the persona's earlier answer connected to it, if any.
+// root is the note of the first question, nil if none was found.
+func followupHistory(ctx context.Context, g noteGraph, answer map[string]interface{}, name string) (turns [][2]string, root map[string]interface{}) {
+	for depth := 0; answer != nil && depth < maxFollowupChain; depth++ {
+		id, _ := answer["id"].(string)
+		title, _ := answer["title"].(string)
+		text, _ := answer["text"].(string)
+		if strings.HasSuffix(title, " Meta Answer") {
+			first := g.from(id, func(t string) bool { return t == name+" Answer" })
+			if first == nil {
+				break
+			}
+			// The meta prompt showed the other personas' answers to the same question
+			var others []string
+			firstID, _ := first["id"].(string)
+			if q := g.from(firstID, isQuestionTitle); q != nil {
+				qID, _ := q["id"].(string)
+				for _, dst := range g.outgoing[qID] {
+					t, _ := g.notes[dst]["title"].(string)
+					if n, ok := atom.PersonaAnswerName(t); ok && n != name && t == n+" Answer" {
+						otherText, _ := g.notes[dst]["text"].(string)
+						others = append(others, fmt.Sprintf("%s said: %s", n, otherText))
+					}
+				}
+			}
+			turns = append(turns, [2]string{renderMetaPrompt(ctx, name, others), text})
+			answer = first
+			continue
+		}
+		question := g.from(id, isQuestionTitle)
+		if question == nil {
+			break
+		}
+		root = question
+		qText, _ := question["text"].(string)
+		turns = append(turns, [2]string{strings.TrimSpace(qText), text})
+		qID, _ := question["id"].(string)
+		answer = g.from(qID, answerBy(name))
+	}
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return turns, root
+}
+
+// followupPersona returns the persona who wrote an answer note: the persona
+// named in its title, told apart by its colour when several share the name,
+// else the stored persona of that name
+func followupPersona(client *canvusapi.Client, widgets []map[string]interface{}, answer map[string]interface{}) (Persona, error) {
+	title, _ := answer["title"].(string)
+	bg, _ := answer["background_color"].(string)
+	name, ok := atom.PersonaAnswerName(title)
+	if !ok {
+		return Persona{}, fmt.Errorf("%q is not a persona answer", title)
+	}
+	var candidates []map[string]interface{}
+	for _, note := range personaNotes(widgets) {
+		noteTitle, _ := note["title"].(string)
+		if _, _, noteName, _ := atom.ParsePersonaNoteTitle(noteTitle); !strings.EqualFold(noteName, name) {
+			continue
+		}
+		// A note in the answer's colour is tried first
+		if color, _ := note["background_color"].(string); strings.EqualFold(color, bg) {
+			candidates = append([]map[string]interface{}{note}, candidates...)
+		} else {
+			candidates = append(candidates, note)
+		}
+	}
+	for _, note := range candidates {
+		if p, ok := personaFromNote(client, note); ok {
+			return p, nil
+		}
+	}
+	if p, ok := personastore.GetGlobalStore().FindByName(client.CanvasID, name); ok {
+		return p, nil
+	}
+	return Persona{}, fmt.Errorf("persona %s not found", name)
+}
+
+// followupBox returns where the answer to the follow-up question dst goes:
+// beyond dst, continuing the line from the answer src it follows, and further
+// along that line while another note is in the way
+func followupBox(widgets []map[string]interface{}, src, dst map[string]interface{}) (x, y, width, height, scale float64) {
+	scaleOf := func(w map[string]interface{}) float64 {
+		if s, ok := w["scale"].(float64); ok && s > 0 {
+			return s
+		}
+		return 1
+	}
+	sx, sy, sw, sh, _ := widgetBox(src)
+	dx, dy, dw, dh, _ := widgetBox(dst)
+	ss, scale := scaleOf(src), scaleOf(dst)
+	vx := (dx + dw*scale/2) - (sx + sw*ss/2)
+	vy := (dy + dh*scale/2) - (sy + sh*ss/2)
+	if vx == 0 && vy == 0 {
+		vx = dw * scale * 1.2
+	}
+	x, y = dx+vx, dy+vy
+	overlaps := func(x, y float64) bool {
+		for _, w := range widgets {
+			if typeStr, _ := w["widget_type"].(string); typeStr != "Note" {
+				continue
+			}
+			wx, wy, ww, wh, ok := widgetBox(w)
+			s := scaleOf(w)
+			if ok && x < wx+ww*s && wx < x+dw*scale && y < wy+wh*s && wy < y+dh*scale {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < maxFollowupChain && overlaps(x, y); i++ {
+		x, y = x+vx, y+vy
+	}
+	return x, y, dw, dh, scale
+}
+
+// HandleFollowupConnector answers a follow-up question: a connector drawn from a
+// persona's answer note (including a follow-up answer, for a chain of follow-ups)
+// to a note holding a question. The persona continues the conversation that led
+// to that answer and its reply goes beyond the question, connected to it. A
+// connector to another persona's answer starts a conversation between the two.
+func HandleFollowupConnector(ctx context.Context, client *canvusapi.Client, connectorEvent canvus.WidgetEvent, chatTokenLimit int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[error] HandleFollowupConnector panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	log.Printf("[HandleFollowupConnector] called: connectorID=%s", connectorEvent.ID)
+	ctx = withSettings(ctx, client.Name)
+	// Extract src and dst IDs from connector data
+	src, srcOK := connectorEvent.Data["src"].(map[string]interface{})
+	dst, dstOK := connectorEvent.Data["dst"].(map[string]interface{})
+	if !srcOK || !dstOK {
+		log.Printf("[HandleFollowupConnector] src/dst missing in connector data")
+		return
+	}
+	srcID, srcIDOK := src["id"].(string)
+	dstID, dstIDOK := dst["id"].(string)
+	if !srcIDOK || !dstIDOK {
+		log.Printf("[HandleFollowupConnector] srcID/dstID missing or not string")
+		return
+	}
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		log.Printf("[HandleFollowupConnector] failed to fetch widgets: %v", err)
+		return
+	}
+	g := newNoteGraph(widgets)
+	srcNote, srcIsNote := g.notes[srcID]
+	dstNote, dstIsNote := g.notes[dstID]
+	if !srcIsNote || !dstIsNote {
+		log.Printf("[HandleFollowupConnector] connector %s does not join two notes", connectorEvent.ID)
+		return
+	}
+	// Check if src is a persona answer note (title ends with ' Answer' and color matches persona colors)
+	title, _ := srcNote["title"].(string)
+	bg, _ := srcNote["background_color"].(string)
+	isPersonaColor := false
+	for _, c := range personaColors {
+		isPersonaColor = isPersonaColor || strings.EqualFold(c, bg)
+	}
+	if _, ok := atom.PersonaAnswerName(title); !ok || !isPersonaColor {
+		log.Printf("[HandleFollowupConnector] src note is not a persona answer note (title/bg)")
+		return
+	}
+	dstTitle, _ := dstNote["title"].(string)
+	dstText, _ := dstNote["text"].(string)
+	if isPersonaDialogue(title, dstTitle) {
+		handlePersonaDialogue(ctx, client, widgets, g, srcNote, dstNote, chatTokenLimit)
+		return
+	}
+	// Check if dst is a note with a question, not an answer (the app's own answer-to-answer connectors)
+	if !isQuestionTitle(dstTitle) || strings.HasSuffix(dstTitle, " Interview Question") || atom.IsDialogueReplyTitle(dstTitle) {
+		log.Printf("[HandleFollowupConnector] dst note is not a question note: %q", dstTitle)
+		return
+	}
+	if !atom.EndsWithQuestionMark(dstText) {
+		log.Printf("[HandleFollowupConnector] dst note does not contain a question")
+		dstX, dstY, dstW, dstH, _ := widgetBox(dstNote)
+		lang := canvasLanguage(client, widgets)
+		noteMeta := map[string]interface{}{
+			"title":            i18n.T(lang, i18n.HelperQuestionTitle),
+			"text":             i18n.T(lang, i18n.HelperFollowupText),
+			"location":         map[string]interface{}{"x": dstX - 1.2*dstW, "y": dstY - 0.33*dstH},
+			"size":             map[string]interface{}{"width": dstW, "height": dstH * 0.7},
+			"background_color": "#e0e0e0",
+		}
+		if _, err := client.CreateNote(noteMeta); err != nil {
+			log.Printf("[warn] CreateNote failed for followup helper: %v", err)
+		}
+		return
+	}
+	key := srcID + "/" + dstID
+	if _, busy := followupsInFlight.LoadOrStore(key, true); busy {
+		return
+	}
+	defer followupsInFlight.Delete(key)
+
+	fail := func(err error) {
+		log.Printf("[HandleFollowupConnector] Follow-up %s to %q not answered: %v", dstID, title, err)
+		x, y, width := noteBox(dstNote)
+		lang := canvasLanguage(client, widgets)
+		createTransientNote(client, x, y-transientNoteHeight-20, width,
+			i18n.T(lang, i18n.FollowupErrorTitle), i18n.T(lang, i18n.FollowupErrorText, title, err), FollowupErrorColor)
+	}
+	persona, err := followupPersona(client, widgets, srcNote)
+	if err != nil {
+		fail(err)
+		return
+	}
+	// A connector drawn twice, or an event delivered twice, must not answer twice
+	for _, id := range g.outgoing[dstID] {
+		if t, _ := g.notes[id]["title"].(string); t == atom.FollowupAnswerTitle(persona.Name) {
+			log.Printf("[HandleFollowupConnector] %s already answered follow-up %s", persona.Name, dstID)
+			return
+		}
+	}
+
+	// Generate follow-up answer using the persona, after the conversation that led to the answer
+	ctx = usage.WithScope(ctx, usage.Scope{Canvas: client.CanvasID, Question: dstID, Persona: persona.Name})
+	geminiClient, err := NewClient(ctx)
+	if err != nil {
+		fail(fmt.Errorf("failed to create Gemini client: %w", err))
+		return
+	}
+	businessContextStr, _, err := getBusinessContextWithCache(ctx, dstID, client, widgets)
+	if err != nil {
+		fail(fmt.Errorf("failed to get business context: %w", err))
+		return
+	}
+	sessionManager := NewSessionManager(geminiClient.GenaiClient())
+	defer trackSessions(client.CanvasID, sessionManager)()
+	history, _ := followupHistory(ctx, g, srcNote, persona.Name)
+	for _, turn := range history {
+		if err := geminiClient.RestoreAnswer(ctx, persona, turn[0], turn[1], sessionManager, businessContextStr); err != nil {
+			fail(fmt.Errorf("failed to restore the conversation so far: %w", err))
+			return
+		}
+	}
+	answer, err := geminiClient.AnswerQuestion(ctx, persona, strings.TrimSpace(dstText), sessionManager, businessContextStr)
+	if err == nil && len(answer) > chatTokenLimit {
+		succinctPrompt := renderSuccinctPrompt(ctx, chatTokenLimit)
+		answer, err = geminiClient.AnswerQuestion(ctx, persona, succinctPrompt, sessionManager, businessContextStr)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Create follow-up answer note
+	fupX, fupY, fupW, fupH, scale := followupBox(widgets, srcNote, dstNote)
+	fupMeta := map[string]interface{}{
+		"title":            atom.FollowupAnswerTitle(persona.Name),
+		"text":             answer,
+		"location":         map[string]interface{}{"x": fupX, "y": fupY},
+		"size":             map[string]interface{}{"width": fupW, "height": fupH},
+		"background_color": bg,
+		"scale":            scale,
+	}
+	fupNote, err := client.CreateNote(fupMeta)
+	if err != nil {
+		log.Printf("[HandleFollowupConnector] failed to create follow-up note: %v", err)
+		return
+	}
+	fupNoteID, _ := fupNote["id"].(string)
+	if fupNoteID == "" {
+		log.Printf("[HandleFollowupConnector] follow-up note ID missing")
+		return
+	}
+	// Create connector from dst to follow-up note, copying settings from original connector
+	connMeta := connectorEvent.Data
+	connMetaCpy := make(map[string]interface{})
+	for k, v := range connMeta {
+		connMetaCpy[k] = v
+	}
+	// Update src/dst for new connector
+	delete(connMetaCpy, "id")
+	connMetaCpy["src"] = map[string]interface{}{"id": dstID, "auto_location": true, "tip": "none"}
+	connMetaCpy["dst"] = map[string]interface{}{"id": fupNoteID, "auto_location": true, "tip": "solid-equilateral-triangle"}
+	connMetaCpy["widget_type"] = "Connector"
+	if _, err := client.CreateConnector(connMetaCpy); err != nil {
+		log.Printf("[warn] CreateConnector failed for follow-up: %v", err)
+	}
+	if _, err := client.UpdateNote(dstID, map[string]interface{}{"background_color": "#ccffcc"}); err != nil {
+		log.Printf("[warn] Failed to mark follow-up %s answered: %v", dstID, err)
+	}
+	log.Printf("[HandleFollowupConnector] Follow-up answer note and connector created for persona %s (after %d earlier turns)", persona.Name, len(history))
+}
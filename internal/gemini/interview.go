@@ -0,0 +1,400 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/molecule"
+	"github.com/jaypaulb/AI-personas/internal/types"
+	"github.com/jaypaulb/AI-personas/internal/usage"
+)
+
+// InterviewErrorColor is the amber background color for interview questions that could not be answered
+const InterviewErrorColor = LibraryErrorColor
+
+// Interview column layout, as fractions of the persona note width
+const (
+	interviewQuestionHeight = 0.4
+	interviewAnswerHeight   = 0.8
+	interviewGap            = 0.05
+)
+
+// interviewThreads serialises the turns of each interview, keyed "<canvas ID>/<persona name>"
+var interviewThreads sync.Map // -> *sync.Mutex
+
+// interviewNotesInFlight holds the IDs of Interview notes being answered
+var interviewNotesInFlight sync.Map
+
+// interviewee is the persona an interview is held with, and its persona note
+type interviewee struct {
+	persona Persona
+	note    map[string]interface{}
+	panel   types.Panel
+	anchor  map[string]interface{} // the panel's anchor, nil if missing
+}
+
+// personaNotes returns the persona notes of every panel among widgets, leaving out failed ones
+func personaNotes(widgets []map[string]interface{}) []map[string]interface{} {
+	var notes []map[string]interface{}
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		title, _ := w["title"].(string)
+		if _, _, name, ok := atom.ParsePersonaNoteTitle(title); ok && typeStr == "Note" && !strings.EqualFold(name, "FAILED") {
+			notes = append(notes, w)
+		}
+	}
+	return notes
+}
+
+// findAnchor returns the anchor named name among widgets, or nil
+func findAnchor(widgets []map[string]interface{}, name string) map[string]interface{} {
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		anchorName, _ := w["anchor_name"].(string)
+		if typeStr == "Anchor" && strings.EqualFold(strings.TrimSpace(anchorName), name) {
+			return w
+		}
+	}
+	return nil
+}
+
+// newInterviewee reads the persona on a persona note
+func newInterviewee(client *canvusapi.Client, widgets []map[string]interface{}, note map[string]interface{}) (interviewee, bool) {
+	title, _ := note["title"].(string)
+	panel, _, _, _ := atom.ParsePersonaNoteTitle(title)
+	p, ok := personaFromNote(client, note)
+	if !ok {
+		return interviewee{}, false
+	}
+	return interviewee{persona: p, note: note, panel: panel, anchor: findAnchor(widgets, atom.PanelAnchorName(panel))}, true
+}
+
+// findInterviewee returns the persona named by ref: a persona name, or a persona
+// note title without the name, "Persona 2" or "Internal Persona 2"
+func findInterviewee(client *canvusapi.Client, widgets []map[string]interface{}, ref string) (interviewee, error) {
+	for _, note := range personaNotes(widgets) {
+		title, _ := note["title"].(string)
+		panel, slot, name, _ := atom.ParsePersonaNoteTitle(title)
+		label := strings.TrimSuffix(atom.PersonaNoteTitle(panel, slot, ""), ": ")
+		if !strings.EqualFold(name, ref) && !strings.EqualFold(label, ref) {
+			continue
+		}
+		if who, ok := newInterviewee(client, widgets, note); ok {
+			return who, nil
+		}
+	}
+	return interviewee{}, fmt.Errorf("there is no persona %q on the canvas", ref)
+}
+
+// scaledBox is widgetBox with the size multiplied by the widget's scale
+func scaledBox(w map[string]interface{}) (x, y, width, height float64, ok bool) {
+	x, y, width, height, ok = widgetBox(w)
+	scale := molecule.ExtractWidgetScale(w)
+	return x, y, width * scale, height * scale, ok
+}
+
+// intervieweeFor returns the persona a question note is put to on its own: the
+// persona whose note it is connected from, or in whose column it is placed
+func intervieweeFor(client *canvusapi.Client, widgets []map[string]interface{}, qnoteID string) (interviewee, bool) {
+	notes := personaNotes(widgets)
+	byID := make(map[string]map[string]interface{}, len(notes))
+	for _, note := range notes {
+		id, _ := note["id"].(string)
+		byID[id] = note
+	}
+	for _, w := range widgets {
+		if typeStr, _ := w["widget_type"].(string); typeStr != "Connector" {
+			continue
+		}
+		src, _ := w["src"].(map[string]interface{})
+		dst, _ := w["dst"].(map[string]interface{})
+		srcID, _ := src["id"].(string)
+		dstID, _ := dst["id"].(string)
+		if note, ok := byID[srcID]; ok && dstID == qnoteID {
+			return newInterviewee(client, widgets, note)
+		}
+	}
+
+	var qnote map[string]interface{}
+	for _, w := range widgets {
+		if id, _ := w["id"].(string); id == qnoteID {
+			qnote = w
+		}
+	}
+	qx, qy, qw, qh, ok := scaledBox(qnote)
+	if !ok {
+		return interviewee{}, false
+	}
+	cx, cy := qx+qw/2, qy+qh/2
+	for _, note := range notes {
+		x, y, width, _, ok := scaledBox(note)
+		if !ok || cx < x || cx > x+width || cy < y {
+			continue
+		}
+		title, _ := note["title"].(string)
+		panel, _, name, _ := atom.ParsePersonaNoteTitle(title)
+		// The column runs from the persona note down to the bottom of its anchor,
+		// or once an interview has started, to just past the interview so far
+		bottom, last := interviewBottom(widgets, name, note, findAnchor(widgets, atom.PanelAnchorName(panel)))
+		if last != "" {
+			bottom += width
+		}
+		if cy <= bottom {
+			return newInterviewee(client, widgets, note)
+		}
+	}
+	return interviewee{}, false
+}
+
+// interviewThread returns the answered questions and answers of the interview
+// with the persona named name, top to bottom
+func interviewThread(widgets []map[string]interface{}, name string) []map[string]interface{} {
+	var thread []map[string]interface{}
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		title, _ := w["title"].(string)
+		if typeStr == "Note" && (title == atom.InterviewQuestionTitle(name) || title == atom.InterviewAnswerTitle(name)) {
+			thread = append(thread, w)
+		}
+	}
+	sort.SliceStable(thread, func(i, j int) bool {
+		_, yi, _, _, _ := widgetBox(thread[i])
+		_, yj, _, _, _ := widgetBox(thread[j])
+		return yi < yj
+	})
+	return thread
+}
+
+// interviewBottom returns the bottom edge of the interview column of the persona
+// named name: the last note of the interview so far, else the panel's anchor
+// (or the persona note without one). last is the ID of the last note, if any.
+func interviewBottom(widgets []map[string]interface{}, name string, note, anchor map[string]interface{}) (bottom float64, last string) {
+	if thread := interviewThread(widgets, name); len(thread) > 0 {
+		_, y, _, h, _ := scaledBox(thread[len(thread)-1])
+		last, _ = thread[len(thread)-1]["id"].(string)
+		return y + h, last
+	}
+	if _, y, _, h, ok := scaledBox(anchor); ok {
+		return y + h, ""
+	}
+	_, y, _, h, _ := scaledBox(note)
+	return y + h, ""
+}
+
+// interviewHistory returns the question and answer pairs of an interview thread
+func interviewHistory(thread []map[string]interface{}, name string) [][2]string {
+	var history [][2]string
+	question := ""
+	for _, w := range thread {
+		title, _ := w["title"].(string)
+		text, _ := w["text"].(string)
+		switch {
+		case title == atom.InterviewQuestionTitle(name):
+			question = text
+		case question != "":
+			history = append(history, [2]string{question, text})
+			question = ""
+		}
+	}
+	return history
+}
+
+// answerInterview puts the question in qnote to the interviewee, continuing the
+// conversation in their interview column: the question note moves to the bottom
+// of the column, the answer goes below it and a blank Interview note below that
+// takes the next question. Nothing on the canvas changes if there is no answer.
+func answerInterview(ctx context.Context, client *canvusapi.Client, qnoteID string, who interviewee, chatTokenLimit int) (string, string, error) {
+	name := who.persona.Name
+	mu, _ := interviewThreads.LoadOrStore(client.CanvasID+"/"+name, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+	ctx = usage.WithScope(ctx, usage.Scope{Canvas: client.CanvasID, Question: qnoteID, Persona: name})
+
+	// Fetch afresh: an earlier turn may have just extended the column
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch widgets: %w", err)
+	}
+	qnote, err := client.GetNote(qnoteID, false)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch question note: %w", err)
+	}
+	question, _ := qnote["text"].(string)
+	question = strings.TrimSpace(question)
+	businessContext, _, _, err := getPanelContextWithCacheAndMissing(ctx, qnoteID, client, widgets, who.panel)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get business context: %w", err)
+	}
+
+	geminiClient, err := NewClient(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	sessionManager := NewSessionManager(geminiClient.GenaiClient())
+	defer trackSessions(client.CanvasID, sessionManager)()
+	thread := interviewThread(widgets, name)
+	for _, turn := range interviewHistory(thread, name) {
+		if err := geminiClient.RestoreAnswer(ctx, who.persona, turn[0], turn[1], sessionManager, businessContext); err != nil {
+			return "", "", fmt.Errorf("failed to restore the interview so far: %w", err)
+		}
+	}
+	answer, err := geminiClient.AnswerQuestion(ctx, who.persona, question, sessionManager, businessContext)
+	if err == nil && len(answer) > chatTokenLimit {
+		answer, err = geminiClient.AnswerQuestion(ctx, who.persona, renderSuccinctPrompt(ctx, chatTokenLimit), sessionManager, businessContext)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	x, _, width, _, _ := widgetBox(who.note)
+	gap := width * interviewGap
+	bottom, last := interviewBottom(widgets, name, who.note, who.anchor)
+	qY := bottom + gap
+	qH := width * interviewQuestionHeight
+	if _, err := client.UpdateNote(qnoteID, map[string]interface{}{
+		"title":            atom.InterviewQuestionTitle(name),
+		"location":         map[string]interface{}{"x": x, "y": qY},
+		"size":             map[string]interface{}{"width": width, "height": qH},
+		"scale":            1.0,
+		"background_color": "#ccffcc",
+	}); err != nil {
+		return "", "", fmt.Errorf("failed to move the question into the interview: %w", err)
+	}
+	color, _ := who.note["background_color"].(string)
+	aY := qY + qH + gap
+	aH := width * interviewAnswerHeight
+	ansNote, err := client.CreateNote(map[string]interface{}{
+		"title":            atom.InterviewAnswerTitle(name),
+		"text":             answer,
+		"location":         map[string]interface{}{"x": x, "y": aY},
+		"size":             map[string]interface{}{"width": width, "height": aH},
+		"background_color": color,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create the answer note: %w", err)
+	}
+	ansID, _ := ansNote["id"].(string)
+	if last != "" {
+		if _, err := client.CreateConnector(BuildConnectorPayload(last, qnoteID)); err != nil {
+			log.Printf("[Interview] Failed to connect the interview with %s to its new question: %v", name, err)
+		}
+	}
+	if _, err := client.CreateConnector(BuildConnectorPayload(qnoteID, ansID)); err != nil {
+		log.Printf("[Interview] Failed to connect question %s to its answer: %v", qnoteID, err)
+	}
+
+	// Leave a blank note for the next question, unless one is waiting already
+	for _, w := range widgets {
+		title, _ := w["title"].(string)
+		text, _ := w["text"].(string)
+		if ref, ok := atom.ParseInterviewTitle(title); ok && strings.EqualFold(ref, name) && strings.TrimSpace(text) == "" {
+			return answer, ansID, nil
+		}
+	}
+	if _, err := client.CreateNote(map[string]interface{}{
+		"title":            atom.InterviewTitle(name),
+		"text":             "",
+		"location":         map[string]interface{}{"x": x, "y": aY + aH + gap},
+		"size":             map[string]interface{}{"width": width, "height": qH},
+		"background_color": "#ffffffff",
+	}); err != nil {
+		log.Printf("[Interview] Failed to create the next question note for %s: %v", name, err)
+	}
+	log.Printf("[Interview] %s answered question %s (turn %d)", name, qnoteID, len(interviewHistory(thread, name))+1)
+	return answer, ansID, nil
+}
+
+// HandleInterviewQuestion answers the question on a note titled "Interview: <persona>"
+// as that persona alone, in their interview column
+func HandleInterviewQuestion(ctx context.Context, client *canvusapi.Client, widget canvus.WidgetEvent, chatTokenLimit int) {
+	if _, busy := interviewNotesInFlight.LoadOrStore(widget.ID, true); busy {
+		return
+	}
+	defer interviewNotesInFlight.Delete(widget.ID)
+	ctx = withSettings(ctx, client.Name)
+	note, err := client.GetNote(widget.ID, false)
+	if err != nil {
+		log.Printf("[HandleInterviewQuestion] Failed to fetch note %s: %v", widget.ID, err)
+		return
+	}
+	title, _ := note["title"].(string)
+	text, _ := note["text"].(string)
+	ref, ok := atom.ParseInterviewTitle(title)
+	if !ok || !atom.EndsWithQuestionMark(text) {
+		return
+	}
+
+	x, y, width := noteBox(note)
+	lang := canvasLanguage(client, nil)
+	widgets, err := client.GetWidgets(false)
+	var who interviewee
+	if err == nil {
+		who, err = findInterviewee(client, widgets, ref)
+	}
+	if err == nil {
+		err = errors.New("cancelled while queued")
+		if !runQueuedQuietly(ctx, client, widget.ID, func(bool) {
+			_, _, err = answerInterview(ctx, client, widget.ID, who, chatTokenLimit)
+		}) {
+			log.Printf("[HandleInterviewQuestion] Question %s to %s aborted before leaving the queue", widget.ID, ref)
+			return
+		}
+	}
+	if err != nil {
+		log.Printf("[HandleInterviewQuestion] Question %s to %s not answered: %v", widget.ID, ref, err)
+		createTransientNote(client, x, y-transientNoteHeight-20, width,
+			i18n.T(lang, i18n.InterviewErrorTitle), i18n.T(lang, i18n.InterviewErrorText, ref, err), InterviewErrorColor)
+	}
+}
+
+// interviewQuestion answers a New_AI_Question note put to one persona as an
+// interview, recording the answer in the Qnote's checkpoint
+func interviewQuestion(ctx context.Context, client *canvusapi.Client, qnoteID string, who interviewee, chatTokenLimit int) {
+	log.Printf("[HandleAIQuestion] Qnote %s is an interview with %s", qnoteID, who.persona.Name)
+	store := checkpoint.GetGlobalStore()
+	if val, ok := qnoteHelperNotes.Load(qnoteID); ok {
+		if err := client.DeleteNote(val.(string)); err != nil {
+			log.Printf("[warn] DeleteNote failed for question helper note %s: %v", val.(string), err)
+		}
+		qnoteHelperNotes.Delete(qnoteID)
+	}
+	note, err := client.GetNote(qnoteID, false)
+	if err == nil {
+		question, _ := note["text"].(string)
+		store.Update(qnoteID, func(r *checkpoint.Record) { r.Question = strings.TrimSpace(question) })
+	}
+	answer, answerID, err := answerInterview(ctx, client, qnoteID, who, chatTokenLimit)
+	if err != nil {
+		log.Printf("[HandleAIQuestion] Interview question %s to %s not answered: %v", qnoteID, who.persona.Name, err)
+		store.Finish(qnoteID, checkpoint.StatusFailed, err.Error())
+		if errors.Is(err, usage.ErrBudgetExceeded) {
+			createBudgetHelperNote(client, qnoteID, err)
+			return
+		}
+		x, y, width := noteBox(note)
+		lang := canvasLanguage(client, nil)
+		createTransientNote(client, x, y-transientNoteHeight-20, width,
+			i18n.T(lang, i18n.InterviewErrorTitle), i18n.T(lang, i18n.InterviewErrorText, who.persona.Name, err), InterviewErrorColor)
+		return
+	}
+	store.Update(qnoteID, func(r *checkpoint.Record) {
+		pp := r.Persona(who.persona.Name)
+		pp.Answer = answer
+		pp.AnswerNoteID = answerID
+		pp.PromptVersion = promptVersion(ctx)
+		r.Complete(checkpoint.StepAnswers)
+		r.Complete(checkpoint.StepAnswerNotes)
+	})
+	answeredNotes.Store(qnoteID, true)
+	store.Finish(qnoteID, checkpoint.StatusDone, "")
+}
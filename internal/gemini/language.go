@@ -0,0 +1,61 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/molecule"
+)
+
+// detectedLanguages caches the language detected from each canvas's business notes: canvas ID -> code
+var detectedLanguages sync.Map
+
+// rememberLanguage detects the language of a canvas's business notes and caches
+// it for the canvas's helper notes
+func rememberLanguage(client *canvusapi.Client, businessText string) {
+	lang, ok := i18n.Detect(businessText)
+	if !ok {
+		return
+	}
+	if prev, loaded := detectedLanguages.Swap(client.CanvasID, lang); !loaded || prev.(string) != lang {
+		log.Printf("[i18n] Canvas %s business notes detected as %s", client.Name, i18n.Name(lang))
+	}
+}
+
+// canvasLanguage returns the language of the notes written on the client's canvas:
+// its configured language, else the one detected from its business notes (found
+// in widgets if given, or remembered from an earlier workflow), else English
+func canvasLanguage(client *canvusapi.Client, widgets []map[string]interface{}) string {
+	if lang := currentSettings().language(client.Name); lang != i18n.Auto {
+		return lang
+	}
+	if widgets != nil {
+		rememberLanguage(client, molecule.BusinessNotesText(widgets))
+	}
+	if lang, ok := detectedLanguages.Load(client.CanvasID); ok {
+		return lang.(string)
+	}
+	return i18n.English
+}
+
+// answerLanguage returns the name of the language personas should use on the
+// workflow's canvas, or "" to leave it to the model (English canvases and
+// business notes whose language cannot be detected)
+func answerLanguage(ctx context.Context, businessContext string) string {
+	s := settingsFrom(ctx)
+	lang := s.language(s.canvas)
+	if lang == i18n.Auto {
+		detected, ok := i18n.Detect(businessContext)
+		if !ok {
+			return ""
+		}
+		lang = detected
+	}
+	if lang == i18n.English {
+		return ""
+	}
+	return i18n.Name(lang)
+}
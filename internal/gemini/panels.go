@@ -0,0 +1,64 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// panelSpan is how many grid cells apart the answers of successive panels are laid out
+const panelSpan = 3
+
+// questionPanels returns the panels a question is put to, given the panels named
+// by its label (nil without one, meaning the customers). Panels other than the
+// customers only take part if their anchor is on the canvas; the names of the
+// missing anchors are returned too.
+func questionPanels(targets []types.Panel, widgets []map[string]interface{}) (panels []types.Panel, missing []string) {
+	if targets == nil {
+		return []types.Panel{types.PanelCustomer}, nil
+	}
+	anchors := make(map[string]bool)
+	for _, w := range widgets {
+		if typeStr, _ := w["widget_type"].(string); typeStr == "Anchor" {
+			name, _ := w["anchor_name"].(string)
+			anchors[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	for _, p := range targets {
+		name := atom.PanelAnchorName(p)
+		if p != types.PanelCustomer && !anchors[strings.ToLower(name)] {
+			missing = append(missing, name)
+			continue
+		}
+		panels = append(panels, p)
+	}
+	return panels, missing
+}
+
+// ensurePanelPersonas returns the personas of panel for a Qnote, creating them first if needed
+func ensurePanelPersonas(ctx context.Context, qnoteID string, client *canvusapi.Client, widgets []map[string]interface{}, panel types.Panel) ([]Persona, error) {
+	key := PanelKey(qnoteID, panel)
+	if _, ok := PersonaNoteIDs.Load(key); !ok {
+		if err := CreatePanelPersonas(ctx, qnoteID, client, widgets, panel); err != nil {
+			return nil, fmt.Errorf("CreatePersonas failed: %w", err)
+		}
+	}
+	personas, err := FetchPersonasFromNotes(key, client)
+	if err != nil || len(personas) < MinRequiredPersonas {
+		// Try to recreate personas if not enough are available
+		log.Printf("[AnswerQuestion] Only %d %s personas available, recreating: %v", len(personas), panel, err)
+		if err := CreatePanelPersonas(ctx, qnoteID, client, widgets, panel); err != nil {
+			return nil, fmt.Errorf("CreatePersonas failed: %w", err)
+		}
+		personas, err = FetchPersonasFromNotes(key, client)
+		if err != nil || len(personas) < MinRequiredPersonas {
+			return nil, fmt.Errorf("could not fetch minimum required personas (%d) after CreatePersonas: %v", MinRequiredPersonas, err)
+		}
+	}
+	return personas, nil
+}
@@ -0,0 +1,131 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/personastore"
+)
+
+// personaUpdatedNoteLifetime is how long the confirmation of a persona edit stays on the canvas
+const personaUpdatedNoteLifetime = 15 * time.Second
+
+// PersonaUpdatedColor is the green background color for persona edit confirmations
+const PersonaUpdatedColor = "#8bc34aff"
+
+// liveSessions holds the session managers of running workflows, mapped to their canvas ID
+var liveSessions sync.Map // *SessionManager -> string
+
+// trackSessions registers sm as live on canvas until the returned function is called
+func trackSessions(canvas string, sm *SessionManager) func() {
+	liveSessions.Store(sm, canvas)
+	return func() { liveSessions.Delete(sm) }
+}
+
+// reseedLiveSessions re-seeds the live sessions on canvas whose persona is named
+// name with persona, returning how many were updated
+func reseedLiveSessions(ctx context.Context, canvas, name string, persona Persona, keepHistory bool) int {
+	reseeded := 0
+	liveSessions.Range(func(key, value interface{}) bool {
+		if value.(string) != canvas {
+			return true
+		}
+		ok, err := key.(*SessionManager).Reseed(ctx, name, persona, keepHistory)
+		if err != nil {
+			log.Printf("[HandlePersonaNoteEdit] Failed to re-seed session %s: %v", name, err)
+		}
+		if ok && err == nil {
+			reseeded++
+		}
+		return true
+	})
+	return reseeded
+}
+
+// HandlePersonaNoteEdit applies an edit to a persona note: the persona is read
+// back from the note, stored, and pushed into the chat sessions of questions in
+// progress, and a short-lived note confirms which fields changed. A helper note
+// below the persona note lists the fields that cannot be read until it is fixed.
+func HandlePersonaNoteEdit(ctx context.Context, client *canvusapi.Client, widget canvus.WidgetEvent) {
+	ctx = withSettings(ctx, client.Name)
+	// Events may carry only the changed properties, so read the whole note
+	note, err := client.GetNote(widget.ID, false)
+	if err != nil {
+		log.Printf("[HandlePersonaNoteEdit] Failed to fetch persona note %s: %v", widget.ID, err)
+		return
+	}
+	old, known := personastore.GetGlobalStore().Get(widget.ID)
+	persona, missing, ok := readPersonaNote(client, note)
+	updatePersonaFieldsHelper(client, note, missing)
+	if !ok || !known {
+		return // nothing to compare against; the note is stored for next time
+	}
+	changed := atom.ChangedPersonaFields(old, persona)
+	if len(changed) == 0 {
+		return
+	}
+
+	keepHistory := configFrom(ctx).Workflow.PersonaEditKeepHistory
+	reseeded := reseedLiveSessions(ctx, client.CanvasID, old.Name, persona, keepHistory)
+	log.Printf("[HandlePersonaNoteEdit] Persona %s edited (%s); re-seeded %d live session(s)", persona.Name, strings.Join(changed, ", "), reseeded)
+	createPersonaUpdatedNote(client, note, persona.Name, changed)
+}
+
+// createPersonaUpdatedNote posts a confirmation just above the persona note
+func createPersonaUpdatedNote(client *canvusapi.Client, personaNote map[string]interface{}, name string, changed []string) {
+	x, y, width := noteBox(personaNote)
+	lang := canvasLanguage(client, nil)
+	createTransientNote(client, x, y-transientNoteHeight-20, width,
+		i18n.T(lang, i18n.PersonaUpdatedTitle), i18n.T(lang, i18n.PersonaUpdatedText, name, strings.Join(changed, ", ")), PersonaUpdatedColor)
+}
+
+// noteBox returns the location and width of a note, defaulting to a 400 wide note at the origin
+func noteBox(note map[string]interface{}) (x, y, width float64) {
+	width = 400
+	if loc, ok := atom.SafeMap(note, "location"); ok {
+		x, _ = atom.SafeFloat64(loc, "x")
+		y, _ = atom.SafeFloat64(loc, "y")
+	}
+	if size, ok := atom.SafeMap(note, "size"); ok {
+		if w, ok := atom.SafeFloat64(size, "width"); ok {
+			width = w
+		}
+	}
+	return x, y, width
+}
+
+// transientNoteHeight is the height of confirmation notes
+const transientNoteHeight = 120.0
+
+// createTransientNote posts a confirmation note at x, y and deletes it after
+// personaUpdatedNoteLifetime
+func createTransientNote(client *canvusapi.Client, x, y, width float64, title, text, color string) {
+	noteMeta := map[string]interface{}{
+		"title":            title,
+		"text":             text,
+		"location":         map[string]interface{}{"x": x, "y": y},
+		"size":             map[string]interface{}{"width": width, "height": transientNoteHeight},
+		"background_color": color,
+	}
+	confirmation, err := client.CreateNote(noteMeta)
+	if err != nil {
+		log.Printf("[createTransientNote] Failed to create note %q: %v", title, err)
+		return
+	}
+	id, _ := confirmation["id"].(string)
+	if id == "" {
+		return
+	}
+	time.AfterFunc(personaUpdatedNoteLifetime, func() {
+		if err := client.DeleteNote(id); err != nil {
+			log.Printf("[createTransientNote] Failed to delete note %s: %v", id, err)
+		}
+	})
+}
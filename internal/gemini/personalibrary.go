@@ -0,0 +1,253 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/library"
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// LibraryErrorColor is the amber background color for persona library requests that failed
+const LibraryErrorColor = "#ffc107ff"
+
+// libraryNotesInFlight holds the IDs of Save_Persona and Import_Personas notes being handled
+var libraryNotesInFlight sync.Map
+
+// personaNoteName returns the persona name in a persona note title, "Persona N: <name>"
+func personaNoteName(note map[string]interface{}) string {
+	title, _ := note["title"].(string)
+	if i := strings.Index(title, ": "); i >= 0 {
+		return strings.TrimSpace(title[i+2:])
+	}
+	return ""
+}
+
+// selectPersonaSlots resolves refs ("all", slot numbers or persona names) to the
+// slots of the persona notes on the canvas, in slot order
+func selectPersonaSlots(notes map[int]map[string]interface{}, refs []string) ([]int, error) {
+	selected := make(map[int]bool)
+	for _, ref := range refs {
+		if strings.EqualFold(ref, "all") {
+			for i, note := range notes {
+				if !strings.EqualFold(personaNoteName(note), "FAILED") {
+					selected[i] = true
+				}
+			}
+			continue
+		}
+		if i, ok := atom.PersonaSlot(ref); ok {
+			if _, exists := notes[i]; !exists {
+				return nil, fmt.Errorf("there is no persona %d on the canvas", i+1)
+			}
+			selected[i] = true
+			continue
+		}
+		found := false
+		for i, note := range notes {
+			if strings.EqualFold(personaNoteName(note), ref) {
+				selected[i], found = true, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("there is no persona named %q on the canvas", ref)
+		}
+	}
+	slots := make([]int, 0, len(selected))
+	for i := range selected {
+		slots = append(slots, i)
+	}
+	sort.Ints(slots)
+	return slots, nil
+}
+
+// SavePersonasToLibrary saves the persona notes named by refs ("all", slot
+// numbers or persona names) to the persona library with tags
+func SavePersonasToLibrary(client *canvusapi.Client, refs, tags []string) ([]library.Entry, error) {
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no persona named; give a persona number, a name or \"all\"")
+	}
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch widgets: %w", err)
+	}
+	notes := existingPersonaNotes(widgets)
+	slots, err := selectPersonaSlots(notes, refs)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, fmt.Errorf("there are no personas on the canvas")
+	}
+	lib := library.GetGlobalLibrary()
+	saved := make([]library.Entry, 0, len(slots))
+	for _, i := range slots {
+		p, ok := personaFromNote(client, notes[i])
+		if !ok {
+			return saved, fmt.Errorf("persona %d has no name", i+1)
+		}
+		entry, err := lib.Save(p, tags, client.Name)
+		if err != nil {
+			return saved, fmt.Errorf("persona %d: %w", i+1, err)
+		}
+		saved = append(saved, entry)
+	}
+	return saved, nil
+}
+
+// ImportPersonasFromLibrary places entries in the free persona slots of the
+// canvas, with headshots, as if they had been generated. Personas already on the
+// canvas and those that do not fit are skipped. Returns the personas placed.
+func ImportPersonasFromLibrary(ctx context.Context, qnoteID string, client *canvusapi.Client, entries []library.Entry) ([]Persona, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no matching personas in the library")
+	}
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch widgets: %w", err)
+	}
+	existing := existingPersonaNotes(widgets)
+	if len(existing) == 4 {
+		return nil, fmt.Errorf("all four persona slots are taken; delete a persona note to make room")
+	}
+	onCanvas := make(map[string]bool, len(existing))
+	for _, note := range existing {
+		onCanvas[strings.ToLower(personaNoteName(note))] = true
+	}
+
+	var placed []Persona
+	source := func(ctx context.Context, client *canvusapi.Client, qnoteID, businessContext string, free []int) ([]Persona, error) {
+		personas := make([]Persona, 4)
+		for _, entry := range entries {
+			if onCanvas[strings.ToLower(entry.Persona.Name)] {
+				log.Printf("[ImportPersonas] %s is already on the canvas", entry.Persona.Name)
+				continue
+			}
+			if len(placed) == len(free) {
+				log.Printf("[ImportPersonas] No free slot for %s", entry.Persona.Name)
+				continue
+			}
+			personas[free[len(placed)]] = entry.Persona
+			placed = append(placed, entry.Persona)
+			onCanvas[strings.ToLower(entry.Persona.Name)] = true
+		}
+		if len(placed) == 0 {
+			return nil, fmt.Errorf("the selected personas are already on the canvas")
+		}
+		return personas, nil
+	}
+	if err := createPersonasFrom(ctx, qnoteID, client, widgets, types.PanelCustomer, source); err != nil {
+		return nil, err
+	}
+	return placed, nil
+}
+
+// libraryEntriesFor returns the library entries an Import_Personas note asks for:
+// by ID, else by tag and persona name. ok is false if the note names nothing yet.
+func libraryEntriesFor(req atom.LibraryNote) ([]library.Entry, bool, error) {
+	all := false
+	names := make(map[string]bool)
+	for _, ref := range req.Personas {
+		if strings.EqualFold(ref, "all") {
+			all = true
+		} else {
+			names[strings.ToLower(ref)] = true
+		}
+	}
+	if len(req.IDs) == 0 && len(req.Tags) == 0 && len(names) == 0 && !all {
+		return nil, false, nil
+	}
+	entries, err := library.GetGlobalLibrary().Select(req.Tags, req.IDs)
+	if err != nil || len(names) == 0 || all {
+		return entries, true, err
+	}
+	var named []library.Entry
+	for _, entry := range entries {
+		if names[strings.ToLower(entry.Persona.Name)] {
+			named = append(named, entry)
+		}
+	}
+	return named, true, nil
+}
+
+// personaNames returns the names of personas, comma separated
+func personaNames(personas []Persona) string {
+	names := make([]string, len(personas))
+	for i, p := range personas {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// HandleLibraryNote carries out a Save_Persona or Import_Personas note once it
+// names what to save or import. The note is deleted when the request succeeds;
+// otherwise it stays, with a helper note giving the reason, so it can be corrected.
+func HandleLibraryNote(ctx context.Context, client *canvusapi.Client, widget canvus.WidgetEvent, save bool) {
+	if _, busy := libraryNotesInFlight.LoadOrStore(widget.ID, true); busy {
+		return
+	}
+	defer libraryNotesInFlight.Delete(widget.ID)
+	ctx = withSettings(ctx, client.Name)
+
+	note, err := client.GetNote(widget.ID, false)
+	if err != nil {
+		log.Printf("[HandleLibraryNote] Failed to fetch note %s: %v", widget.ID, err)
+		return
+	}
+	text, _ := note["text"].(string)
+	req := atom.ParseLibraryNote(text)
+	lang := canvasLanguage(client, nil)
+
+	var title, message string
+	if save {
+		if len(req.Personas) == 0 {
+			return // wait until the note names the personas to save
+		}
+		var saved []library.Entry
+		saved, err = SavePersonasToLibrary(client, req.Personas, req.Tags)
+		if err == nil {
+			personas := make([]Persona, len(saved))
+			for i, entry := range saved {
+				personas[i] = entry.Persona
+			}
+			tags := strings.Join(library.NormalizeTags(req.Tags), ", ")
+			if tags == "" {
+				tags = "-"
+			}
+			title, message = i18n.T(lang, i18n.LibrarySavedTitle), i18n.T(lang, i18n.LibrarySavedText, personaNames(personas), tags)
+		}
+	} else {
+		entries, ok, selectErr := libraryEntriesFor(req)
+		if !ok {
+			return // wait until the note names the personas to import
+		}
+		var placed []Persona
+		if err = selectErr; err == nil {
+			placed, err = ImportPersonasFromLibrary(ctx, widget.ID, client, entries)
+		}
+		if err == nil {
+			title, message = i18n.T(lang, i18n.LibraryImportTitle), i18n.T(lang, i18n.LibraryImportText, len(placed), personaNames(placed))
+		}
+	}
+
+	x, y, width := noteBox(note)
+	if err != nil {
+		log.Printf("[HandleLibraryNote] Request on note %s failed: %v", widget.ID, err)
+		createTransientNote(client, x, y-transientNoteHeight-20, width,
+			i18n.T(lang, i18n.LibraryErrorTitle), i18n.T(lang, i18n.LibraryErrorText, err), LibraryErrorColor)
+		return
+	}
+	log.Printf("[HandleLibraryNote] %s", message)
+	if err := client.DeleteNote(widget.ID); err != nil {
+		log.Printf("[HandleLibraryNote] Failed to delete note %s: %v", widget.ID, err)
+	}
+	createTransientNote(client, x, y, width, title, message, PersonaUpdatedColor)
+}
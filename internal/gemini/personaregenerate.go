@@ -0,0 +1,206 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/canvus"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/personastore"
+	"github.com/jaypaulb/AI-personas/internal/types"
+	"github.com/jaypaulb/AI-personas/internal/usage"
+)
+
+// RegenerateErrorColor is the amber background color for regenerate requests that failed
+const RegenerateErrorColor = LibraryErrorColor
+
+// regeneratingSlots holds the persona slots being regenerated, keyed "<canvas ID>/<panel>/<slot>"
+var regeneratingSlots sync.Map
+
+// findHeadshots returns the headshot images of persona slot (0-3) of panel among widgets
+func findHeadshots(widgets []map[string]interface{}, panel types.Panel, slot int) []map[string]interface{} {
+	prefix := atom.PersonaNoteTitle(panel, slot, "")
+	var images []map[string]interface{}
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		title, _ := w["title"].(string)
+		if typeStr == "Image" && strings.HasPrefix(title, prefix) && strings.HasSuffix(title, " Headshot") {
+			images = append(images, w)
+		}
+	}
+	return images
+}
+
+// widgetBox returns the location and size of a widget
+func widgetBox(w map[string]interface{}) (x, y, width, height float64, ok bool) {
+	loc, locOK := atom.SafeMap(w, "location")
+	size, sizeOK := atom.SafeMap(w, "size")
+	if !locOK || !sizeOK {
+		return 0, 0, 0, 0, false
+	}
+	x, xOK := atom.SafeFloat64(loc, "x")
+	y, yOK := atom.SafeFloat64(loc, "y")
+	width, wOK := atom.SafeFloat64(size, "width")
+	height, hOK := atom.SafeFloat64(size, "height")
+	return x, y, width, height, xOK && yOK && wOK && hOK
+}
+
+// headshotBox returns where the headshot of persona slot (0-3) goes: where the
+// old headshot was, else where createPersonasFrom puts it in the panel's anchor
+func headshotBox(old []map[string]interface{}, anchor map[string]interface{}, slot int) (x, y, width, height float64, ok bool) {
+	for _, w := range old {
+		if x, y, width, height, ok := widgetBox(w); ok {
+			return x, y, width, height, true
+		}
+	}
+	ax, ay, aw, ah, ok := widgetBox(anchor)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	return ax + aw*0.02 + float64(slot)*(aw*0.23+aw*0.01), ay + ah*0.02, aw * 0.23, ah * 0.10, true
+}
+
+// RegeneratePersonaSlot replaces the persona in slot (0-3) of a panel with a new
+// one from Gemini that differs from the panel's other personas. The persona note is
+// rewritten in place, the headshot is replaced, and live chat sessions with the
+// old persona start afresh as the new one. A failed slot can be regenerated too.
+// Returns the old persona (empty for a failed slot) and the new one; on failure
+// the note keeps its old title.
+func RegeneratePersonaSlot(ctx context.Context, client *canvusapi.Client, panel types.Panel, slot int) (old, persona Persona, err error) {
+	label := strings.ToLower(atom.PersonaSlotLabel(panel, slot))
+	key := fmt.Sprintf("%s/%s/%d", client.CanvasID, panel, slot)
+	if _, busy := regeneratingSlots.LoadOrStore(key, true); busy {
+		return old, persona, fmt.Errorf("%s is already being regenerated", label)
+	}
+	defer regeneratingSlots.Delete(key)
+	ctx = usage.WithScope(withSettings(ctx, client.Name), usage.Scope{Canvas: client.CanvasID})
+
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		return old, persona, fmt.Errorf("failed to fetch widgets: %w", err)
+	}
+	notes := existingPanelNotes(widgets, panel)
+	note, ok := notes[slot]
+	if !ok {
+		return old, persona, fmt.Errorf("there is no %s on the canvas", label)
+	}
+	noteID, _ := note["id"].(string)
+	store := personastore.GetGlobalStore()
+	if stored, ok := store.Get(noteID); ok {
+		old = stored
+	} else {
+		text, _ := note["text"].(string)
+		old = ParsePersonaNote(text)
+	}
+
+	// Put the old title back if the persona cannot be replaced
+	oldTitle := atom.PersonaNoteTitle(panel, slot, "FAILED")
+	if old.Name != "" {
+		oldTitle = atom.PersonaNoteTitle(panel, slot, old.Name)
+	}
+	defer func() {
+		if title, _ := note["title"].(string); err != nil && title != oldTitle {
+			if _, updateErr := client.UpdateNote(noteID, map[string]interface{}{"title": oldTitle}); updateErr != nil {
+				log.Printf("[RegeneratePersona] Failed to restore the title of note %s: %v", noteID, updateErr)
+			}
+		}
+	}()
+
+	var others []Persona
+	for i, n := range notes {
+		if i == slot || strings.EqualFold(personaNoteName(n), "FAILED") {
+			continue
+		}
+		if p, ok := personaFromNote(client, n); ok {
+			others = append(others, p)
+		}
+	}
+	businessContext, anchor, _, err := getPanelContextWithCacheAndMissing(ctx, "", client, widgets, panel)
+	if err != nil {
+		return old, persona, fmt.Errorf("failed to get business context: %w", err)
+	}
+
+	genCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	geminiClient, err := NewClient(genCtx)
+	if err != nil {
+		return old, persona, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	persona, err = geminiClient.RegeneratePersona(genCtx, businessContext, panel, others, old)
+	if err != nil {
+		return old, persona, fmt.Errorf("Gemini persona generation failed: %w", err)
+	}
+
+	// Store the new persona first, so the edit event of the rewrite finds nothing changed
+	store.Save(client.CanvasID, noteID, persona)
+	title := atom.PersonaNoteTitle(panel, slot, persona.Name)
+	if _, err = client.UpdateNote(noteID, map[string]interface{}{
+		"title":            title,
+		"text":             FormatPersonaNote(persona),
+		"background_color": personaColors[slot%len(personaColors)],
+	}); err != nil {
+		if old.Name != "" {
+			store.Save(client.CanvasID, noteID, old)
+		}
+		return old, persona, fmt.Errorf("failed to update persona note: %w", err)
+	}
+	updatePersonaFieldsHelper(client, note, nil)
+	log.Printf("[RegeneratePersona] %s regenerated: %s replaces %q", label, persona.Name, old.Name)
+
+	if old.Name != "" {
+		reseeded := reseedLiveSessions(ctx, client.CanvasID, old.Name, persona, false)
+		log.Printf("[RegeneratePersona] Restarted %d live session(s) of %s as %s", reseeded, old.Name, persona.Name)
+	}
+
+	headshots := findHeadshots(widgets, panel, slot)
+	x, y, width, height, ok := headshotBox(headshots, anchor, slot)
+	for _, img := range headshots {
+		id, _ := img["id"].(string)
+		if err := client.DeleteImage(id); err != nil {
+			log.Printf("[RegeneratePersona] Failed to delete old headshot %s: %v", id, err)
+		}
+	}
+	if !ok {
+		log.Printf("[RegeneratePersona] No place for the headshot of %s", label)
+		return old, persona, nil
+	}
+	// The headshot takes a while; it must outlive a request that started the regeneration
+	go uploadPersonaHeadshot(context.WithoutCancel(ctx), client, persona, x, y, width, height, slot, title)
+	return old, persona, nil
+}
+
+// HandleRegeneratePersona regenerates the persona of a note retitled
+// "Persona N: REGENERATE" (or "Internal Persona N: REGENERATE" and so on) and posts a note confirming it or giving the reason it failed
+func HandleRegeneratePersona(ctx context.Context, client *canvusapi.Client, widget canvus.WidgetEvent) {
+	ctx = withSettings(ctx, client.Name)
+	note, err := client.GetNote(widget.ID, false)
+	if err != nil {
+		log.Printf("[HandleRegeneratePersona] Failed to fetch persona note %s: %v", widget.ID, err)
+		return
+	}
+	title, _ := note["title"].(string)
+	panel, slot, _, ok := atom.ParsePersonaNoteTitle(title)
+	if !ok {
+		log.Printf("[HandleRegeneratePersona] Note %s is not a persona note: %q", widget.ID, title)
+		return
+	}
+
+	_, persona, err := RegeneratePersonaSlot(ctx, client, panel, slot)
+	label := atom.PersonaSlotLabel(panel, slot)
+	x, y, width := noteBox(note)
+	lang := canvasLanguage(client, nil)
+	if err != nil {
+		log.Printf("[HandleRegeneratePersona] %s not regenerated: %v", label, err)
+		createTransientNote(client, x, y-transientNoteHeight-20, width,
+			i18n.T(lang, i18n.RegenerateErrorTitle), i18n.T(lang, i18n.RegenerateErrorText, label, err), RegenerateErrorColor)
+		return
+	}
+	createTransientNote(client, x, y-transientNoteHeight-20, width,
+		i18n.T(lang, i18n.RegeneratedTitle), i18n.T(lang, i18n.RegeneratedText, label, persona.Name), PersonaUpdatedColor)
+}
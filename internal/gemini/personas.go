@@ -2,6 +2,7 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -11,13 +12,20 @@ import (
 
 	"github.com/jaypaulb/AI-personas/canvusapi"
 	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
 	"github.com/jaypaulb/AI-personas/internal/molecule"
+	"github.com/jaypaulb/AI-personas/internal/personastore"
 	"github.com/jaypaulb/AI-personas/internal/timing"
+	"github.com/jaypaulb/AI-personas/internal/types"
+	"github.com/jaypaulb/AI-personas/internal/usage"
 )
 
 // FailedPersonaColor is the red background color for failed persona indicators
 const FailedPersonaColor = "#f44336ff"
 
+// personaColors are the background colors of the persona notes, by slot
+var personaColors = []string{"#2196f3ff", "#4caf50ff", "#ff9800ff", "#9c27b0ff"}
+
 // MinRequiredPersonas is the minimum number of personas required for partial success
 const MinRequiredPersonas = 1
 
@@ -70,6 +78,73 @@ func ParsePersonaNote(text string) Persona {
 	return atom.ParsePersonaNote(text)
 }
 
+// personaFieldsHelper is the incomplete-fields helper note posted for a persona note
+type personaFieldsHelper struct {
+	id      string
+	missing string // the missing field labels, comma separated
+}
+
+// personaFieldsHelpers maps persona note IDs to their personaFieldsHelper
+var personaFieldsHelpers sync.Map
+
+// personaFromNote reads the persona on a persona note. Fields that cannot be read
+// are taken from the persona stored when the note was created or last read. The
+// result is stored for next time. ok is false if the persona has no name.
+func personaFromNote(client *canvusapi.Client, note map[string]interface{}) (Persona, bool) {
+	p, _, ok := readPersonaNote(client, note)
+	return p, ok
+}
+
+// readPersonaNote is personaFromNote, also returning the core fields that could
+// not be read from the note
+func readPersonaNote(client *canvusapi.Client, note map[string]interface{}) (p Persona, missing []string, ok bool) {
+	id, _ := note["id"].(string)
+	text, _ := note["text"].(string)
+	p = ParsePersonaNote(text)
+	missing = atom.MissingPersonaFields(p)
+	store := personastore.GetGlobalStore()
+	if stored, ok := store.Get(id); ok {
+		p = atom.MergePersona(p, stored)
+	}
+	title, _ := note["title"].(string)
+	if panel, _, _, ok := atom.ParsePersonaNoteTitle(title); ok {
+		p = withPanel(p, panel)
+	}
+	if len(missing) > 0 {
+		log.Printf("[personaFromNote] Persona note %s is missing %v", id, missing)
+	}
+	if p.Name == "" {
+		return p, missing, false
+	}
+	store.Save(client.CanvasID, id, p)
+	return p, missing, true
+}
+
+// updatePersonaFieldsHelper posts, replaces or removes the helper note for a
+// persona note so it lists exactly the fields currently missing
+func updatePersonaFieldsHelper(client *canvusapi.Client, note map[string]interface{}, missing []string) {
+	id, _ := note["id"].(string)
+	key := strings.Join(missing, ", ")
+	var prev personaFieldsHelper
+	if v, ok := personaFieldsHelpers.Load(id); ok {
+		prev = v.(personaFieldsHelper)
+	}
+	if prev.missing == key {
+		return
+	}
+	if prev.id != "" {
+		if err := client.DeleteNote(prev.id); err != nil {
+			log.Printf("[personaFromNote] Failed to delete helper note %s: %v", prev.id, err)
+		}
+	}
+	if key == "" {
+		personaFieldsHelpers.Delete(id)
+		return
+	}
+	helperID := molecule.CreatePersonaFieldsHelper(client, note, missing, canvasLanguage(client, nil))
+	personaFieldsHelpers.Store(id, personaFieldsHelper{id: helperID, missing: key})
+}
+
 // FetchPersonasFromNotes fetches persona notes by IDs and parses them
 // Updated to support partial success - returns available personas even if some are missing
 func FetchPersonasFromNotes(qnoteID string, client *canvusapi.Client) ([]Persona, error) {
@@ -92,8 +167,12 @@ func FetchPersonasFromNotes(qnoteID string, client *canvusapi.Client) ([]Persona
 			fetchErrors = append(fetchErrors, fmt.Sprintf("note %s: %v", id, err))
 			continue
 		}
-		text, _ := note["text"].(string)
-		personas = append(personas, ParsePersonaNote(text))
+		p, ok := personaFromNote(client, note)
+		if !ok {
+			fetchErrors = append(fetchErrors, fmt.Sprintf("note %s: no persona name", id))
+			continue
+		}
+		personas = append(personas, p)
 	}
 	if len(personas) == 0 {
 		return nil, fmt.Errorf("failed to fetch any persona notes for Qnote %s: %v", qnoteID, fetchErrors)
@@ -111,11 +190,11 @@ func CreatePersonas(ctx context.Context, qnoteID string, client *canvusapi.Clien
 	return CreatePersonasWithCache(ctx, qnoteID, client, nil)
 }
 
-// createFailedPersonaNote creates a red indicator note for a persona that failed to generate
-func createFailedPersonaNote(client *canvusapi.Client, personaIndex int, reason string, x, y, width, height float64) string {
+// createFailedPersonaNote creates a red indicator note for a persona of panel that failed to generate
+func createFailedPersonaNote(client *canvusapi.Client, panel types.Panel, personaIndex int, reason string, x, y, width, height float64) string {
 	noteMeta := map[string]interface{}{
-		"title":            fmt.Sprintf("Persona %d: FAILED", personaIndex+1),
-		"text":             fmt.Sprintf("Failed to create persona %d.\n\nReason: %s\n\nThis persona will be skipped in Q&A sessions.", personaIndex+1, reason),
+		"title":            atom.PersonaNoteTitle(panel, personaIndex, "FAILED"),
+		"text":             i18n.T(canvasLanguage(client, nil), i18n.FailedPersonaText, personaIndex+1, reason),
 		"location":         map[string]interface{}{"x": x, "y": y},
 		"size":             map[string]interface{}{"width": width, "height": height},
 		"background_color": FailedPersonaColor,
@@ -135,13 +214,84 @@ func createFailedPersonaNote(client *canvusapi.Client, personaIndex int, reason
 // Supports partial success - continues with minimum 1 persona if some fail.
 // Returns error if any required step fails.
 func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvusapi.Client, cachedWidgets []map[string]interface{}) error {
+	return createPersonasFrom(ctx, qnoteID, client, cachedWidgets, types.PanelCustomer, panelPersonaSource(types.PanelCustomer))
+}
+
+// CreatePanelPersonas is CreatePersonasWithCache for the personas of panel, which
+// go in the panel's own anchor and are stored under PanelKey(qnoteID, panel)
+func CreatePanelPersonas(ctx context.Context, qnoteID string, client *canvusapi.Client, cachedWidgets []map[string]interface{}, panel types.Panel) error {
+	return createPersonasFrom(ctx, qnoteID, client, cachedWidgets, panel, panelPersonaSource(panel))
+}
+
+// PanelKey returns the PersonaNoteIDs key of the personas of panel for a Qnote:
+// the Qnote ID itself for customers, so existing callers keep working
+func PanelKey(qnoteID string, panel types.Panel) string {
+	if panel == types.PanelCustomer || panel == "" {
+		return qnoteID
+	}
+	return qnoteID + "#" + string(panel)
+}
+
+// personaSource supplies the personas for the free persona slots (0-3). The
+// result is indexed by slot; a persona without a name leaves its slot empty.
+type personaSource func(ctx context.Context, client *canvusapi.Client, qnoteID, businessContext string, free []int) ([]Persona, error)
+
+// panelPersonaSource returns the personaSource asking Gemini for a fresh set of personas for panel
+func panelPersonaSource(panel types.Panel) personaSource {
+	return func(ctx context.Context, client *canvusapi.Client, qnoteID, businessContext string, free []int) ([]Persona, error) {
+		log.Printf("[CreatePersonas] Generating %s personas using Gemini API...", panel)
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+		geminiClient, err := NewClient(ctx)
+		if err != nil {
+			log.Printf("[CreatePersonas] ERROR: Failed to create Gemini client: %v", err)
+			return nil, fmt.Errorf("Failed to create Gemini client: %w", err)
+		}
+
+		// Note: GeneratePanelPersonas is already instrumented in client.go
+		personas, err := geminiClient.GeneratePanelPersonas(ctx, businessContext, panel)
+		if err != nil {
+			if errors.Is(err, usage.ErrBudgetExceeded) {
+				createBudgetHelperNote(client, qnoteID, err)
+			}
+			log.Printf("[CreatePersonas] ERROR: Gemini persona generation failed: %v", err)
+			return nil, fmt.Errorf("Gemini persona generation failed: %w", err)
+		}
+		log.Printf("[CreatePersonas] Successfully generated %d personas from Gemini", len(personas))
+		return personas, nil
+	}
+}
+
+// existingPersonaNotes returns the customer persona notes among widgets, by slot (0-3)
+func existingPersonaNotes(widgets []map[string]interface{}) map[int]map[string]interface{} {
+	return existingPanelNotes(widgets, types.PanelCustomer)
+}
+
+// existingPanelNotes returns the persona notes of panel among widgets, by slot (0-3)
+func existingPanelNotes(widgets []map[string]interface{}, panel types.Panel) map[int]map[string]interface{} {
+	existing := make(map[int]map[string]interface{})
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		title, _ := w["title"].(string)
+		if p, slot, _, ok := atom.ParsePersonaNoteTitle(title); ok && typeStr == "Note" && p == panel {
+			existing[slot] = w
+		}
+	}
+	return existing
+}
+
+// createPersonasFrom creates persona notes and images in the free slots of the
+// anchor of panel, taking the personas from source
+func createPersonasFrom(ctx context.Context, qnoteID string, client *canvusapi.Client, cachedWidgets []map[string]interface{}, panel types.Panel, source personaSource) error {
 	// Start end-to-end workflow timing
 	workflowTimer := timing.Start("create_personas_workflow")
 	defer func() {
 		workflowTimer.StopAndLog(true)
 	}()
 
-	log.Printf("[CreatePersonas] Starting persona creation for Qnote %s", qnoteID)
+	log.Printf("[CreatePersonas] Starting %s persona creation for Qnote %s", panel, qnoteID)
+	key := PanelKey(qnoteID, panel)
+	ctx = usage.WithScope(withSettings(ctx, client.Name), usage.Scope{Canvas: client.CanvasID, Question: qnoteID})
 
 	// Step 1: Fetch all widgets (or use cache)
 	var widgets []map[string]interface{}
@@ -163,12 +313,12 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 
 	// Use the helper to get business context and anchor (pass cached widgets to avoid redundant fetch)
 	businessContextTimer := timing.Start("create_personas_get_business_context")
-	businessContext, personasAnchor, missingNotes, err := getBusinessContextWithCacheAndMissing(ctx, qnoteID, client, widgets)
+	businessContext, personasAnchor, missingNotes, err := getPanelContextWithCacheAndMissing(ctx, qnoteID, client, widgets, panel)
 	if err != nil {
 		businessContextTimer.StopAndLog(false)
 		// If there are missing notes, create a helper note on the canvas
 		if len(missingNotes) > 0 {
-			molecule.CreateMissingNotesHelper(client, missingNotes, personasAnchor)
+			molecule.CreateMissingNotesHelper(client, missingNotes, personasAnchor, canvasLanguage(client, widgets))
 		}
 		log.Printf("[CreatePersonas] ERROR: Failed to get business context or anchor: %v", err)
 		return fmt.Errorf("[CreatePersonas] Failed to get business context or anchor: %w", err)
@@ -177,23 +327,10 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 	log.Printf("[CreatePersonas] Business context extracted (%d chars), personas anchor found", len(businessContext))
 
 	// --- Persona existence check ---
-	existingPersonas := make(map[int]map[string]interface{}) // index -> widget
+	existingPersonas := existingPanelNotes(widgets, panel) // index -> widget
 	personaTitles := make([]string, 4)
-	for i := 0; i < 4; i++ {
-		personaTitles[i] = ""
-	}
-
-	// First, try to match existing notes to personas by index
-	for _, w := range widgets {
-		typeStr, _ := w["widget_type"].(string)
-		title, _ := w["title"].(string)
-		for i := 0; i < 4; i++ {
-			prefix := fmt.Sprintf("Persona %d: ", i+1)
-			if typeStr == "Note" && strings.HasPrefix(strings.TrimSpace(title), prefix) {
-				existingPersonas[i] = w
-				personaTitles[i] = title // Save the actual title for later use
-			}
-		}
+	for i, w := range existingPersonas {
+		personaTitles[i], _ = w["title"].(string) // Save the actual title for later use
 	}
 
 	if len(existingPersonas) == 4 {
@@ -211,31 +348,24 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			p := ParsePersonaNote(text)
 			log.Printf("[CreatePersonas] Existing Persona %d: %s (ID: %s)", i+1, p.Name, id)
 		}
-		PersonaNoteIDs.Store(qnoteID, personaIDs)
+		PersonaNoteIDs.Store(key, personaIDs)
 		log.Printf("[CreatePersonas] Stored existing persona IDs for Qnote %s", qnoteID)
 		return nil
 	}
 
-	// --- Gemini persona generation for missing personas ---
-	log.Printf("[CreatePersonas] Generating personas using Gemini API...")
-	ctx2, cancel2 := context.WithTimeout(ctx, 60*time.Second)
-	defer cancel2()
-	geminiClient, err := NewClient(ctx2)
-	if err != nil {
-		log.Printf("[CreatePersonas] ERROR: Failed to create Gemini client: %v", err)
-		return fmt.Errorf("[CreatePersonas] Failed to create Gemini client: %w", err)
+	free := make([]int, 0, 4)
+	for i := 0; i < 4; i++ {
+		if _, exists := existingPersonas[i]; !exists {
+			free = append(free, i)
+		}
 	}
-
-	// Note: GeneratePersonas is already instrumented in client.go
-	personas, err := geminiClient.GeneratePersonas(ctx2, businessContext)
+	personas, err := source(ctx, client, qnoteID, businessContext, free)
 	if err != nil {
-		log.Printf("[CreatePersonas] ERROR: Gemini persona generation failed: %v", err)
-		return fmt.Errorf("[CreatePersonas] Gemini persona generation failed: %w", err)
+		return fmt.Errorf("[CreatePersonas] %w", err)
 	}
-	log.Printf("[CreatePersonas] Successfully generated %d personas from Gemini", len(personas))
 
 	// Color palette
-	colors := []string{"#2196f3ff", "#4caf50ff", "#ff9800ff", "#9c27b0ff"}
+	colors := personaColors
 
 	// Layout calculation with safe type assertions
 	anchor := personasAnchor
@@ -280,6 +410,11 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			continue // Skip existing
 		}
 
+		// A source may leave a slot empty, e.g. an import of fewer personas than free slots
+		if i < len(personas) && personas[i].Name == "" {
+			continue
+		}
+
 		// Handle case where we have fewer personas generated than needed
 		if i >= len(personas) {
 			log.Printf("[CreatePersonas] WARN: No persona data for index %d (only %d personas generated)", i+1, len(personas))
@@ -288,7 +423,7 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			noteY := ay + (ah * 0.34)
 			imgW := aw * colW
 			noteH := 0.40 * ah
-			failedID := createFailedPersonaNote(client, i, "Gemini did not generate enough personas", x, noteY, imgW, noteH)
+			failedID := createFailedPersonaNote(client, panel, i, "Gemini did not generate enough personas", x, noteY, imgW, noteH)
 			personaIDs[i] = failedID // Store even failed IDs for tracking
 			createErrorsMu.Lock()
 			createErrors = append(createErrors, fmt.Errorf("persona %d: no data from Gemini", i+1))
@@ -296,7 +431,7 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			continue
 		}
 
-		p := personas[i]
+		p := withPanel(personas[i], panel)
 		color := colors[i%len(colors)]
 		formatted := FormatPersonaNote(p)
 		// Calculate position
@@ -308,7 +443,7 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 		// Place note at the bottom of the anchor area, with a border
 		noteY := ay + (ah * 0.34)
 
-		title := fmt.Sprintf("Persona %d: %s", i+1, p.Name)
+		title := atom.PersonaNoteTitle(panel, i, p.Name)
 		personaTitles[i] = title
 
 		noteMeta := map[string]interface{}{
@@ -327,7 +462,7 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			singleNoteTimer.StopAndLog(false)
 			log.Printf("[CreatePersonas] ERROR: Failed to create persona note %d (%s): %v", i+1, title, err)
 			// Create failure indicator note
-			failedID := createFailedPersonaNote(client, i, err.Error(), x, noteY, imgW, noteH*ah)
+			failedID := createFailedPersonaNote(client, panel, i, err.Error(), x, noteY, imgW, noteH*ah)
 			personaIDs[i] = failedID
 			createErrorsMu.Lock()
 			createErrors = append(createErrors, fmt.Errorf("persona %d (%s): %w", i+1, title, err))
@@ -343,6 +478,7 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			} else {
 				singleNoteTimer.StopAndLog(true)
 				personaIDs[i] = noteWidgetID
+				personastore.GetGlobalStore().Save(client.CanvasID, noteWidgetID, p)
 				noteCreated = true
 				successCountMu.Lock()
 				successCount++
@@ -356,69 +492,13 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 			imgWg.Add(1)
 			go func(p Persona, x, imgY, imgW, imgHpx float64, idx int, title string) {
 				defer imgWg.Done()
-
-				// Time the entire image goroutine operation
-				goroutineTimer := timing.Start(fmt.Sprintf("create_personas_image_goroutine_%d", idx+1))
-
-				log.Printf("[CreatePersonas] Calling OpenAI DALL-E for persona: %s", title)
-
-				// Note: GeneratePersonaImageOpenAI is already instrumented in client.go
-				// It tracks: openai_dalle_total, openai_dalle_api_attempt_N, openai_dalle_image_download
-				imgBytes, err := GeneratePersonaImageOpenAI(p)
-				if err != nil {
-					timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=dalle_generation persona=%s", title))
-					goroutineTimer.Stop()
-					log.Printf("[CreatePersonas] Persona image not generated for %s: %v", title, err)
-					return
-				}
-
-				tmpfile, err := os.CreateTemp("", "persona_*.png")
-				if err != nil {
-					timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=temp_file persona=%s", title))
-					goroutineTimer.Stop()
-					log.Printf("[CreatePersonas] Could not create temp file for persona image %s: %v", title, err)
-					return
-				}
-				imgPath := tmpfile.Name()
-				if _, err := tmpfile.Write(imgBytes); err != nil {
-					timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=write_temp persona=%s", title))
-					goroutineTimer.Stop()
-					log.Printf("[CreatePersonas] Could not write persona image to temp file %s: %v", title, err)
-					tmpfile.Close()
-					os.Remove(imgPath)
-					return
-				}
-				tmpfile.Close()
-
-				imgMeta := map[string]interface{}{
-					"title":    title + " Headshot",
-					"location": map[string]interface{}{"x": x, "y": imgY},
-					"size":     map[string]interface{}{"width": imgW, "height": imgHpx},
-				}
-
-				// Time the Canvus image upload separately
-				uploadTimer := timing.Start(fmt.Sprintf("create_personas_image_upload_%d", idx+1))
-				imgWidget, err := client.CreateImage(imgPath, imgMeta)
-				if err != nil {
-					uploadTimer.StopAndLog(false)
-					timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=upload persona=%s", title))
-					goroutineTimer.Stop()
-					log.Printf("[CreatePersonas] Failed to upload persona image for %s: %v", title, err)
-				} else {
-					uploadTimer.StopAndLog(true)
-					imgWidgetID, _ := imgWidget["id"].(string)
-					timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), true, fmt.Sprintf("persona=%s image_id=%s", title, imgWidgetID))
-					goroutineTimer.Stop()
-					log.Printf("[CreatePersonas] Persona image uploaded: %s (ID: %s)", title+" Headshot", imgWidgetID)
-				}
-				os.Remove(imgPath)
+				uploadPersonaHeadshot(ctx, client, p, x, imgY, imgW, imgHpx, idx, title)
 			}(p, x, imgY, imgW, imgHpx, i, title)
 		}
 	}
 
 	noteCreationTimer.StopAndLog(true)
 	log.Printf("[CreatePersonas] Persona image generation running in background for %d personas", successCount)
-	// --- end Gemini persona generation ---
 
 	// Check for partial success - need at least MinRequiredPersonas
 	if successCount < MinRequiredPersonas {
@@ -441,11 +521,71 @@ func CreatePersonasWithCache(ctx context.Context, qnoteID string, client *canvus
 	}
 
 	// Store persona note IDs for this Qnote (may be less than 4 in partial success case)
-	PersonaNoteIDs.Store(qnoteID, validIDs)
+	PersonaNoteIDs.Store(key, validIDs)
 	log.Printf("[CreatePersonas] Successfully created and stored %d persona IDs for Qnote %s", len(validIDs), qnoteID)
 	return nil
 }
 
+// uploadPersonaHeadshot generates a headshot for p and places it on the canvas at
+// x, imgY, titled after the persona note title
+func uploadPersonaHeadshot(ctx context.Context, client *canvusapi.Client, p Persona, x, imgY, imgW, imgHpx float64, idx int, title string) {
+	// Time the entire image goroutine operation
+	goroutineTimer := timing.Start(fmt.Sprintf("create_personas_image_goroutine_%d", idx+1))
+
+	log.Printf("[CreatePersonas] Calling OpenAI DALL-E for persona: %s", title)
+
+	// Note: GeneratePersonaImageOpenAI is already instrumented in client.go
+	// It tracks: openai_dalle_total, openai_dalle_api_attempt_N, openai_dalle_image_download
+	imgBytes, err := GeneratePersonaImageOpenAI(usage.WithPersona(ctx, p.Name), p)
+	if err != nil {
+		timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=dalle_generation persona=%s", title))
+		goroutineTimer.Stop()
+		log.Printf("[CreatePersonas] Persona image not generated for %s: %v", title, err)
+		return
+	}
+
+	tmpfile, err := os.CreateTemp("", "persona_*.png")
+	if err != nil {
+		timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=temp_file persona=%s", title))
+		goroutineTimer.Stop()
+		log.Printf("[CreatePersonas] Could not create temp file for persona image %s: %v", title, err)
+		return
+	}
+	imgPath := tmpfile.Name()
+	if _, err := tmpfile.Write(imgBytes); err != nil {
+		timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=write_temp persona=%s", title))
+		goroutineTimer.Stop()
+		log.Printf("[CreatePersonas] Could not write persona image to temp file %s: %v", title, err)
+		tmpfile.Close()
+		os.Remove(imgPath)
+		return
+	}
+	tmpfile.Close()
+
+	imgMeta := map[string]interface{}{
+		"title":    title + " Headshot",
+		"location": map[string]interface{}{"x": x, "y": imgY},
+		"size":     map[string]interface{}{"width": imgW, "height": imgHpx},
+	}
+
+	// Time the Canvus image upload separately
+	uploadTimer := timing.Start(fmt.Sprintf("create_personas_image_upload_%d", idx+1))
+	imgWidget, err := client.CreateImage(imgPath, imgMeta)
+	if err != nil {
+		uploadTimer.StopAndLog(false)
+		timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), false, fmt.Sprintf("error=upload persona=%s", title))
+		goroutineTimer.Stop()
+		log.Printf("[CreatePersonas] Failed to upload persona image for %s: %v", title, err)
+	} else {
+		uploadTimer.StopAndLog(true)
+		imgWidgetID, _ := imgWidget["id"].(string)
+		timing.LogOperationWithDetails(goroutineTimer.Name(), goroutineTimer.Duration(), true, fmt.Sprintf("persona=%s image_id=%s", title, imgWidgetID))
+		goroutineTimer.Stop()
+		log.Printf("[CreatePersonas] Persona image uploaded: %s (ID: %s)", title+" Headshot", imgWidgetID)
+	}
+	os.Remove(imgPath)
+}
+
 // getBusinessContext extracts business notes and the personas anchor from the canvas.
 // Deprecated: Use getBusinessContextWithCache for better performance.
 func getBusinessContext(ctx context.Context, qnoteID string, client *canvusapi.Client) (string, map[string]interface{}, error) {
@@ -463,6 +603,12 @@ func getBusinessContextWithCache(ctx context.Context, qnoteID string, client *ca
 // Returns the missing notes list for error feedback purposes.
 // If cachedWidgets is provided, it will be used instead of fetching widgets again.
 func getBusinessContextWithCacheAndMissing(ctx context.Context, qnoteID string, client *canvusapi.Client, cachedWidgets []map[string]interface{}) (string, map[string]interface{}, []string, error) {
+	return getPanelContextWithCacheAndMissing(ctx, qnoteID, client, cachedWidgets, types.PanelCustomer)
+}
+
+// getPanelContextWithCacheAndMissing is getBusinessContextWithCacheAndMissing
+// returning the anchor of panel in place of the Personas anchor.
+func getPanelContextWithCacheAndMissing(ctx context.Context, qnoteID string, client *canvusapi.Client, cachedWidgets []map[string]interface{}, panel types.Panel) (string, map[string]interface{}, []string, error) {
 	var widgets []map[string]interface{}
 	var err error
 
@@ -483,7 +629,8 @@ func getBusinessContextWithCacheAndMissing(ctx context.Context, qnoteID string,
 		getWidgetsTimer.StopAndLog(true)
 	}
 
-	businessContext, personasAnchor, missingNotes, err := molecule.ExtractBusinessContext(widgets)
+	businessContext, personasAnchor, missingNotes, err := molecule.ExtractPanelContext(widgets, atom.PanelAnchorName(panel))
+	rememberLanguage(client, molecule.BusinessNotesText(widgets))
 	if err != nil {
 		if len(missingNotes) > 0 {
 			log.Printf("[getBusinessContext] Missing required notes: %v", missingNotes)
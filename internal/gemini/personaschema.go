@@ -0,0 +1,98 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/types"
+	"google.golang.org/genai"
+)
+
+// personaRepairAttempts is how many times Gemini is asked to fix invalid personas
+const personaRepairAttempts = 2
+
+// personaFields are the persona JSON fields, in the order Gemini should write them
+var personaFields = []string{
+	"name", "role", "description", "background", "goals", "age", "sex", "race",
+	"sector", "region", "company_size", "market", "attitude", "pain_points", "objections", "budget", "decision_authority", "tech_savviness",
+	"channels", "brand_affinities", "personality",
+}
+
+// personalityTraits are the Big Five personality fields
+var personalityTraits = []string{"openness", "conscientiousness", "extraversion", "agreeableness", "neuroticism"}
+
+// personasSchema constrains Gemini's response to a JSON array of count complete
+// personas, taking the sector, region and company size from d's lists if it has them
+func personasSchema(count int, d types.Diversity) *genai.Schema {
+	text := func(description string) *genai.Schema {
+		return &genai.Schema{Type: genai.TypeString, Description: description, MinLength: genai.Ptr[int64](1)}
+	}
+	choice := func(description string, values []string) *genai.Schema {
+		if len(values) == 0 {
+			return text(description)
+		}
+		return &genai.Schema{Type: genai.TypeString, Description: description, Format: "enum", Enum: values}
+	}
+	list := func(description, item string) *genai.Schema {
+		return &genai.Schema{Type: genai.TypeArray, Description: description, MinItems: genai.Ptr[int64](1), Items: text(item)}
+	}
+	personality := &genai.Schema{
+		Type:             genai.TypeObject,
+		Description:      "Big Five personality profile",
+		Properties:       make(map[string]*genai.Schema, len(personalityTraits)),
+		Required:         personalityTraits,
+		PropertyOrdering: personalityTraits,
+	}
+	for _, trait := range personalityTraits {
+		personality.Properties[trait] = &genai.Schema{Type: genai.TypeString, Format: "enum", Enum: []string{"low", "medium", "high"}}
+	}
+	return &genai.Schema{
+		Type:     genai.TypeArray,
+		MinItems: genai.Ptr(int64(count)),
+		MaxItems: genai.Ptr(int64(count)),
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"name":        text("Full name"),
+				"role":        text("Job title and organisation type"),
+				"description": text("One or two sentences describing the persona"),
+				"background":  text("Professional and personal background"),
+				"goals":       list("Key objectives related to the business context", "One goal"),
+				"age": {
+					Type:    genai.TypeInteger,
+					Minimum: genai.Ptr[float64](18),
+					Maximum: genai.Ptr[float64](100),
+				},
+				"sex":                text("Sex"),
+				"race":               text("Race or ethnicity"),
+				"sector":             choice("Market sector the persona buys for", d.Sectors),
+				"region":             choice("Region or country where the persona lives or works", d.Regions),
+				"company_size":       choice("Size of the persona's organisation, e.g. sole trader, startup, SME, enterprise", d.CompanySizes),
+				"market":             choice("B2B if buying for a business, B2C if buying for themselves", []string{"B2B", "B2C"}),
+				"attitude":           choice("Attitude towards the offering", []string{types.AttitudeSceptic, types.AttitudeNeutral, types.AttitudeEnthusiast}),
+				"pain_points":        list("Frustrations the persona has today that the business could address", "One pain point"),
+				"objections":         list("Reasons the persona might hesitate to buy", "One objection"),
+				"budget":             text("Budget or income bracket available for this kind of purchase"),
+				"decision_authority": text("Role in the buying decision, e.g. final approver, influencer, end user"),
+				"tech_savviness":     text("Comfort with technology: low, medium or high, with a short qualifier"),
+				"channels":           list("Channels the persona prefers for learning about and buying products", "One channel"),
+				"brand_affinities":   list("Brands the persona likes or trusts", "One brand"),
+				"personality":        personality,
+			},
+			Required:         personaFields,
+			PropertyOrdering: personaFields,
+		},
+	}
+}
+
+// parsePersonas decodes a persona generation response expecting count personas
+// and validates it, returning the usable personas and the problems to send back
+// to the model
+func parsePersonas(text string, count int) ([]Persona, []string) {
+	var personas []Persona
+	if err := json.Unmarshal([]byte(atom.StripMarkdownCodeBlock(text)), &personas); err != nil {
+		return nil, []string{fmt.Sprintf("the response is not a valid JSON array of personas: %v", err)}
+	}
+	return atom.ValidatePersonas(personas, count)
+}
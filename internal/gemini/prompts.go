@@ -0,0 +1,46 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/internal/prompts"
+)
+
+// renderPrompt renders the named template from the workflow's prompt set,
+// falling back to the built-in template if an override fails to render
+func renderPrompt(ctx context.Context, name string, data interface{}) string {
+	out, err := promptsFrom(ctx).Render(name, data)
+	if err == nil {
+		return out
+	}
+	log.Printf("[prompts] %v, using the built-in prompt", err)
+	out, _ = prompts.Default().Render(name, data)
+	return out
+}
+
+// promptVersion returns the version of the workflow's prompt set, recorded with its answers
+func promptVersion(ctx context.Context) string {
+	return promptsFrom(ctx).Version
+}
+
+// renderSystemPrompt returns the prompt that sets up a persona's chat session
+func renderSystemPrompt(ctx context.Context, persona Persona, businessContext string) string {
+	return renderPrompt(ctx, prompts.System, prompts.SystemData{Persona: persona, BusinessContext: businessContext, Language: answerLanguage(ctx, businessContext)})
+}
+
+// renderMetaPrompt returns the prompt asking a persona to react to the others' answers
+func renderMetaPrompt(ctx context.Context, name string, others []string) string {
+	return renderPrompt(ctx, prompts.MetaAnswer, prompts.MetaAnswerData{Name: name, Others: strings.Join(others, "; ")})
+}
+
+// renderDialoguePrompt returns the prompt asking a persona to reply to another about question
+func renderDialoguePrompt(ctx context.Context, other, question, said string) string {
+	return renderPrompt(ctx, prompts.Dialogue, prompts.DialogueData{Other: other, Question: question, Said: said})
+}
+
+// renderSuccinctPrompt returns the prompt asking for an answer within limit characters
+func renderSuccinctPrompt(ctx context.Context, limit int) string {
+	return renderPrompt(ctx, prompts.Succinct, prompts.SuccinctData{Limit: limit})
+}
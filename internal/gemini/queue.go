@@ -0,0 +1,119 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/queue"
+)
+
+// --- Global workflow queue, set by main at startup ---
+var (
+	workflowQueue   *queue.Queue
+	workflowQueueMu sync.RWMutex
+
+	// queueNoteMu serialises helper note updates so concurrent position
+	// callbacks cannot create duplicate helper notes
+	queueNoteMu sync.Mutex
+)
+
+// SetWorkflowQueue sets the queue that gates LLM-heavy workflow stages. With no
+// queue set, stages run immediately.
+func SetWorkflowQueue(q *queue.Queue) {
+	workflowQueueMu.Lock()
+	defer workflowQueueMu.Unlock()
+	workflowQueue = q
+}
+
+// GetWorkflowQueue returns the workflow queue, or nil if none is set
+func GetWorkflowQueue() *queue.Queue {
+	workflowQueueMu.RLock()
+	defer workflowQueueMu.RUnlock()
+	return workflowQueue
+}
+
+// runQueued runs fn on the workflow queue and blocks until it has finished.
+// fn receives waited=true if it had to wait for a worker, so it can refresh any
+// widgets fetched beforehand. While waiting, the Qnote's helper note shows the
+// queue position. Returns false if fn never ran (cancelled or shut down).
+func runQueued(ctx context.Context, client *canvusapi.Client, qnoteID string, fn func(waited bool)) bool {
+	return runOnQueue(ctx, client, qnoteID, func(q *queue.Queue) { showQueuePosition(client, q, qnoteID) }, fn)
+}
+
+// runQueuedQuietly is runQueued for workflows without a helper note, such as
+// interview questions: the queue position is only logged
+func runQueuedQuietly(ctx context.Context, client *canvusapi.Client, noteID string, fn func(waited bool)) bool {
+	return runOnQueue(ctx, client, noteID, nil, fn)
+}
+
+// runOnQueue submits fn to the workflow queue as the job id and waits for it,
+// calling showPosition (if set) whenever the job's place in the queue changes
+func runOnQueue(ctx context.Context, client *canvusapi.Client, id string, showPosition func(q *queue.Queue), fn func(waited bool)) bool {
+	q := GetWorkflowQueue()
+	if q == nil {
+		fn(false)
+		return true
+	}
+
+	ran := false
+	var waited atomic.Bool // set once the job has been shown a queue position
+	job := &queue.Job{
+		ID:     id,
+		Canvas: client.CanvasID,
+		Run: func(context.Context) {
+			ran = true
+			fn(waited.Load())
+		},
+		OnPosition: func(int) {
+			waited.Store(true)
+			if showPosition != nil {
+				showPosition(q)
+			}
+		},
+	}
+	done, position := q.Submit(job)
+	if position > 0 {
+		log.Printf("[queue] Note %s is #%d in the queue", id, position)
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if q.Cancel(id) {
+			return false
+		}
+		// Already running; let it finish so notes are not left half-written
+		<-done
+	}
+	return ran
+}
+
+// showQueuePosition updates the Qnote's helper note with its current place in the
+// queue, creating the helper note if the workflow has none yet
+func showQueuePosition(client *canvusapi.Client, q *queue.Queue, qnoteID string) {
+	queueNoteMu.Lock()
+	defer queueNoteMu.Unlock()
+
+	// Re-read the position: callbacks run asynchronously and may arrive out of order
+	position := q.Position(qnoteID)
+	if position == 0 {
+		return
+	}
+	if _, ok := qnoteHelperNotes.Load(qnoteID); !ok {
+		EnsureHelperNoteForQuestion(qnoteID, client)
+		checkpointHelperNote(qnoteID)
+	}
+	val, ok := qnoteHelperNotes.Load(qnoteID)
+	if !ok {
+		return
+	}
+	helperID := val.(string)
+	text := i18n.T(canvasLanguage(client, nil), i18n.QueuePosition, position)
+	if _, err := client.UpdateNote(helperID, map[string]interface{}{"text": text}); err != nil {
+		log.Printf("[warn] UpdateNote failed showing queue position on helper note %s: %v", helperID, err)
+	}
+}
@@ -0,0 +1,165 @@
+package gemini
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/checkpoint"
+)
+
+// Recovery modes for workflows interrupted by a restart (workflow.recovery)
+const (
+	RecoveryResume  = "resume"  // continue from the last completed step (default)
+	RecoveryCleanup = "cleanup" // delete partial notes and reset the Qnote
+	RecoveryOff     = "off"     // leave interrupted workflows untouched
+)
+
+// recoveryMode returns the configured workflow.recovery mode
+func recoveryMode() string {
+	return strings.ToLower(currentConfig().Workflow.Recovery)
+}
+
+// checkpointHelperNote records the Qnote's tracked helper note in its checkpoint
+func checkpointHelperNote(qnoteID string) {
+	val, ok := qnoteHelperNotes.Load(qnoteID)
+	if !ok {
+		return
+	}
+	helperID := val.(string)
+	checkpoint.GetGlobalStore().Update(qnoteID, func(r *checkpoint.Record) { r.HelperID = helperID })
+}
+
+// createTrackedConnector creates a connector from src to dst unless an interrupted run
+// already created it, recording the new connector's ID in the Qnote's checkpoint
+func createTrackedConnector(client *canvusapi.Client, qnoteID string, existing map[string]string, src, dst string) error {
+	key := checkpoint.ConnectorKey(src, dst)
+	if _, ok := existing[key]; ok {
+		return nil
+	}
+	conn, err := client.CreateConnector(BuildConnectorPayload(src, dst))
+	if err != nil {
+		return err
+	}
+	connID, _ := conn["id"].(string)
+	checkpoint.GetGlobalStore().Update(qnoteID, func(r *checkpoint.Record) {
+		if r.Connectors == nil {
+			r.Connectors = make(map[string]string)
+		}
+		r.Connectors[key] = connID
+	})
+	return nil
+}
+
+// RecoverWorkflows handles question workflows on this client's canvas that were
+// still running when the process stopped. Depending on WORKFLOW_RECOVERY they are
+// handed to resume (which should restart HandleAIQuestion for the Qnote), or their
+// partial notes are deleted and the Qnote is reset so the question starts over.
+func RecoverWorkflows(client *canvusapi.Client, resume func(qnoteID string)) {
+	mode := recoveryMode()
+	store := checkpoint.GetGlobalStore()
+	pending := store.Incomplete(client.CanvasID)
+	if len(pending) == 0 {
+		return
+	}
+	if mode == RecoveryOff {
+		log.Printf("[recovery] %d interrupted workflows left untouched (WORKFLOW_RECOVERY=off)", len(pending))
+		return
+	}
+	log.Printf("[recovery] Found %d interrupted workflows (mode=%s)", len(pending), mode)
+
+	for _, rec := range pending {
+		qWidget, err := client.GetNote(rec.QnoteID, false)
+		if err != nil {
+			log.Printf("[recovery] Qnote %s no longer exists, removing its partial notes", rec.QnoteID)
+			deleteWorkflowWidgets(client, rec)
+			store.Finish(rec.QnoteID, checkpoint.StatusCancelled, "question note deleted")
+			continue
+		}
+
+		// The helper note text belongs to the old run; a fresh one is made on resume
+		if rec.HelperID != "" {
+			if err := client.DeleteNote(rec.HelperID); err != nil {
+				log.Printf("[warn] DeleteNote failed for helper note %s: %v", rec.HelperID, err)
+			}
+			rec.HelperID = ""
+		}
+
+		if mode == RecoveryCleanup {
+			deleteWorkflowWidgets(client, rec)
+			question := rec.Question
+			if question == "" {
+				text, _ := qWidget["text"].(string)
+				question = questionFromNoteText(text)
+			}
+			// A white New_AI_Question note is picked up again as a fresh question
+			if _, err := client.UpdateNote(rec.QnoteID, map[string]interface{}{"background_color": "#ffffffff", "text": question}); err != nil {
+				log.Printf("[warn] UpdateNote failed resetting Qnote %s: %v", rec.QnoteID, err)
+			}
+			store.Finish(rec.QnoteID, checkpoint.StatusCancelled, "cleaned up after restart")
+			log.Printf("[recovery] Cleaned up and reset Qnote %s", rec.QnoteID)
+			continue
+		}
+
+		store.Update(rec.QnoteID, func(r *checkpoint.Record) { r.HelperID = "" })
+		log.Printf("[recovery] Resuming Qnote %s (completed steps: %v)", rec.QnoteID, rec.Steps)
+		resume(rec.QnoteID)
+	}
+}
+
+// deleteWorkflowWidgets removes the answer notes, meta notes, connectors and anchor a workflow created
+func deleteWorkflowWidgets(client *canvusapi.Client, rec checkpoint.Record) {
+	notes, connectors, anchor := rec.CreatedWidgets()
+	for _, id := range connectors {
+		if err := client.DeleteConnector(id); err != nil {
+			log.Printf("[warn] DeleteConnector failed for %s: %v", id, err)
+		}
+	}
+	if anchor != "" {
+		if err := client.DeleteAnchor(anchor); err != nil {
+			log.Printf("[warn] DeleteAnchor failed for %s: %v", anchor, err)
+		}
+	}
+	for _, id := range notes {
+		if err := client.DeleteNote(id); err != nil {
+			log.Printf("[warn] DeleteNote failed for %s: %v", id, err)
+		}
+	}
+	if rec.HelperID != "" {
+		if err := client.DeleteNote(rec.HelperID); err != nil {
+			log.Printf("[warn] DeleteNote failed for helper note %s: %v", rec.HelperID, err)
+		}
+	}
+	log.Printf("[recovery] Deleted %d notes and %d connectors for Qnote %s", len(notes), len(connectors), rec.QnoteID)
+}
+
+// CleanupQuestion deletes the answer notes, connectors, anchor and helper note a
+// finished (or abandoned) question workflow created, keeping its checkpoint so the
+// answers can still be exported. If deleteQnote is set the Qnote is removed too.
+func CleanupQuestion(client *canvusapi.Client, qnoteID string, deleteQnote bool) error {
+	store := checkpoint.GetGlobalStore()
+	rec, ok := store.Get(qnoteID)
+	if !ok {
+		return fmt.Errorf("no checkpoint for question %s", qnoteID)
+	}
+	deleteWorkflowWidgets(client, rec)
+	store.Update(qnoteID, func(r *checkpoint.Record) {
+		for i := range r.Personas {
+			r.Personas[i].AnswerNoteID = ""
+			r.Personas[i].MetaNoteID = ""
+		}
+		r.Connectors = nil
+		r.AnchorID = ""
+		r.HelperID = ""
+	})
+	if rec.Status == checkpoint.StatusRunning {
+		store.Finish(qnoteID, checkpoint.StatusCancelled, "cleaned up")
+	}
+	if deleteQnote {
+		if err := client.DeleteNote(qnoteID); err != nil {
+			return fmt.Errorf("failed to delete question note %s: %w", qnoteID, err)
+		}
+	}
+	return nil
+}
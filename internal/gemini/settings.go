@@ -0,0 +1,92 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/jaypaulb/AI-personas/internal/config"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+	"github.com/jaypaulb/AI-personas/internal/prompts"
+)
+
+// runtimeSettings are the configuration and prompt templates a workflow runs with
+type runtimeSettings struct {
+	cfg       *config.Config
+	prompts   *prompts.Sets
+	languages map[string]string // registered canvas name -> language code
+	canvas    string            // registered canvas name, selecting its prompts and language
+}
+
+// language returns the configured language code for the canvas, or i18n.Auto
+func (s runtimeSettings) language(canvas string) string {
+	if lang, ok := s.languages[canvas]; ok {
+		return lang
+	}
+	return i18n.Normalize(s.cfg.Workflow.Language)
+}
+
+// settingsKey is the context key under which a workflow's settings are pinned
+type settingsKey struct{}
+
+var (
+	settingsMu sync.RWMutex
+	settings   = runtimeSettings{cfg: config.Default(), prompts: &prompts.Sets{Global: prompts.Default()}}
+)
+
+// Configure sets the configuration, prompt templates and languages used by new Gemini
+// clients, sessions and workflows. With none set, the built-in defaults are
+// used. Running workflows keep the settings they started with.
+func Configure(cfg *config.Config) {
+	sets, err := cfg.LoadPrompts()
+	if err != nil {
+		log.Printf("[prompts] Using the built-in prompts: %v", err)
+		sets = &prompts.Sets{Global: prompts.Default()}
+	}
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = runtimeSettings{cfg: cfg, prompts: sets, languages: cfg.CanvasLanguages()}
+}
+
+// currentSettings returns the settings set by Configure
+func currentSettings() runtimeSettings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+// currentConfig returns the configuration set by Configure
+func currentConfig() *config.Config {
+	return currentSettings().cfg
+}
+
+// withSettings pins the current settings and the canvas's prompts to ctx, so a
+// workflow keeps them when Configure is called again by a reload. Settings
+// already pinned to ctx are kept.
+func withSettings(ctx context.Context, canvas string) context.Context {
+	if _, ok := ctx.Value(settingsKey{}).(runtimeSettings); ok {
+		return ctx
+	}
+	s := currentSettings()
+	s.canvas = canvas
+	return context.WithValue(ctx, settingsKey{}, s)
+}
+
+// settingsFrom returns the settings pinned to ctx, or the current ones
+func settingsFrom(ctx context.Context) runtimeSettings {
+	if s, ok := ctx.Value(settingsKey{}).(runtimeSettings); ok {
+		return s
+	}
+	return currentSettings()
+}
+
+// configFrom returns the configuration pinned to ctx, or the current one
+func configFrom(ctx context.Context) *config.Config {
+	return settingsFrom(ctx).cfg
+}
+
+// promptsFrom returns the prompt templates pinned to ctx, or the current global ones
+func promptsFrom(ctx context.Context) *prompts.Set {
+	s := settingsFrom(ctx)
+	return s.prompts.For(s.canvas)
+}
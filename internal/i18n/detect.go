@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minDetectHits is the fewest stopword matches needed to pick a Latin-script language
+const minDetectHits = 3
+
+// stopwords are frequent short words that are distinctive for each Latin-script language
+var stopwords = map[string]map[string]bool{
+	"en": set("the", "and", "of", "to", "is", "for", "with", "our", "we", "are", "that", "this", "from", "by", "who", "their"),
+	"fr": set("le", "la", "les", "des", "et", "est", "pour", "nous", "une", "du", "avec", "dans", "sur", "que", "qui", "aux", "nos", "leur"),
+	"de": set("der", "die", "das", "und", "ist", "für", "wir", "mit", "ein", "eine", "den", "von", "zu", "auf", "nicht", "unsere", "dem", "sie"),
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// Detect guesses the language of text, returning false if it cannot tell.
+// Japanese is recognised by its kana; English, French and German by stopwords.
+func Detect(text string) (string, bool) {
+	var letters, kana int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+			letters++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	if letters == 0 {
+		return "", false
+	}
+	if kana*10 >= letters {
+		return "ja", true
+	}
+
+	hits := make(map[string]int, len(stopwords))
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for lang, words := range stopwords {
+			if words[word] {
+				hits[lang]++
+			}
+		}
+	}
+	best, bestHits, runnerUp := "", 0, 0
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			best, bestHits, runnerUp = lang, n, bestHits
+		case n > runnerUp:
+			runnerUp = n
+		}
+	}
+	// Require a clear winner so short or mixed-language notes fall back to the default
+	if bestHits < minDetectHits || bestHits < 2*runnerUp {
+		return "", false
+	}
+	return best, true
+}
@@ -0,0 +1,312 @@
+// Package i18n localises the notes the app writes on a canvas and detects the
+// language a canvas is written in.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Language codes with special meaning
+const (
+	English = "en"
+	Auto    = "auto" // detect from the business notes
+)
+
+// Message identifies a localised canvas text
+type Message string
+
+// Canvas texts. Those taking arguments are fmt format strings.
+const (
+	HelperQuestionTitle  Message = "helper_question_title"
+	HelperQuestionText   Message = "helper_question_text"
+	HelperProcessing     Message = "helper_processing"
+	HelperFollowupText   Message = "helper_followup_text"
+	HelperPersonasTitle  Message = "helper_personas_title"
+	HelperPersonasText   Message = "helper_personas_text"
+	AnswersWait          Message = "answers_wait"
+	AnswersWaitSeconds   Message = "answers_wait_seconds" // %d seconds
+	AnswersWaitMinutes   Message = "answers_wait_minutes"
+	QueuePosition        Message = "queue_position" // %d position
+	TimeoutTitle         Message = "timeout_title"
+	TimeoutText          Message = "timeout_text" // %v timeout
+	BudgetTitle          Message = "budget_title"
+	BudgetText           Message = "budget_text" // %v budget error
+	MissingNotesTitle    Message = "missing_notes_title"
+	MissingNotesIntro    Message = "missing_notes_intro"
+	MissingNotesOutro    Message = "missing_notes_outro"
+	FailedPersonaText    Message = "failed_persona_text" // %d persona number, %s reason
+	PersonaFieldsTitle   Message = "persona_fields_title"
+	PersonaFieldsText    Message = "persona_fields_text" // %s persona note title, %s missing field labels
+	PersonaUpdatedTitle  Message = "persona_updated_title"
+	PersonaUpdatedText   Message = "persona_updated_text" // %s persona name, %s changed field labels
+	LibrarySavedTitle    Message = "library_saved_title"
+	LibrarySavedText     Message = "library_saved_text" // %s persona names, %s tags
+	LibraryImportTitle   Message = "library_import_title"
+	LibraryImportText    Message = "library_import_text" // %d count, %s persona names
+	LibraryErrorTitle    Message = "library_error_title"
+	LibraryErrorText     Message = "library_error_text" // %v error
+	RegeneratedTitle     Message = "regenerated_title"
+	RegeneratedText      Message = "regenerated_text" // %s persona slot, e.g. "Internal Persona 2", %s new name
+	RegenerateErrorTitle Message = "regenerate_error_title"
+	RegenerateErrorText  Message = "regenerate_error_text" // %s persona slot, %v error
+	PanelMissingText     Message = "panel_missing_text"    // %s anchor names
+	InterviewErrorTitle  Message = "interview_error_title"
+	InterviewErrorText   Message = "interview_error_text" // %s persona, %v error
+	FollowupErrorTitle   Message = "followup_error_title"
+	FollowupErrorText    Message = "followup_error_text" // %s answer note title, %v error
+	DialogueErrorTitle   Message = "dialogue_error_title"
+	DialogueErrorText    Message = "dialogue_error_text" // %s and %s the two personas, %v error
+)
+
+// names are the English names of the supported languages, used in prompts
+var names = map[string]string{
+	"en": "English",
+	"fr": "French",
+	"de": "German",
+	"ja": "Japanese",
+}
+
+// aliases map language names people are likely to write to codes
+var aliases = map[string]string{
+	"english":  "en",
+	"french":   "fr",
+	"français": "fr",
+	"francais": "fr",
+	"german":   "de",
+	"deutsch":  "de",
+	"japanese": "ja",
+	"日本語":      "ja",
+}
+
+var catalog = map[string]map[Message]string{
+	"en": {
+		HelperQuestionTitle:  "Helper: Please enter a question for this note",
+		HelperQuestionText:   "Please enter a question in the main note to begin the Q&A process.",
+		HelperProcessing:     "Processing Question...",
+		HelperFollowupText:   "Please enter a question in the note to enable follow-up.",
+		HelperPersonasTitle:  "Helper: Generating personas, please wait...",
+		HelperPersonasText:   "Personas are being generated. Please wait before proceeding.",
+		AnswersWait:          "Generating answers, please wait...",
+		AnswersWaitSeconds:   "Generating answers, please wait... This can take up to %d seconds.",
+		AnswersWaitMinutes:   "Generating answers, please wait... This could take a few minutes as the model thinks about its answers.",
+		QueuePosition:        "You are #%d in the queue.\n\nYour question will be answered as soon as earlier requests finish.",
+		TimeoutTitle:         "Question Wait Timed Out",
+		TimeoutText:          "The system waited %v for a question to be entered, but none was detected.\n\nPlease enter your question (ending with ?) in the note, then create a new 'New_AI_Question' trigger note to restart the Q&A process.",
+		BudgetTitle:          "Budget Limit Reached",
+		BudgetText:           "This request was stopped before calling the AI because it would exceed the configured spending budget.\n\n%v\n\nAsk the facilitator to raise the budget or try again tomorrow.",
+		MissingNotesTitle:    "Missing Required Notes",
+		MissingNotesIntro:    "The following required Business Model Canvas notes are missing:",
+		MissingNotesOutro:    "Please add these notes to the canvas with the exact titles listed above, then try again.",
+		FailedPersonaText:    "Failed to create persona %d.\n\nReason: %s\n\nThis persona will be skipped in Q&A sessions.",
+		PersonaFieldsTitle:   "Persona Note Incomplete",
+		PersonaFieldsText:    "These fields could not be read from '%s': %s.\n\nThe last saved values are used until the note is fixed. Keep each field on its own line as 'Label: value', e.g. 'Role: Head of Operations'.",
+		PersonaUpdatedTitle:  "Persona Updated",
+		PersonaUpdatedText:   "%s: %s changed. Answers from now on use the new profile.",
+		LibrarySavedTitle:    "Saved to Persona Library",
+		LibrarySavedText:     "Saved %s. Tags: %s",
+		LibraryImportTitle:   "Personas Imported",
+		LibraryImportText:    "%d persona(s) imported from the library: %s",
+		LibraryErrorTitle:    "Persona Library",
+		LibraryErrorText:     "The request could not be completed: %v",
+		RegeneratedTitle:     "Persona Regenerated",
+		RegeneratedText:      "%s is now %s, with a fresh conversation.",
+		RegenerateErrorTitle: "Persona Not Regenerated",
+		RegenerateErrorText:  "%s could not be regenerated: %v",
+		PanelMissingText:     "This question is for a panel with no anchor on the canvas. Add an anchor named %s to ask it.",
+		InterviewErrorTitle:  "Interview Not Answered",
+		InterviewErrorText:   "The question could not be put to %s: %v",
+		FollowupErrorTitle:   "Follow-up Not Answered",
+		FollowupErrorText:    "The follow-up to %s could not be answered: %v",
+		DialogueErrorTitle:   "Dialogue Stopped",
+		DialogueErrorText:    "The dialogue between %s and %s stopped: %v",
+	},
+	"fr": {
+		HelperQuestionTitle:  "Aide : saisissez une question dans cette note",
+		HelperQuestionText:   "Saisissez une question dans la note principale pour lancer les questions-réponses.",
+		HelperProcessing:     "Traitement de la question...",
+		HelperFollowupText:   "Saisissez une question dans la note pour poser une question de suivi.",
+		HelperPersonasTitle:  "Aide : génération des personas, veuillez patienter...",
+		HelperPersonasText:   "Les personas sont en cours de génération. Veuillez patienter avant de continuer.",
+		AnswersWait:          "Génération des réponses, veuillez patienter...",
+		AnswersWaitSeconds:   "Génération des réponses, veuillez patienter... Cela peut prendre jusqu'à %d secondes.",
+		AnswersWaitMinutes:   "Génération des réponses, veuillez patienter... Cela peut prendre quelques minutes, le temps que le modèle réfléchisse à ses réponses.",
+		QueuePosition:        "Vous êtes n° %d dans la file d'attente.\n\nVotre question sera traitée dès que les demandes précédentes seront terminées.",
+		TimeoutTitle:         "Délai d'attente de la question dépassé",
+		TimeoutText:          "Le système a attendu %v qu'une question soit saisie, mais aucune n'a été détectée.\n\nSaisissez votre question (terminée par ?) dans la note, puis créez une nouvelle note déclencheur 'New_AI_Question' pour relancer les questions-réponses.",
+		BudgetTitle:          "Limite de budget atteinte",
+		BudgetText:           "Cette demande a été arrêtée avant l'appel à l'IA, car elle dépasserait le budget configuré.\n\n%v\n\nDemandez à l'animateur d'augmenter le budget ou réessayez demain.",
+		MissingNotesTitle:    "Notes obligatoires manquantes",
+		MissingNotesIntro:    "Les notes obligatoires suivantes du Business Model Canvas sont manquantes :",
+		MissingNotesOutro:    "Ajoutez ces notes au canvas avec exactement les titres ci-dessus, puis réessayez.",
+		FailedPersonaText:    "Impossible de créer le persona %d.\n\nRaison : %s\n\nCe persona sera ignoré lors des questions-réponses.",
+		PersonaFieldsTitle:   "Note de persona incomplète",
+		PersonaFieldsText:    "Ces champs n'ont pas pu être lus dans « %s » : %s.\n\nLes dernières valeurs enregistrées sont utilisées jusqu'à ce que la note soit corrigée. Gardez chaque champ sur sa propre ligne sous la forme « Libellé: valeur », par exemple « Role: Directrice des opérations ».",
+		PersonaUpdatedTitle:  "Persona mis à jour",
+		PersonaUpdatedText:   "%s : %s modifié(s). Les réponses suivantes utilisent le nouveau profil.",
+		LibrarySavedTitle:    "Enregistré dans la bibliothèque de personas",
+		LibrarySavedText:     "%s enregistré(s). Étiquettes : %s",
+		LibraryImportTitle:   "Personas importés",
+		LibraryImportText:    "%d persona(s) importé(s) depuis la bibliothèque : %s",
+		LibraryErrorTitle:    "Bibliothèque de personas",
+		LibraryErrorText:     "La demande n'a pas pu aboutir : %v",
+		RegeneratedTitle:     "Persona régénéré",
+		RegeneratedText:      "« %s » est désormais %s, avec une nouvelle conversation.",
+		RegenerateErrorTitle: "Persona non régénéré",
+		RegenerateErrorText:  "« %s » n'a pas pu être régénéré : %v",
+		PanelMissingText:     "Cette question s'adresse à un panel sans ancre sur le canevas. Ajoutez une ancre nommée %s pour la poser.",
+		InterviewErrorTitle:  "Entretien sans réponse",
+		InterviewErrorText:   "La question n'a pas pu être posée à %s : %v",
+		FollowupErrorTitle:   "Question de suivi sans réponse",
+		FollowupErrorText:    "La question de suivi à %s n'a pas pu recevoir de réponse : %v",
+		DialogueErrorTitle:   "Dialogue interrompu",
+		DialogueErrorText:    "Le dialogue entre %s et %s s'est interrompu : %v",
+	},
+	"de": {
+		HelperQuestionTitle:  "Hilfe: Bitte eine Frage in diese Notiz eingeben",
+		HelperQuestionText:   "Bitte geben Sie eine Frage in die Hauptnotiz ein, um die Fragerunde zu starten.",
+		HelperProcessing:     "Frage wird verarbeitet...",
+		HelperFollowupText:   "Bitte geben Sie eine Frage in die Notiz ein, um nachzufragen.",
+		HelperPersonasTitle:  "Hilfe: Personas werden erstellt, bitte warten...",
+		HelperPersonasText:   "Die Personas werden erstellt. Bitte warten Sie, bevor Sie fortfahren.",
+		AnswersWait:          "Antworten werden erstellt, bitte warten...",
+		AnswersWaitSeconds:   "Antworten werden erstellt, bitte warten... Das kann bis zu %d Sekunden dauern.",
+		AnswersWaitMinutes:   "Antworten werden erstellt, bitte warten... Das kann einige Minuten dauern, während das Modell über seine Antworten nachdenkt.",
+		QueuePosition:        "Sie sind Nr. %d in der Warteschlange.\n\nIhre Frage wird beantwortet, sobald frühere Anfragen abgeschlossen sind.",
+		TimeoutTitle:         "Zeitüberschreitung beim Warten auf die Frage",
+		TimeoutText:          "Das System hat %v auf eine Frage gewartet, aber keine erkannt.\n\nBitte geben Sie Ihre Frage (mit ? am Ende) in die Notiz ein und erstellen Sie dann eine neue Auslöser-Notiz 'New_AI_Question', um die Fragerunde neu zu starten.",
+		BudgetTitle:          "Budgetgrenze erreicht",
+		BudgetText:           "Diese Anfrage wurde vor dem Aufruf der KI gestoppt, weil sie das eingestellte Budget überschreiten würde.\n\n%v\n\nBitten Sie die Moderation, das Budget zu erhöhen, oder versuchen Sie es morgen erneut.",
+		MissingNotesTitle:    "Erforderliche Notizen fehlen",
+		MissingNotesIntro:    "Die folgenden erforderlichen Notizen des Business Model Canvas fehlen:",
+		MissingNotesOutro:    "Bitte fügen Sie diese Notizen mit genau den oben genannten Titeln zum Canvas hinzu und versuchen Sie es erneut.",
+		FailedPersonaText:    "Persona %d konnte nicht erstellt werden.\n\nGrund: %s\n\nDiese Persona wird in der Fragerunde übersprungen.",
+		PersonaFieldsTitle:   "Persona-Notiz unvollständig",
+		PersonaFieldsText:    "Diese Felder konnten in „%s“ nicht gelesen werden: %s.\n\nBis die Notiz korrigiert ist, werden die zuletzt gespeicherten Werte verwendet. Schreiben Sie jedes Feld in eine eigene Zeile als „Bezeichnung: Wert“, z. B. „Role: Leiterin Betrieb“.",
+		PersonaUpdatedTitle:  "Persona aktualisiert",
+		PersonaUpdatedText:   "%s: %s geändert. Ab jetzt verwenden die Antworten das neue Profil.",
+		LibrarySavedTitle:    "In der Persona-Bibliothek gespeichert",
+		LibrarySavedText:     "%s gespeichert. Tags: %s",
+		LibraryImportTitle:   "Personas importiert",
+		LibraryImportText:    "%d Persona(s) aus der Bibliothek importiert: %s",
+		LibraryErrorTitle:    "Persona-Bibliothek",
+		LibraryErrorText:     "Die Anfrage konnte nicht ausgeführt werden: %v",
+		RegeneratedTitle:     "Persona neu erstellt",
+		RegeneratedText:      "%s ist jetzt %s, mit einem neuen Gespräch.",
+		RegenerateErrorTitle: "Persona nicht neu erstellt",
+		RegenerateErrorText:  "%s konnte nicht neu erstellt werden: %v",
+		PanelMissingText:     "Diese Frage richtet sich an ein Panel ohne Anker auf der Leinwand. Fügen Sie einen Anker namens %s hinzu, um sie zu stellen.",
+		InterviewErrorTitle:  "Interview nicht beantwortet",
+		InterviewErrorText:   "Die Frage konnte %s nicht gestellt werden: %v",
+		FollowupErrorTitle:   "Nachfrage nicht beantwortet",
+		FollowupErrorText:    "Die Nachfrage zu %s konnte nicht beantwortet werden: %v",
+		DialogueErrorTitle:   "Dialog abgebrochen",
+		DialogueErrorText:    "Der Dialog zwischen %s und %s wurde abgebrochen: %v",
+	},
+	"ja": {
+		HelperQuestionTitle:  "ヘルプ：このノートに質問を入力してください",
+		HelperQuestionText:   "質疑応答を始めるには、メインのノートに質問を入力してください。",
+		HelperProcessing:     "質問を処理しています...",
+		HelperFollowupText:   "フォローアップするには、ノートに質問を入力してください。",
+		HelperPersonasTitle:  "ヘルプ：ペルソナを生成しています。しばらくお待ちください...",
+		HelperPersonasText:   "ペルソナを生成しています。完了するまでお待ちください。",
+		AnswersWait:          "回答を生成しています。しばらくお待ちください...",
+		AnswersWaitSeconds:   "回答を生成しています。しばらくお待ちください... 最大%d秒ほどかかります。",
+		AnswersWaitMinutes:   "回答を生成しています。しばらくお待ちください... モデルが回答を検討するため、数分かかる場合があります。",
+		QueuePosition:        "現在、順番待ちの%d番目です。\n\n先の依頼が終わり次第、質問に回答します。",
+		TimeoutTitle:         "質問の待機がタイムアウトしました",
+		TimeoutText:          "%v待ちましたが、質問が入力されませんでした。\n\nノートに質問（末尾に？）を入力してから、新しい 'New_AI_Question' トリガーノートを作成して質疑応答を再開してください。",
+		BudgetTitle:          "予算の上限に達しました",
+		BudgetText:           "設定された予算を超えるため、AI を呼び出す前にこのリクエストを停止しました。\n\n%v\n\nファシリテーターに予算の引き上げを依頼するか、明日もう一度お試しください。",
+		MissingNotesTitle:    "必須ノートがありません",
+		MissingNotesIntro:    "次の Business Model Canvas の必須ノートがありません：",
+		MissingNotesOutro:    "上記のタイトルのとおりにノートをキャンバスに追加してから、もう一度お試しください。",
+		FailedPersonaText:    "ペルソナ %d を作成できませんでした。\n\n理由：%s\n\nこのペルソナは質疑応答でスキップされます。",
+		PersonaFieldsTitle:   "ペルソナノートが不完全です",
+		PersonaFieldsText:    "「%s」から次の項目を読み取れませんでした：%s。\n\nノートが修正されるまで、最後に保存された値を使用します。各項目は「Label: 値」の形式で1行ずつ記入してください（例：「Role: 事業部長」）。",
+		PersonaUpdatedTitle:  "ペルソナを更新しました",
+		PersonaUpdatedText:   "%s：%s を変更しました。これ以降の回答には新しいプロフィールが使われます。",
+		LibrarySavedTitle:    "ペルソナライブラリに保存しました",
+		LibrarySavedText:     "%s を保存しました。タグ：%s",
+		LibraryImportTitle:   "ペルソナをインポートしました",
+		LibraryImportText:    "ライブラリから %d 件のペルソナをインポートしました：%s",
+		LibraryErrorTitle:    "ペルソナライブラリ",
+		LibraryErrorText:     "リクエストを完了できませんでした：%v",
+		RegeneratedTitle:     "ペルソナを再生成しました",
+		RegeneratedText:      "%s は %s になり、会話は新しく始まります。",
+		RegenerateErrorTitle: "ペルソナを再生成できませんでした",
+		RegenerateErrorText:  "%s を再生成できませんでした：%v",
+		PanelMissingText:     "この質問の対象パネルのアンカーがキャンバスにありません。質問するには %s という名前のアンカーを追加してください。",
+		InterviewErrorTitle:  "インタビュー未回答",
+		InterviewErrorText:   "%s に質問できませんでした：%v",
+		FollowupErrorTitle:   "フォローアップ未回答",
+		FollowupErrorText:    "%s へのフォローアップに回答できませんでした：%v",
+		DialogueErrorTitle:   "対話が中断されました",
+		DialogueErrorText:    "%s と %s の対話が中断されました：%v",
+	},
+}
+
+// Languages returns the supported language codes, sorted
+func Languages() []string {
+	codes := make([]string, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Normalize turns a language setting such as "fr-FR", "French" or "Deutsch" into
+// a language code. Empty and "auto" return Auto; anything else is returned lower-cased.
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" || lang == Auto {
+		return Auto
+	}
+	if code, ok := aliases[lang]; ok {
+		return code
+	}
+	if base, _, ok := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-"); ok {
+		lang = base
+	}
+	return lang
+}
+
+// Supported reports whether lang (after Normalize) is Auto or has a catalog
+func Supported(lang string) bool {
+	lang = Normalize(lang)
+	_, ok := catalog[lang]
+	return ok || lang == Auto
+}
+
+// Name returns the English name of the language, e.g. "French"
+func Name(lang string) string {
+	if name, ok := names[Normalize(lang)]; ok {
+		return name
+	}
+	return names[English]
+}
+
+// T returns the message in lang, falling back to English, formatted with args
+func T(lang string, msg Message, args ...interface{}) string {
+	text, ok := catalog[Normalize(lang)][msg]
+	if !ok {
+		text = catalog[English][msg]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Is reports whether text is msg in any supported language, so notes created
+// before a canvas's language changed are still recognised
+func Is(text string, msg Message) bool {
+	for _, messages := range catalog {
+		if messages[msg] == text {
+			return true
+		}
+	}
+	return false
+}
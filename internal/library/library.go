@@ -0,0 +1,314 @@
+// Package library keeps personas saved for reuse across canvases, tagged by
+// industry or any other label, one JSON file per persona.
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// DefaultDir is where the library is kept unless configured otherwise
+const DefaultDir = ".state/library"
+
+// Entry is one saved persona
+type Entry struct {
+	ID      string        `json:"id"`
+	Persona types.Persona `json:"persona"`
+	Tags    []string      `json:"tags,omitempty"`
+	// Source records where the persona came from, e.g. a canvas or an imported file
+	Source  string    `json:"source,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// HasAnyTag reports whether the entry has one of tags; no tags match every entry
+func (e *Entry) HasAnyTag(tags []string) bool {
+	tags = NormalizeTags(tags)
+	if len(tags) == 0 {
+		return true
+	}
+	for _, t := range e.Tags {
+		for _, tag := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Library keeps one JSON file per saved persona under Dir
+type Library struct {
+	Dir string
+
+	// State - owned by this organism
+	mu      sync.Mutex
+	entries map[string]*Entry
+	loaded  bool
+}
+
+// New creates a library rooted at dir
+func New(dir string) *Library {
+	return &Library{Dir: dir, entries: make(map[string]*Entry)}
+}
+
+// normalizeTag lower-cases a tag and collapses its whitespace
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags tidies tags, dropping empty and duplicate ones
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// entryID derives a stable ID from the persona's name and content, so saving the
+// same persona twice updates one entry while a namesake gets its own
+func entryID(p types.Persona) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(p.Name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			slug.WriteRune(r)
+		case slug.Len() > 0 && !strings.HasSuffix(slug.String(), "-"):
+			slug.WriteByte('-')
+		}
+	}
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return strings.TrimSuffix(slug.String(), "-") + "-" + hex.EncodeToString(sum[:])[:6]
+}
+
+// loadLocked reads all entries from disk on first use. Caller must hold l.mu.
+func (l *Library) loadLocked() {
+	if l.loaded {
+		return
+	}
+	l.loaded = true
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[library] Failed to read %s: %v", l.Dir, err)
+		}
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(l.Dir, e.Name()))
+		if err != nil {
+			log.Printf("[library] Failed to read %s: %v", e.Name(), err)
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.ID == "" {
+			log.Printf("[library] Skipping unreadable persona %s: %v", e.Name(), err)
+			continue
+		}
+		l.entries[entry.ID] = &entry
+	}
+}
+
+// saveLocked writes entry to disk atomically. Caller must hold l.mu.
+func (l *Library) saveLocked(entry *Entry) error {
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create library dir: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal persona: %w", err)
+	}
+	p := filepath.Join(l.Dir, entry.ID+".json")
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write persona: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store persona: %w", err)
+	}
+	return nil
+}
+
+// Save adds persona to the library with tags, merging the tags if the same
+// persona is already saved, and returns its entry
+func (l *Library) Save(persona types.Persona, tags []string, source string) (Entry, error) {
+	if persona.Name == "" {
+		return Entry{}, fmt.Errorf("persona has no name")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadLocked()
+	id := entryID(persona)
+	entry := &Entry{ID: id, Persona: persona, Tags: NormalizeTags(tags), Source: source, SavedAt: time.Now()}
+	if old, ok := l.entries[id]; ok {
+		entry.Tags = NormalizeTags(append(old.Tags, entry.Tags...))
+		if source == "" {
+			entry.Source = old.Source
+		}
+	}
+	if err := l.saveLocked(entry); err != nil {
+		return Entry{}, err
+	}
+	l.entries[id] = entry
+	log.Printf("[library] Saved persona %s as %s (tags: %s)", persona.Name, id, strings.Join(entry.Tags, ", "))
+	return *entry, nil
+}
+
+// Get returns the entry saved as id
+func (l *Library) Get(id string) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadLocked()
+	entry, ok := l.entries[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// List returns the entries with any of tags, or every entry if there are none, sorted by name
+func (l *Library) List(tags []string) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadLocked()
+	var out []Entry
+	for _, entry := range l.entries {
+		if entry.HasAnyTag(tags) {
+			out = append(out, *entry)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Persona.Name != out[j].Persona.Name {
+			return out[i].Persona.Name < out[j].Persona.Name
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Select returns the entries named by ids, in that order, or those with any of tags if ids is empty
+func (l *Library) Select(tags, ids []string) ([]Entry, error) {
+	if len(ids) == 0 {
+		return l.List(tags), nil
+	}
+	out := make([]Entry, 0, len(ids))
+	for _, id := range ids {
+		entry, ok := l.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("no persona %q in the library", id)
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// Delete removes the entry saved as id
+func (l *Library) Delete(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadLocked()
+	if _, ok := l.entries[id]; !ok {
+		return fmt.Errorf("no persona %q in the library", id)
+	}
+	if err := os.Remove(filepath.Join(l.Dir, id+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete persona: %w", err)
+	}
+	delete(l.entries, id)
+	log.Printf("[library] Deleted persona %s", id)
+	return nil
+}
+
+// Parse reads personas supplied as JSON: an export of the library (an array of
+// entries), an array of personas, or a single persona. Each persona must have
+// at least the fields a persona note needs.
+func Parse(data []byte) ([]Entry, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var one json.RawMessage
+		if err := json.Unmarshal(data, &one); err != nil {
+			return nil, fmt.Errorf("not valid JSON: %w", err)
+		}
+		raw = []json.RawMessage{one}
+	}
+	entries := make([]Entry, 0, len(raw))
+	for i, item := range raw {
+		var probe struct {
+			Persona *json.RawMessage `json:"persona"`
+		}
+		if err := json.Unmarshal(item, &probe); err != nil {
+			return nil, fmt.Errorf("persona %d: not a JSON object: %w", i+1, err)
+		}
+		var entry Entry
+		if probe.Persona != nil {
+			if err := json.Unmarshal(item, &entry); err != nil {
+				return nil, fmt.Errorf("persona %d: %w", i+1, err)
+			}
+		} else if err := json.Unmarshal(item, &entry.Persona); err != nil {
+			return nil, fmt.Errorf("persona %d: %w", i+1, err)
+		}
+		if missing := atom.MissingPersonaFields(entry.Persona); len(missing) > 0 {
+			return nil, fmt.Errorf("persona %d: missing %s", i+1, strings.Join(missing, ", "))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Import saves entries parsed by Parse, adding tags to each, and returns the saved entries
+func (l *Library) Import(entries []Entry, tags []string, source string) ([]Entry, error) {
+	saved := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		from := source
+		if entry.Source != "" {
+			from = entry.Source
+		}
+		e, err := l.Save(entry.Persona, append(append([]string{}, entry.Tags...), tags...), from)
+		if err != nil {
+			return saved, err
+		}
+		saved = append(saved, e)
+	}
+	return saved, nil
+}
+
+// --- Global instance shared by all workflows ---
+var (
+	globalLibrary     *Library
+	globalLibraryOnce sync.Once
+)
+
+// SetGlobalLibrary replaces the process-wide persona library. Call it at startup, before any workflow runs.
+func SetGlobalLibrary(l *Library) {
+	globalLibraryOnce.Do(func() {})
+	globalLibrary = l
+}
+
+// GetGlobalLibrary returns the process-wide persona library, in DefaultDir unless SetGlobalLibrary was called
+func GetGlobalLibrary() *Library {
+	globalLibraryOnce.Do(func() {
+		globalLibrary = New(DefaultDir)
+	})
+	return globalLibrary
+}
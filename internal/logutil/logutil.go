@@ -2,11 +2,26 @@ package logutil
 
 import (
 	"log"
-	"os"
 	"strings"
+	"sync/atomic"
 )
 
-var logLevel = parseLogLevel(os.Getenv("LOG_LEVEL"))
+// logLevel holds the minimum Level logged; it may change on a config reload
+var logLevel atomic.Int32
+
+func init() {
+	logLevel.Store(int32(LevelInfo))
+}
+
+// SetLevel sets the minimum level logged ("debug", "info", "warn" or "error")
+func SetLevel(level string) {
+	logLevel.Store(int32(parseLogLevel(level)))
+}
+
+// enabled reports whether messages at level are logged
+func enabled(level Level) bool {
+	return Level(logLevel.Load()) <= level
+}
 
 type Level int
 
@@ -32,19 +47,19 @@ func parseLogLevel(s string) Level {
 }
 
 func Debugf(format string, v ...interface{}) {
-	if logLevel <= LevelDebug {
+	if enabled(LevelDebug) {
 		log.Printf("[DEBUG] "+format, v...)
 	}
 }
 
 func Infof(format string, v ...interface{}) {
-	if logLevel <= LevelInfo {
+	if enabled(LevelInfo) {
 		log.Printf("[INFO] "+format, v...)
 	}
 }
 
 func Warnf(format string, v ...interface{}) {
-	if logLevel <= LevelWarn {
+	if enabled(LevelWarn) {
 		log.Printf("[WARN] "+format, v...)
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
 )
 
 // RequiredBusinessNoteTitles returns the list of required business note titles
@@ -27,20 +28,20 @@ func RequiredBusinessNoteTitles() []string {
 // MissingNotesHelperColor is the red background color for missing notes feedback
 const MissingNotesHelperColor = "#f44336ff"
 
-// CreateMissingNotesHelper creates a helper note on the canvas listing which required
-// business notes are missing. Returns the helper note ID if created, or empty string on error.
-func CreateMissingNotesHelper(client *canvusapi.Client, missingNotes []string, personasAnchor map[string]interface{}) string {
+// CreateMissingNotesHelper creates a helper note in lang on the canvas listing which
+// required business notes are missing. Returns the helper note ID if created, or empty string on error.
+func CreateMissingNotesHelper(client *canvusapi.Client, missingNotes []string, personasAnchor map[string]interface{}, lang string) string {
 	if len(missingNotes) == 0 {
 		return ""
 	}
 
 	// Build the help text
 	var sb strings.Builder
-	sb.WriteString("The following required Business Model Canvas notes are missing:\n\n")
+	sb.WriteString(i18n.T(lang, i18n.MissingNotesIntro) + "\n\n")
 	for i, note := range missingNotes {
 		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, note))
 	}
-	sb.WriteString("\nPlease add these notes to the canvas with the exact titles listed above, then try again.")
+	sb.WriteString("\n" + i18n.T(lang, i18n.MissingNotesOutro))
 
 	// Determine position for the helper note
 	// If we have a personas anchor, place it nearby; otherwise use default position
@@ -72,7 +73,7 @@ func CreateMissingNotesHelper(client *canvusapi.Client, missingNotes []string, p
 	}
 
 	noteMeta := map[string]interface{}{
-		"title":            "Missing Required Notes",
+		"title":            i18n.T(lang, i18n.MissingNotesTitle),
 		"text":             sb.String(),
 		"location":         map[string]interface{}{"x": x, "y": y},
 		"size":             map[string]interface{}{"width": width, "height": height},
@@ -90,9 +91,34 @@ func CreateMissingNotesHelper(client *canvusapi.Client, missingNotes []string, p
 	return helperID
 }
 
+// BusinessNotesText returns the text of the business notes found in widgets,
+// without their titles, e.g. for detecting the language they are written in
+func BusinessNotesText(widgets []map[string]interface{}) string {
+	required := make(map[string]bool)
+	for _, t := range RequiredBusinessNoteTitles() {
+		required[t] = true
+	}
+	var texts []string
+	for _, w := range widgets {
+		typeStr, _ := w["widget_type"].(string)
+		title, _ := w["title"].(string)
+		if typeStr == "Note" && required[strings.ToUpper(strings.TrimSpace(title))] {
+			text, _ := w["text"].(string)
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
 // ExtractBusinessContext extracts business context and personas anchor from widgets
 // Returns: businessContext string, personasAnchor widget, missing note titles, error
 func ExtractBusinessContext(widgets []map[string]interface{}) (string, map[string]interface{}, []string, error) {
+	return ExtractPanelContext(widgets, "Personas")
+}
+
+// ExtractPanelContext is ExtractBusinessContext for the persona panel whose notes
+// go in the anchor named anchorName, e.g. "Internal Personas"
+func ExtractPanelContext(widgets []map[string]interface{}, anchorName string) (string, map[string]interface{}, []string, error) {
 	requiredTitles := RequiredBusinessNoteTitles()
 	titleMap := make(map[string]bool)
 	for _, t := range requiredTitles {
@@ -117,8 +143,8 @@ func ExtractBusinessContext(widgets []map[string]interface{}) (string, map[strin
 		}
 
 		if typeStr == "Anchor" {
-			anchorName, _ := w["anchor_name"].(string)
-			if strings.EqualFold(strings.TrimSpace(anchorName), "Personas") {
+			name, _ := w["anchor_name"].(string)
+			if strings.EqualFold(strings.TrimSpace(name), anchorName) {
 				personasAnchor = w
 			}
 		}
@@ -138,7 +164,7 @@ func ExtractBusinessContext(widgets []map[string]interface{}) (string, map[strin
 	}
 
 	if personasAnchor == nil {
-		return "", nil, nil, fmt.Errorf("Personas anchor not found")
+		return "", nil, nil, fmt.Errorf("%s anchor not found", anchorName)
 	}
 
 	// Build business context string
@@ -0,0 +1,55 @@
+package molecule
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/i18n"
+)
+
+// PersonaFieldsHelperColor is the amber background color for incomplete persona note feedback
+const PersonaFieldsHelperColor = "#ffc107ff"
+
+// CreatePersonaFieldsHelper creates a helper note in lang just below a persona note
+// listing the fields that could not be read from it. Returns the helper note ID if
+// created, or empty string on error.
+func CreatePersonaFieldsHelper(client *canvusapi.Client, personaNote map[string]interface{}, missing []string, lang string) string {
+	if len(missing) == 0 {
+		return ""
+	}
+	title, _ := personaNote["title"].(string)
+
+	var x, y, width, height float64 = 0, 0, 400, 200
+	if loc, ok := atom.SafeMap(personaNote, "location"); ok {
+		x, _ = atom.SafeFloat64(loc, "x")
+		y, _ = atom.SafeFloat64(loc, "y")
+	}
+	if size, ok := atom.SafeMap(personaNote, "size"); ok {
+		if w, ok := atom.SafeFloat64(size, "width"); ok {
+			width = w
+		}
+		if h, ok := atom.SafeFloat64(size, "height"); ok {
+			y += h + 20 // just below the persona note
+		}
+	}
+
+	noteMeta := map[string]interface{}{
+		"title":            i18n.T(lang, i18n.PersonaFieldsTitle),
+		"text":             i18n.T(lang, i18n.PersonaFieldsText, title, strings.Join(missing, ", ")),
+		"location":         map[string]interface{}{"x": x, "y": y},
+		"size":             map[string]interface{}{"width": width, "height": height},
+		"background_color": PersonaFieldsHelperColor,
+	}
+
+	helperNote, err := client.CreateNote(noteMeta)
+	if err != nil {
+		log.Printf("[CreatePersonaFieldsHelper] Failed to create helper note for %s: %v", title, err)
+		return ""
+	}
+
+	helperID, _ := helperNote["id"].(string)
+	log.Printf("[CreatePersonaFieldsHelper] Created helper (ID: %s) for %s, missing %v", helperID, title, missing)
+	return helperID
+}
@@ -0,0 +1,165 @@
+// Package personastore keeps the structured form of each persona note on disk,
+// so a persona survives a facilitator garbling or deleting lines of its note:
+// the fields that can still be read from the note win, the rest come from here.
+package personastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// DefaultDir is where personas are stored unless configured otherwise
+const DefaultDir = ".state/personas"
+
+// Entry is the stored persona behind one persona note
+type Entry struct {
+	NoteID    string        `json:"note_id"`
+	Canvas    string        `json:"canvas"`
+	Persona   types.Persona `json:"persona"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// Store keeps one JSON file per persona note under Dir
+type Store struct {
+	Dir string
+
+	// State - owned by this organism
+	mu      sync.Mutex
+	entries map[string]*Entry
+	loaded  bool
+}
+
+// New creates a store rooted at dir
+func New(dir string) *Store {
+	return &Store{Dir: dir, entries: make(map[string]*Entry)}
+}
+
+// loadLocked reads all entries from disk on first use. Caller must hold s.mu.
+func (s *Store) loadLocked() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[personastore] Failed to read %s: %v", s.Dir, err)
+		}
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.Dir, e.Name()))
+		if err != nil {
+			log.Printf("[personastore] Failed to read %s: %v", e.Name(), err)
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.NoteID == "" {
+			log.Printf("[personastore] Skipping unreadable persona %s: %v", e.Name(), err)
+			continue
+		}
+		s.entries[entry.NoteID] = &entry
+	}
+}
+
+// saveLocked writes entry to disk atomically. Caller must hold s.mu.
+func (s *Store) saveLocked(entry *Entry) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create persona dir: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal persona: %w", err)
+	}
+	p := filepath.Join(s.Dir, entry.NoteID+".json")
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write persona: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store persona: %w", err)
+	}
+	return nil
+}
+
+// Save stores persona as the structured form of the note noteID on canvas.
+// Saving the persona already stored is a no-op.
+func (s *Store) Save(canvas, noteID string, persona types.Persona) {
+	if noteID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	if old, ok := s.entries[noteID]; ok && old.Canvas == canvas && reflect.DeepEqual(old.Persona, persona) {
+		return
+	}
+	entry := &Entry{NoteID: noteID, Canvas: canvas, Persona: persona, UpdatedAt: time.Now()}
+	s.entries[noteID] = entry
+	if err := s.saveLocked(entry); err != nil {
+		log.Printf("[personastore] %v", err)
+	}
+}
+
+// Get returns the persona stored for the note noteID
+func (s *Store) Get(noteID string) (types.Persona, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	entry, ok := s.entries[noteID]
+	if !ok {
+		return types.Persona{}, false
+	}
+	return entry.Persona, true
+}
+
+// FindByName returns the most recently stored persona named name on canvas, for
+// when its note is no longer on the canvas
+func (s *Store) FindByName(canvas, name string) (types.Persona, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	var found *Entry
+	for _, entry := range s.entries {
+		if entry.Canvas == canvas && strings.EqualFold(entry.Persona.Name, name) && (found == nil || entry.UpdatedAt.After(found.UpdatedAt)) {
+			found = entry
+		}
+	}
+	if found == nil {
+		return types.Persona{}, false
+	}
+	return found.Persona, true
+}
+
+// --- Global instance shared by all workflows ---
+var (
+	globalStore     *Store
+	globalStoreOnce sync.Once
+)
+
+// SetGlobalStore replaces the process-wide persona store. Call it at startup, before any workflow runs.
+func SetGlobalStore(s *Store) {
+	globalStoreOnce.Do(func() {})
+	globalStore = s
+}
+
+// GetGlobalStore returns the process-wide persona store, in DefaultDir unless SetGlobalStore was called
+func GetGlobalStore() *Store {
+	globalStoreOnce.Do(func() {
+		globalStore = New(DefaultDir)
+	})
+	return globalStore
+}
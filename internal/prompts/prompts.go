@@ -0,0 +1,254 @@
+// Package prompts holds the text/template prompts sent to Gemini and DALL-E.
+// The built-in set is embedded in the binary; a directory of .tmpl files can
+// override any of them, globally or for one canvas.
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// Template names, each stored as <name>.tmpl
+const (
+	Personas         = "personas"          // customer persona generation, PersonasData
+	PersonasInternal = "personas_internal" // internal stakeholder generation, PersonasData
+	PersonasPartner  = "personas_partner"  // partner persona generation, PersonasData
+	PersonasInvestor = "personas_investor" // investor persona generation, PersonasData
+	PersonasRepair   = "personas_repair"   // re-ask after invalid personas, PersonasRepairData
+	PersonasReplace  = "personas_replace"  // new personas in place of some of a set, PersonasReplaceData
+	System           = "system"            // persona chat setup, SystemData
+	MetaAnswer       = "meta_answer"       // reaction to the other answers, MetaAnswerData
+	Dialogue         = "dialogue"          // one turn of a conversation between two personas, DialogueData
+	Succinct         = "succinct"          // shorter rephrasing, SuccinctData
+	Image            = "image"             // persona headshot, ImageData
+)
+
+// Names lists every template a Set provides
+var Names = []string{Personas, PersonasInternal, PersonasPartner, PersonasInvestor, PersonasRepair, PersonasReplace, System, MetaAnswer, Dialogue, Succinct, Image}
+
+// VersionFile names the optional file in a prompts directory holding its version label
+const VersionFile = "VERSION"
+
+// PersonasData is the data for the personas template
+type PersonasData struct {
+	BusinessContext string
+	Count           int
+	Rules           []string // diversity constraints the set must meet
+	Language        string   // e.g. "French"; empty for English or when undetected
+}
+
+// PersonasRepairData is the data for the personas_repair template
+type PersonasRepairData struct {
+	Count    int
+	Problems []string // what was wrong with the previous response
+}
+
+// PersonasReplaceData is the data for the personas_replace template
+type PersonasReplaceData struct {
+	BusinessContext string
+	Panel           types.Panel          // the panel the set belongs to
+	Keep            []types.Persona      // the personas staying in the set
+	Replace         []PersonaReplacement // one per persona to write, in order
+	Rules           []string             // diversity constraints of the whole set
+	Problems        []string             // what is wrong with the set now
+	Language        string               // e.g. "French"; empty for English or when undetected
+}
+
+// PersonaReplacement describes one persona to write in place of another
+type PersonaReplacement struct {
+	Number int      // position in the response, from 1
+	Needs  []string // requirements the new persona must meet, e.g. "sector: Retail"
+}
+
+// SystemData is the data for the system template
+type SystemData struct {
+	Persona         types.Persona
+	BusinessContext string
+	Language        string // e.g. "French"; empty for English or when undetected
+}
+
+// MetaAnswerData is the data for the meta_answer template
+type MetaAnswerData struct {
+	Name   string
+	Others string // the other personas' answers, separated by "; "
+}
+
+// DialogueData is the data for the dialogue template
+type DialogueData struct {
+	Other    string // the persona being talked to
+	Question string // the question the conversation is about
+	Said     string // what the other persona has just said
+}
+
+// SuccinctData is the data for the succinct template
+type SuccinctData struct {
+	Limit int
+}
+
+// ImageData is the data for the image template
+type ImageData struct {
+	Persona types.Persona
+}
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// Set is a complete, parsed set of prompt templates
+type Set struct {
+	// Version identifies the prompt wording: the VERSION label of the most specific
+	// directory that has one ("builtin" or "custom" otherwise), "@" a hash of the templates
+	Version string
+
+	texts     map[string]string
+	templates *template.Template
+}
+
+// defaultSet is parsed once from the embedded templates
+var defaultSet = mustBuiltin()
+
+// Default returns the built-in prompt set
+func Default() *Set {
+	return defaultSet
+}
+
+// mustBuiltin parses the embedded templates
+func mustBuiltin() *Set {
+	texts := make(map[string]string, len(Names))
+	for _, name := range Names {
+		data, err := builtin.ReadFile("templates/" + name + ".tmpl")
+		if err != nil {
+			panic(fmt.Sprintf("prompts: missing built-in template %s: %v", name, err))
+		}
+		texts[name] = string(data)
+	}
+	s, err := newSet("builtin", texts)
+	if err != nil {
+		panic(fmt.Sprintf("prompts: %v", err))
+	}
+	return s
+}
+
+// Overlay returns a copy of s with the templates found in dir replacing its own.
+// An empty dir returns s. Files other than <name>.tmpl and VERSION are rejected
+// so a misspelt template name does not go unnoticed.
+func (s *Set) Overlay(dir string) (*Set, error) {
+	if dir == "" {
+		return s, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompts directory: %w", err)
+	}
+	texts := make(map[string]string, len(s.texts))
+	for name, text := range s.texts {
+		texts[name] = text
+	}
+	label := strings.SplitN(s.Version, "@", 2)[0]
+	if label == "builtin" {
+		label = "custom"
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if e.Name() == VersionFile {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if v := strings.TrimSpace(string(data)); v != "" {
+				label = v
+			}
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ".tmpl")
+		if _, known := s.texts[name]; !known || name == e.Name() {
+			return nil, fmt.Errorf("%s: unknown prompt template (expected one of %s.tmpl)", path, strings.Join(Names, ".tmpl, "))
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		texts[name] = string(data)
+	}
+	set, err := newSet(label, texts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return set, nil
+}
+
+// newSet parses texts and derives the set's version from label and their content
+func newSet(label string, texts map[string]string) (*Set, error) {
+	root := template.New("prompts").Option("missingkey=error")
+	h := sha256.New()
+	for _, name := range Names {
+		text, ok := texts[name]
+		if !ok {
+			return nil, fmt.Errorf("missing prompt template %s", name)
+		}
+		if _, err := root.New(name).Parse(text); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", name, text)
+	}
+	return &Set{
+		Version:   label + "@" + hex.EncodeToString(h.Sum(nil))[:8],
+		texts:     texts,
+		templates: root,
+	}, nil
+}
+
+// Render executes the named template with data, trimming surrounding whitespace
+func (s *Set) Render(name string, data interface{}) (string, error) {
+	tmpl := s.templates.Lookup(name)
+	if tmpl == nil {
+		return "", fmt.Errorf("unknown prompt template %s", name)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("prompt %s (%s): %w", name, s.Version, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Sets holds the global prompt set and the per-canvas sets layered on top of it
+type Sets struct {
+	Global   *Set
+	ByCanvas map[string]*Set // keyed by registered canvas name
+}
+
+// Load builds the global set from dir over the built-in templates, and a set for
+// each canvas in canvasDirs over the global one
+func Load(dir string, canvasDirs map[string]string) (*Sets, error) {
+	global, err := Default().Overlay(dir)
+	if err != nil {
+		return nil, err
+	}
+	sets := &Sets{Global: global, ByCanvas: make(map[string]*Set, len(canvasDirs))}
+	for canvas, cdir := range canvasDirs {
+		set, err := global.Overlay(cdir)
+		if err != nil {
+			return nil, fmt.Errorf("canvas %s: %w", canvas, err)
+		}
+		sets.ByCanvas[canvas] = set
+	}
+	return sets, nil
+}
+
+// For returns the set used on the named canvas
+func (s *Sets) For(canvas string) *Set {
+	if set, ok := s.ByCanvas[canvas]; ok {
+		return set
+	}
+	return s.Global
+}
@@ -0,0 +1,317 @@
+// Package queue runs question workflows on a bounded pool of workers.
+// Jobs are FIFO within a canvas and canvases take turns, so one busy room
+// cannot starve the others.
+package queue
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// DefaultConcurrency is the number of workflows run at once when WORKFLOW_CONCURRENCY is unset
+const DefaultConcurrency = 2
+
+// Job is a unit of work submitted to the queue
+type Job struct {
+	// ID identifies the job for Position and Cancel (usually the Qnote ID)
+	ID string
+	// Canvas groups jobs for round-robin fairness
+	Canvas string
+	// Run performs the work
+	Run func(ctx context.Context)
+	// OnPosition is called with the job's 1-based queue position whenever it
+	// changes while the job is waiting (optional)
+	OnPosition func(position int)
+
+	done     chan struct{}
+	position int
+}
+
+// Queue is a bounded worker pool with per-canvas FIFO lanes
+type Queue struct {
+	concurrency int
+
+	// State - owned by this organism
+	mu      sync.Mutex
+	cond    *sync.Cond
+	lanes   map[string][]*Job
+	canvas  []string // round-robin order of canvases with lanes
+	next    int      // index into canvas of the lane to serve next
+	running map[string]*Job
+	stopped bool
+}
+
+// New creates a queue that runs at most concurrency jobs at once
+func New(concurrency int) *Queue {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	q := &Queue{
+		concurrency: concurrency,
+		lanes:       make(map[string][]*Job),
+		running:     make(map[string]*Job),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Start launches the workers. They stop when ctx is cancelled; wg (if not nil)
+// tracks them for graceful shutdown.
+func (q *Queue) Start(ctx context.Context, wg *sync.WaitGroup) {
+	log.Printf("[queue] Starting %d workflow workers", q.concurrency)
+	for i := 0; i < q.concurrency; i++ {
+		if wg != nil {
+			wg.Add(1)
+		}
+		go func(worker int) {
+			if wg != nil {
+				defer wg.Done()
+			}
+			q.work(ctx, worker)
+		}(i + 1)
+	}
+	go func() {
+		<-ctx.Done()
+		q.stop()
+	}()
+}
+
+// Submit enqueues a job and returns a channel closed when it finishes (or is
+// dropped). The returned position is 0 if a worker is free, otherwise the
+// job's 1-based place in line.
+func (q *Queue) Submit(job *Job) (<-chan struct{}, int) {
+	job.done = make(chan struct{})
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		close(job.done)
+		return job.done, 0
+	}
+	if _, ok := q.lanes[job.Canvas]; !ok {
+		q.canvas = append(q.canvas, job.Canvas)
+	}
+	q.lanes[job.Canvas] = append(q.lanes[job.Canvas], job)
+	position := q.positionLocked(job.ID)
+	updates := q.positionUpdatesLocked()
+	q.cond.Signal()
+	q.mu.Unlock()
+
+	log.Printf("[queue] Submitted job %s (canvas=%s, position=%d)", job.ID, job.Canvas, position)
+	notify(updates)
+	return job.done, position
+}
+
+// Position returns a waiting job's 1-based position, or 0 if it is running or unknown
+func (q *Queue) Position(jobID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.positionLocked(jobID)
+}
+
+// Len returns the number of jobs waiting for a free worker
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waitingLocked())
+}
+
+// Running returns the number of jobs currently running
+func (q *Queue) Running() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.running)
+}
+
+// Cancel removes a waiting job. It returns false if the job is running or unknown.
+func (q *Queue) Cancel(jobID string) bool {
+	q.mu.Lock()
+	for c, lane := range q.lanes {
+		for i, job := range lane {
+			if job.ID != jobID {
+				continue
+			}
+			q.lanes[c] = append(lane[:i:i], lane[i+1:]...)
+			updates := q.positionUpdatesLocked()
+			q.mu.Unlock()
+			close(job.done)
+			log.Printf("[queue] Cancelled waiting job %s", jobID)
+			notify(updates)
+			return true
+		}
+	}
+	q.mu.Unlock()
+	return false
+}
+
+func (q *Queue) work(ctx context.Context, worker int) {
+	for {
+		q.mu.Lock()
+		var job *Job
+		for {
+			if q.stopped {
+				q.mu.Unlock()
+				return
+			}
+			if job = q.popLocked(); job != nil {
+				break
+			}
+			q.cond.Wait()
+		}
+		q.running[job.ID] = job
+		updates := q.positionUpdatesLocked()
+		q.mu.Unlock()
+
+		notify(updates)
+		log.Printf("[queue] Worker %d running job %s (canvas=%s)", worker, job.ID, job.Canvas)
+		q.run(ctx, job)
+
+		q.mu.Lock()
+		delete(q.running, job.ID)
+		q.cond.Signal()
+		q.mu.Unlock()
+		close(job.done)
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[error] queue job %s panic recovered: %v\n%s", job.ID, r, debug.Stack())
+		}
+	}()
+	job.Run(ctx)
+}
+
+// stop wakes all workers so they exit, and releases anyone waiting on a dropped job
+func (q *Queue) stop() {
+	q.mu.Lock()
+	q.stopped = true
+	var dropped []*Job
+	for _, lane := range q.lanes {
+		dropped = append(dropped, lane...)
+	}
+	q.lanes = make(map[string][]*Job)
+	q.canvas = nil
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	for _, job := range dropped {
+		close(job.done)
+	}
+	if len(dropped) > 0 {
+		log.Printf("[queue] Dropped %d waiting jobs on shutdown", len(dropped))
+	}
+}
+
+// popLocked removes the next job in round-robin order, or returns nil if none can start
+func (q *Queue) popLocked() *Job {
+	if len(q.running) >= q.concurrency || len(q.canvas) == 0 {
+		return nil
+	}
+	for i := 0; i < len(q.canvas); i++ {
+		idx := (q.next + i) % len(q.canvas)
+		c := q.canvas[idx]
+		lane := q.lanes[c]
+		if len(lane) == 0 {
+			continue
+		}
+		job := lane[0]
+		q.lanes[c] = lane[1:]
+		q.next = (idx + 1) % len(q.canvas)
+		q.pruneLocked()
+		return job
+	}
+	return nil
+}
+
+// pruneLocked drops empty lanes from the round-robin order
+func (q *Queue) pruneLocked() {
+	kept := q.canvas[:0]
+	nextCanvas := ""
+	if len(q.canvas) > 0 {
+		nextCanvas = q.canvas[q.next%len(q.canvas)]
+	}
+	q.next = 0
+	for _, c := range q.canvas {
+		if len(q.lanes[c]) == 0 {
+			delete(q.lanes, c)
+			continue
+		}
+		if c == nextCanvas {
+			q.next = len(kept)
+		}
+		kept = append(kept, c)
+	}
+	q.canvas = kept
+}
+
+// orderLocked returns waiting jobs in the order they will be dispatched
+func (q *Queue) orderLocked() []*Job {
+	var order []*Job
+	depth := 0
+	for {
+		added := false
+		for i := 0; i < len(q.canvas); i++ {
+			lane := q.lanes[q.canvas[(q.next+i)%len(q.canvas)]]
+			if depth < len(lane) {
+				order = append(order, lane[depth])
+				added = true
+			}
+		}
+		if !added {
+			return order
+		}
+		depth++
+	}
+}
+
+// waitingLocked returns the dispatch order minus the jobs that free workers are about to pick up
+func (q *Queue) waitingLocked() []*Job {
+	order := q.orderLocked()
+	free := q.concurrency - len(q.running)
+	if free <= 0 {
+		return order
+	}
+	if free >= len(order) {
+		return nil
+	}
+	return order[free:]
+}
+
+func (q *Queue) positionLocked(jobID string) int {
+	for i, job := range q.waitingLocked() {
+		if job.ID == jobID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// positionUpdate is a pending OnPosition callback
+type positionUpdate struct {
+	fn       func(int)
+	position int
+}
+
+// positionUpdatesLocked records new positions and returns the callbacks to run once unlocked
+func (q *Queue) positionUpdatesLocked() []positionUpdate {
+	var updates []positionUpdate
+	for i, job := range q.waitingLocked() {
+		pos := i + 1
+		if job.position == pos {
+			continue
+		}
+		job.position = pos
+		if job.OnPosition != nil {
+			updates = append(updates, positionUpdate{fn: job.OnPosition, position: pos})
+		}
+	}
+	return updates
+}
+
+func notify(updates []positionUpdate) {
+	for _, u := range updates {
+		go u.fn(u.position)
+	}
+}
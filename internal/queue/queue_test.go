@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// job returns a job that appends its ID to ran when it runs
+func job(id, canvas string, mu *sync.Mutex, ran *[]string) *Job {
+	return &Job{ID: id, Canvas: canvas, Run: func(context.Context) {
+		mu.Lock()
+		*ran = append(*ran, id)
+		mu.Unlock()
+	}}
+}
+
+// waitDone fails the test if done is not closed in time
+func waitDone(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not finish", what)
+	}
+}
+
+func TestPositionsTakeCanvasesInTurn(t *testing.T) {
+	q := New(1)
+	var mu sync.Mutex
+	var ran []string
+	for _, j := range []*Job{job("a1", "A", &mu, &ran), job("a2", "A", &mu, &ran), job("a3", "A", &mu, &ran), job("b1", "B", &mu, &ran)} {
+		q.Submit(j)
+	}
+	// a1 goes to the free worker; b1 is served before the rest of canvas A
+	for id, want := range map[string]int{"a1": 0, "b1": 1, "a2": 2, "a3": 3, "unknown": 0} {
+		if got := q.Position(id); got != want {
+			t.Errorf("Position(%s) = %d, want %d", id, got, want)
+		}
+	}
+	if got := q.Len(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+}
+
+func TestRunsCanvasesRoundRobin(t *testing.T) {
+	q := New(1)
+	var mu sync.Mutex
+	var ran []string
+	var last <-chan struct{}
+	for _, j := range []*Job{
+		job("a1", "A", &mu, &ran), job("a2", "A", &mu, &ran), job("a3", "A", &mu, &ran),
+		job("b1", "B", &mu, &ran), job("b2", "B", &mu, &ran),
+		job("c1", "C", &mu, &ran),
+	} {
+		last, _ = q.Submit(j)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx, nil)
+	waitDone(t, last, "the last job")
+
+	want := []string{"a1", "b1", "c1", "a2", "b2", "a3"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != len(want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Fatalf("ran %v, want %v", ran, want)
+		}
+	}
+}
+
+func TestRunsAtMostConcurrencyJobs(t *testing.T) {
+	const concurrency, jobs = 2, 5
+	q := New(concurrency)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx, nil)
+
+	release := make(chan struct{})
+	var running, peak int32
+	var dones []<-chan struct{}
+	for i := 0; i < jobs; i++ {
+		done, _ := q.Submit(&Job{ID: string(rune('a' + i)), Canvas: "A", Run: func(context.Context) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+		}})
+		dones = append(dones, done)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Running() < concurrency && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := q.Running(); got != concurrency {
+		t.Fatalf("Running() = %d, want %d", got, concurrency)
+	}
+	if got := q.Len(); got != jobs-concurrency {
+		t.Errorf("Len() = %d, want %d", got, jobs-concurrency)
+	}
+	close(release)
+	for _, done := range dones {
+		waitDone(t, done, "a job")
+	}
+	if p := atomic.LoadInt32(&peak); p > concurrency {
+		t.Errorf("%d jobs ran at once, want at most %d", p, concurrency)
+	}
+}
+
+func TestCancelRemovesWaitingJob(t *testing.T) {
+	q := New(1)
+	var mu sync.Mutex
+	var ran []string
+	q.Submit(job("a", "A", &mu, &ran))
+	bDone, _ := q.Submit(job("b", "A", &mu, &ran))
+	moved := make(chan int, 2)
+	c := job("c", "A", &mu, &ran)
+	c.OnPosition = func(position int) { moved <- position }
+	q.Submit(c)
+	if got := <-moved; got != 2 {
+		t.Fatalf("c told position %d on submit, want 2", got)
+	}
+
+	if !q.Cancel("b") {
+		t.Fatal("Cancel(b) = false for a waiting job")
+	}
+	waitDone(t, bDone, "the cancelled job")
+	if got := q.Position("c"); got != 1 {
+		t.Errorf("Position(c) = %d after cancelling b, want 1", got)
+	}
+	select {
+	case got := <-moved:
+		if got != 1 {
+			t.Errorf("c told position %d after cancelling b, want 1", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("c was not told its new position")
+	}
+	if q.Cancel("b") || q.Cancel("unknown") {
+		t.Error("Cancel succeeded for a job that is not waiting")
+	}
+}
+
+func TestShutdownDropsWaitingJobs(t *testing.T) {
+	q := New(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	q.Start(ctx, &wg)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	runningDone, _ := q.Submit(&Job{ID: "running", Canvas: "A", Run: func(context.Context) {
+		close(started)
+		<-release
+	}})
+	<-started
+	var ran atomic.Bool
+	waitingDone, position := q.Submit(&Job{ID: "waiting", Canvas: "A", Run: func(context.Context) { ran.Store(true) }})
+	if position != 1 {
+		t.Fatalf("Submit position = %d behind a running job, want 1", position)
+	}
+
+	cancel()
+	waitDone(t, waitingDone, "the dropped job")
+	close(release)
+	waitDone(t, runningDone, "the running job")
+	wg.Wait()
+	if ran.Load() {
+		t.Error("a job waiting at shutdown ran")
+	}
+	done, position := q.Submit(&Job{ID: "late", Canvas: "A", Run: func(context.Context) { ran.Store(true) }})
+	waitDone(t, done, "a job submitted after shutdown")
+	if position != 0 || ran.Load() {
+		t.Errorf("job submitted after shutdown got position %d, ran=%v; want 0, false", position, ran.Load())
+	}
+}
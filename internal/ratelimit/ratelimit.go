@@ -0,0 +1,92 @@
+// Package ratelimit provides token-bucket rate limiting for outbound API calls,
+// one bucket per provider (e.g. "gemini", "openai").
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// TokenBucket allows bursts of up to Burst requests and refills at Rate requests per second
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a bucket allowing perMinute requests per minute with the given burst
+func NewTokenBucket(perMinute, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   float64(perMinute) / 60.0,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done. A nil bucket never blocks.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+var (
+	buckets   = make(map[string]*TokenBucket)
+	bucketsMu sync.Mutex
+)
+
+// Configure limits a provider to perMinute requests per minute, allowing bursts
+// of burst requests (a quarter of the limit if 0). A perMinute of 0 removes the limit.
+func Configure(provider string, perMinute, burst int) {
+	bucketsMu.Lock()
+	defer bucketsMu.Unlock()
+	if perMinute <= 0 {
+		delete(buckets, provider)
+		return
+	}
+	if burst <= 0 {
+		burst = perMinute / 4
+	}
+	b := NewTokenBucket(perMinute, burst)
+	buckets[provider] = b
+	log.Printf("[ratelimit] %s limited to %d requests/minute (burst %d)", provider, perMinute, int(b.burst))
+}
+
+// ForProvider returns the shared bucket for a provider, or nil (unlimited) if
+// Configure has not set a limit for it
+func ForProvider(provider string) *TokenBucket {
+	bucketsMu.Lock()
+	defer bucketsMu.Unlock()
+	return buckets[provider]
+}
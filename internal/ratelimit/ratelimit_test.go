@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsBurst(t *testing.T) {
+	b := NewTokenBucket(60, 3) // one request a second after the burst
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("Wait %d within the burst: %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("burst of 3 took %v, want no wait", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait past the burst = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	b := NewTokenBucket(600, 1) // a request every 100ms
+	ctx := context.Background()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	start := time.Now()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("second Wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("second Wait took %v, want about 100ms", elapsed)
+	}
+}
+
+func TestNilBucketNeverBlocks(t *testing.T) {
+	var b *TokenBucket
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); err != nil {
+		t.Errorf("nil bucket Wait = %v, want nil", err)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	const provider = "test-provider"
+	defer Configure(provider, 0, 0)
+
+	if ForProvider(provider) != nil {
+		t.Fatal("ForProvider returned a bucket before Configure")
+	}
+	tests := []struct {
+		name      string
+		perMinute int
+		burst     int
+		wantBurst float64
+	}{
+		{"explicit burst", 120, 10, 10},
+		{"burst defaults to a quarter", 120, 0, 30},
+		{"burst is at least one", 3, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(provider, tt.perMinute, tt.burst)
+			b := ForProvider(provider)
+			if b == nil {
+				t.Fatal("ForProvider = nil after Configure")
+			}
+			if b.burst != tt.wantBurst || b.rate != float64(tt.perMinute)/60 {
+				t.Errorf("bucket burst %v rate %v, want burst %v rate %v", b.burst, b.rate, tt.wantBurst, float64(tt.perMinute)/60)
+			}
+		})
+	}
+	Configure(provider, 0, 0)
+	if ForProvider(provider) != nil {
+		t.Error("ForProvider returned a bucket after Configure with no limit")
+	}
+}
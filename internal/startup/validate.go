@@ -6,39 +6,38 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"time"
 
-	"github.com/jaypaulb/AI-personas/canvusapi"
 	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/config"
 	"github.com/jaypaulb/AI-personas/internal/gemini"
 )
 
-// ValidateAPIKeys checks that all required API keys are valid and functional
-func ValidateAPIKeys(timeout time.Duration) error {
+// ValidateAPIKeys checks that all API keys in cfg are valid and functional
+func ValidateAPIKeys(cfg *config.Config, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// 1. Gemini
-	if err := validateGeminiKey(ctx); err != nil {
+	if err := validateGeminiKey(ctx, cfg); err != nil {
 		return err
 	}
 
 	// 2. OpenAI
-	if err := validateOpenAIKey(ctx); err != nil {
+	if err := validateOpenAIKey(ctx, cfg); err != nil {
 		return err
 	}
 
 	// 3. Canvus (MCS)
-	if err := validateCanvusKey(); err != nil {
+	if err := validateCanvusKey(cfg); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func validateGeminiKey(ctx context.Context) error {
-	geminiKey := os.Getenv("GEMINI_API_KEY")
+func validateGeminiKey(ctx context.Context, cfg *config.Config) error {
+	geminiKey := cfg.Gemini.APIKey
 	log.Printf("[startup] GEMINI_API_KEY: %s", atom.MaskKey(geminiKey))
 
 	gClient, err := gemini.NewClient(ctx)
@@ -52,10 +51,10 @@ func validateGeminiKey(ctx context.Context) error {
 	return nil
 }
 
-func validateOpenAIKey(ctx context.Context) error {
-	openaiKey := os.Getenv("OPENAI_API_KEY")
+func validateOpenAIKey(ctx context.Context, cfg *config.Config) error {
+	openaiKey := cfg.OpenAI.APIKey
 	if openaiKey == "" {
-		return errors.New("OPENAI_API_KEY not set in environment")
+		return errors.New("OPENAI_API_KEY not set in environment or config")
 	}
 
 	openaiReq, _ := http.NewRequestWithContext(ctx, "GET", "https://api.openai.com/v1/models", nil)
@@ -73,16 +72,21 @@ func validateOpenAIKey(ctx context.Context) error {
 	return nil
 }
 
-func validateCanvusKey() error {
-	mcsKey := os.Getenv("CANVUS_API_KEY")
-	client, err := canvusapi.NewClientFromEnv()
+func validateCanvusKey(cfg *config.Config) error {
+	mcsKey := cfg.Canvus.APIKey
+	registry, err := cfg.Canvus.Registry()
 	if err != nil {
 		return fmt.Errorf("MCS API key check failed (key: %s): %w", atom.MaskKey(mcsKey), err)
 	}
-
-	_, err = client.GetCanvasInfo()
+	clients, err := registry.NewClients()
 	if err != nil {
 		return fmt.Errorf("MCS API key check failed (key: %s): %w", atom.MaskKey(mcsKey), err)
 	}
+
+	for _, client := range clients {
+		if _, err := client.GetCanvasInfo(); err != nil {
+			return fmt.Errorf("MCS API key check failed for canvas %s on %s (key: %s): %w", client.Name, client.Server, atom.MaskKey(client.ApiKey), err)
+		}
+	}
 	return nil
 }
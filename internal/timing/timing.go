@@ -1,23 +1,24 @@
 // Package timing provides utilities for measuring and logging operation durations.
-// Logging is conditional on the DEBUG environment variable.
+// Logging is conditional on debug mode, enabled with SetDebug.
 package timing
 
 import (
 	"log"
-	"os"
+	"sync/atomic"
 	"time"
 )
 
-// debugEnabled caches the DEBUG environment variable check at package init time.
-var debugEnabled bool
+// debugEnabled is set from the configuration at startup and on reload.
+var debugEnabled atomic.Bool
 
-func init() {
-	debugEnabled = os.Getenv("DEBUG") == "1"
+// SetDebug enables or disables timing logs.
+func SetDebug(enabled bool) {
+	debugEnabled.Store(enabled)
 }
 
-// IsDebugEnabled returns whether DEBUG=1 is set.
+// IsDebugEnabled returns whether debug mode is enabled.
 func IsDebugEnabled() bool {
-	return debugEnabled
+	return debugEnabled.Load()
 }
 
 // Timer measures elapsed time for an operation.
@@ -72,7 +73,7 @@ func (t *Timer) StopAndLog(success bool) time.Duration {
 // Only logs if DEBUG=1 is set in the environment.
 // Format: [timing] operation=%s duration_ms=%d success=%t
 func LogOperation(name string, duration time.Duration, success bool) {
-	if !debugEnabled {
+	if !debugEnabled.Load() {
 		return
 	}
 	log.Printf("[timing] operation=%s duration_ms=%d success=%t", name, duration.Milliseconds(), success)
@@ -82,7 +83,7 @@ func LogOperation(name string, duration time.Duration, success bool) {
 // Only logs if DEBUG=1 is set in the environment.
 // Format: [timing] operation=%s duration_ms=%d success=%t %s
 func LogOperationWithDetails(name string, duration time.Duration, success bool, details string) {
-	if !debugEnabled {
+	if !debugEnabled.Load() {
 		return
 	}
 	log.Printf("[timing] operation=%s duration_ms=%d success=%t %s", name, duration.Milliseconds(), success, details)
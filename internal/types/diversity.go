@@ -0,0 +1,29 @@
+package types
+
+// Markets accepted by Diversity.Market
+const (
+	MarketB2B   = "b2b"
+	MarketB2C   = "b2c"
+	MarketMixed = "mixed" // at least one B2B and one B2C persona
+)
+
+// Persona attitudes towards the business's offering
+const (
+	AttitudeSceptic    = "sceptic"
+	AttitudeNeutral    = "neutral"
+	AttitudeEnthusiast = "enthusiast"
+)
+
+// Diversity is the set of constraints a generated set of personas must meet.
+// Zero values disable a constraint. For the lists, every persona must take one
+// of the values and together they cover as many of them as there are personas.
+type Diversity struct {
+	DistinctSectors bool     `yaml:"distinct_sectors"` // every persona from a different sector
+	Sectors         []string `yaml:"sectors"`
+	MinAgeSpread    int      `yaml:"min_age_spread"` // years between the youngest and oldest persona
+	Regions         []string `yaml:"regions"`
+	CompanySizes    []string `yaml:"company_sizes"`
+	Market          string   `yaml:"market"` // b2b, b2c or mixed; empty for any
+	MinSceptics     int      `yaml:"min_sceptics"`
+	MinEnthusiasts  int      `yaml:"min_enthusiasts"`
+}
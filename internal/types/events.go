@@ -10,6 +10,13 @@ const (
 	TriggerCreatePersonasNote
 	TriggerQnoteQuestionDetected
 	TriggerConnectorCreated
+	TriggerCancelAIQuestion
+	TriggerWidgetDeleted
+	TriggerPersonaNoteEdited
+	TriggerSavePersonaNote
+	TriggerImportPersonasNote
+	TriggerRegeneratePersona
+	TriggerInterviewQuestion
 )
 
 // WidgetEvent represents a widget event from the Canvus API
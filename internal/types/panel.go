@@ -0,0 +1,24 @@
+package types
+
+// Panel is a group of personas a question can be put to
+type Panel string
+
+// Persona panels. Customers are the personas of the Personas anchor; each other
+// panel has its own anchor and generation prompt.
+const (
+	PanelCustomer Panel = "customer" // potential clients of the business
+	PanelInternal Panel = "internal" // people working for the business, e.g. CFO, support lead
+	PanelPartner  Panel = "partner"  // key partners, e.g. suppliers and resellers
+	PanelInvestor Panel = "investor" // current and prospective investors
+)
+
+// Panels lists every panel, customers first
+var Panels = []Panel{PanelCustomer, PanelInternal, PanelPartner, PanelInvestor}
+
+// EffectivePanel returns the panel of p, customers for personas without one
+func (p Persona) EffectivePanel() Panel {
+	if p.Panel == "" {
+		return PanelCustomer
+	}
+	return p.Panel
+}
@@ -43,7 +43,104 @@ func (g *GoalsString) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("GoalsString: cannot unmarshal %s", string(data))
 }
 
-// Persona represents a customer persona for focus group simulation
+// StringList handles a list as either an array of strings or a single string
+// separated by semicolons, and prints as "a; b; c"
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err == nil {
+		*l = arr
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = ParseStringList(s)
+		return nil
+	}
+	return fmt.Errorf("StringList: cannot unmarshal %s", string(data))
+}
+
+// ParseStringList splits "a; b; c" into a StringList, dropping empty items
+func ParseStringList(s string) StringList {
+	var l StringList
+	for _, item := range strings.Split(s, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			l = append(l, item)
+		}
+	}
+	return l
+}
+
+func (l StringList) String() string {
+	return strings.Join(l, "; ")
+}
+
+// Personality is a Big Five personality profile; each trait is "low", "medium" or "high"
+type Personality struct {
+	Openness          string `json:"openness"`
+	Conscientiousness string `json:"conscientiousness"`
+	Extraversion      string `json:"extraversion"`
+	Agreeableness     string `json:"agreeableness"`
+	Neuroticism       string `json:"neuroticism"`
+}
+
+// Traits returns the trait names and levels in the usual OCEAN order
+func (p Personality) Traits() [][2]string {
+	return [][2]string{
+		{"Openness", p.Openness},
+		{"Conscientiousness", p.Conscientiousness},
+		{"Extraversion", p.Extraversion},
+		{"Agreeableness", p.Agreeableness},
+		{"Neuroticism", p.Neuroticism},
+	}
+}
+
+// String formats the profile as "Openness: high; Conscientiousness: medium; ...",
+// leaving out unset traits
+func (p Personality) String() string {
+	var parts []string
+	for _, t := range p.Traits() {
+		if t[1] != "" {
+			parts = append(parts, t[0]+": "+t[1])
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParsePersonality reads a profile written by Personality.String
+func ParsePersonality(s string) Personality {
+	var p Personality
+	for _, part := range strings.Split(s, ";") {
+		name, level, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		level = strings.TrimSpace(level)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "openness":
+			p.Openness = level
+		case "conscientiousness":
+			p.Conscientiousness = level
+		case "extraversion":
+			p.Extraversion = level
+		case "agreeableness":
+			p.Agreeableness = level
+		case "neuroticism":
+			p.Neuroticism = level
+		}
+	}
+	return p
+}
+
+// NoteField is a "Label: value" line of a persona note that is not a persona
+// field, kept so facilitators' additions survive being parsed and rewritten
+type NoteField struct {
+	Label string `json:"label,omitempty"` // as written, e.g. "⭐ Favourite quote"; empty for a line without one
+	Value string `json:"value"`
+}
+
+// Persona represents a customer, or a member of another panel, for focus group simulation
 type Persona struct {
 	Name        string      `json:"name"`
 	Role        string      `json:"role"`
@@ -53,4 +150,27 @@ type Persona struct {
 	Age         AgeString   `json:"age"`
 	Sex         string      `json:"sex"`
 	Race        string      `json:"race"`
+
+	// Psychographics and buying behaviour
+	PainPoints        StringList  `json:"pain_points,omitempty"`
+	Objections        StringList  `json:"objections,omitempty"`
+	Budget            string      `json:"budget,omitempty"`             // budget or income bracket
+	DecisionAuthority string      `json:"decision_authority,omitempty"` // e.g. "final approver", "influencer"
+	TechSavviness     string      `json:"tech_savviness,omitempty"`
+	Channels          StringList  `json:"channels,omitempty"` // preferred channels for hearing about and buying products
+	BrandAffinities   StringList  `json:"brand_affinities,omitempty"`
+	Personality       Personality `json:"personality"`
+
+	// Coverage, checked against the diversity constraints
+	Sector      string `json:"sector,omitempty"`       // market sector the persona buys for
+	Region      string `json:"region,omitempty"`       // where the persona lives or works
+	CompanySize string `json:"company_size,omitempty"` // e.g. "startup", "enterprise"
+	Market      string `json:"market,omitempty"`       // "B2B" or "B2C"
+	Attitude    string `json:"attitude,omitempty"`     // "sceptic", "neutral" or "enthusiast" about the offering
+
+	// Panel is the panel the persona sits on; empty for a customer
+	Panel Panel `json:"panel,omitempty"`
+
+	// Extra holds lines added to the persona note that are not fields above
+	Extra []NoteField `json:"extra,omitempty"`
 }
@@ -0,0 +1,113 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is matched (via errors.Is) by every *BudgetError
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget holds spending caps. A zero value for any field disables that cap.
+type Budget struct {
+	CanvasTokens int     `json:"canvas_tokens,omitempty"`
+	CanvasCost   float64 `json:"canvas_cost_usd,omitempty"`
+	DailyTokens  int     `json:"daily_tokens,omitempty"`
+	DailyCost    float64 `json:"daily_cost_usd,omitempty"`
+}
+
+// Enabled returns true if any cap is configured
+func (b Budget) Enabled() bool {
+	return b.CanvasTokens > 0 || b.CanvasCost > 0 || b.DailyTokens > 0 || b.DailyCost > 0
+}
+
+// BudgetError describes which cap a request would break
+type BudgetError struct {
+	Period   string // "canvas" or "daily"
+	Unit     string // "tokens" or "USD"
+	Limit    float64
+	Used     float64
+	Estimate float64
+}
+
+func (e *BudgetError) Error() string {
+	if e.Unit == "USD" {
+		return fmt.Sprintf("%s budget exceeded: used $%.4f of $%.4f, request needs ~$%.4f", e.Period, e.Used, e.Limit, e.Estimate)
+	}
+	return fmt.Sprintf("%s budget exceeded: used %.0f of %.0f tokens, request needs ~%.0f", e.Period, e.Used, e.Limit, e.Estimate)
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) true for any BudgetError
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// SetBudget replaces the spending caps enforced by CheckBudget
+func (t *Tracker) SetBudget(b Budget) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.budget = b
+}
+
+// Budget returns the spending caps currently enforced
+func (t *Tracker) Budget() Budget {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.budget
+}
+
+// Today returns the usage recorded since local midnight
+func (t *Tracker) Today() Totals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollDayLocked(time.Now())
+	return t.dayTotals
+}
+
+// rollDayLocked resets the daily totals when the date changes. Caller must hold t.mu.
+func (t *Tracker) rollDayLocked(now time.Time) {
+	day := now.Format("2006-01-02")
+	if t.day != day {
+		t.day = day
+		t.dayTotals = Totals{}
+	}
+}
+
+// CheckBudget returns a *BudgetError if a call with the estimated usage, made for
+// the given scope, would push the canvas or daily totals over their caps.
+func (t *Tracker) CheckBudget(scope Scope, model string, promptTokens, outputTokens, images int) error {
+	estTokens := float64(promptTokens + outputTokens)
+	estCost := t.prices.Cost(model, promptTokens, outputTokens, images)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.budget
+	if !b.Enabled() {
+		return nil
+	}
+
+	var canvas Totals
+	if cu, ok := t.canvases[scope.Canvas]; ok {
+		canvas = cu.Totals
+	}
+	t.rollDayLocked(time.Now())
+	day := t.dayTotals
+
+	switch {
+	case b.CanvasTokens > 0 && float64(canvas.Tokens())+estTokens > float64(b.CanvasTokens):
+		return &BudgetError{Period: "canvas", Unit: "tokens", Limit: float64(b.CanvasTokens), Used: float64(canvas.Tokens()), Estimate: estTokens}
+	case b.CanvasCost > 0 && canvas.Cost+estCost > b.CanvasCost:
+		return &BudgetError{Period: "canvas", Unit: "USD", Limit: b.CanvasCost, Used: canvas.Cost, Estimate: estCost}
+	case b.DailyTokens > 0 && float64(day.Tokens())+estTokens > float64(b.DailyTokens):
+		return &BudgetError{Period: "daily", Unit: "tokens", Limit: float64(b.DailyTokens), Used: float64(day.Tokens()), Estimate: estTokens}
+	case b.DailyCost > 0 && day.Cost+estCost > b.DailyCost:
+		return &BudgetError{Period: "daily", Unit: "USD", Limit: b.DailyCost, Used: day.Cost, Estimate: estCost}
+	}
+	return nil
+}
+
+// EstimateTokens gives a rough token count for text (about 4 characters per token)
+func EstimateTokens(text string) int {
+	return len(text)/4 + 1
+}
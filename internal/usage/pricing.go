@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Price holds the unit prices for a single model, in USD
+type Price struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+	PerImage         float64 `json:"per_image"`
+}
+
+// PriceTable maps a model name (or model name prefix) to its prices
+type PriceTable map[string]Price
+
+// DefaultPriceTable returns list prices for the models this project uses.
+// Override with a JSON file referenced by PRICE_TABLE_FILE when prices change.
+func DefaultPriceTable() PriceTable {
+	return PriceTable{
+		"gemini-2.5-flash-lite": {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+		"gemini-2.5-flash":      {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+		"gemini-2.5-pro":        {InputPerMillion: 1.25, OutputPerMillion: 10.00},
+		"models/imagen-3.0":     {PerImage: 0.03},
+		"dall-e-2":              {PerImage: 0.018},
+		"dall-e-3":              {PerImage: 0.04},
+		"gemini-2.0-flash":      {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+		"gemini-2.0-flash-lite": {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+		"gemini-1.5-flash":      {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+		"gemini-1.5-pro":        {InputPerMillion: 1.25, OutputPerMillion: 5.00},
+	}
+}
+
+// LoadPriceTable reads a JSON price table from path and merges it over the defaults
+func LoadPriceTable(path string) (PriceTable, error) {
+	table := DefaultPriceTable()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return table, fmt.Errorf("failed to read price table: %w", err)
+	}
+	var overrides PriceTable
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return table, fmt.Errorf("failed to parse price table %s: %w", path, err)
+	}
+	for model, price := range overrides {
+		table[model] = price
+	}
+	return table, nil
+}
+
+// PriceTableFromFile loads the price table at path over the defaults, falling back
+// to the defaults alone if path is empty or unreadable
+func PriceTableFromFile(path string) PriceTable {
+	if path == "" {
+		return DefaultPriceTable()
+	}
+	table, err := LoadPriceTable(path)
+	if err != nil {
+		log.Printf("[usage] %v; using default prices", err)
+		return DefaultPriceTable()
+	}
+	log.Printf("[usage] Loaded price table from %s (%d models)", path, len(table))
+	return table
+}
+
+// Lookup returns the price for a model. Exact names win, otherwise the longest
+// matching prefix is used so that versioned model names share a base price.
+func (pt PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.TrimSpace(model)
+	if p, ok := pt[model]; ok {
+		return p, true
+	}
+	bestLen := 0
+	var best Price
+	for name, p := range pt {
+		if strings.HasPrefix(model, name) && len(name) > bestLen {
+			best = p
+			bestLen = len(name)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Cost calculates the cost in USD of a single call
+func (pt PriceTable) Cost(model string, promptTokens, outputTokens, images int) float64 {
+	p, ok := pt.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return float64(promptTokens)/1e6*p.InputPerMillion +
+		float64(outputTokens)/1e6*p.OutputPerMillion +
+		float64(images)*p.PerImage
+}
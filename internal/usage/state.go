@@ -0,0 +1,71 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// DefaultStateFile is where the budget totals are saved unless configured
+const DefaultStateFile = ".state/usage.json"
+
+// savedState is the part of the aggregates the budget caps are checked against,
+// saved so a restart does not reset them
+type savedState struct {
+	Day      string                  `json:"day"`
+	Today    Totals                  `json:"today"`
+	Canvases map[string]*CanvasUsage `json:"canvases"`
+}
+
+// SetStateFile loads the canvas and daily totals saved at path, if it exists,
+// and saves them there after every recorded call from now on
+func (t *Tracker) SetStateFile(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stateFile = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read usage state: %w", err)
+	}
+	var s savedState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to parse usage state %s: %w", path, err)
+	}
+	t.day, t.dayTotals = s.Day, s.Today
+	for id, cu := range s.Canvases {
+		if cu.Personas == nil {
+			cu.Personas = make(map[string]Totals)
+		}
+		t.canvases[id] = cu
+	}
+	return nil
+}
+
+// saveStateLocked writes the totals to the state file, if one is set. Caller must hold t.mu.
+func (t *Tracker) saveStateLocked() {
+	if t.stateFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(savedState{Day: t.day, Today: t.dayTotals, Canvases: t.canvases}, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(t.stateFile), 0o755)
+	}
+	tmp := t.stateFile + ".tmp"
+	if err == nil {
+		err = os.WriteFile(tmp, data, 0o644)
+	}
+	if err == nil {
+		if err = os.Rename(tmp, t.stateFile); err != nil {
+			os.Remove(tmp)
+		}
+	}
+	if err != nil {
+		log.Printf("[usage] Failed to save usage state to %s: %v", t.stateFile, err)
+	}
+}
@@ -0,0 +1,39 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestBudgetTotalsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "usage.json")
+	budget := Budget{CanvasTokens: 1000, DailyTokens: 1500}
+
+	before := NewTracker(DefaultPriceTable())
+	if err := before.SetStateFile(path); err != nil {
+		t.Fatalf("SetStateFile on a missing file: %v", err)
+	}
+	ctx := WithScope(context.Background(), Scope{Canvas: "c1", Question: "q1", Persona: "Ann"})
+	before.Record(ctx, "gemini-2.5-flash", "chat", 600, 300, 0)
+
+	after := NewTracker(DefaultPriceTable())
+	if err := after.SetStateFile(path); err != nil {
+		t.Fatalf("SetStateFile: %v", err)
+	}
+	after.SetBudget(budget)
+	if got := after.Today().Tokens(); got != 900 {
+		t.Errorf("Today().Tokens() = %d after restart, want 900", got)
+	}
+	cu, ok := after.Canvas("c1")
+	if !ok || cu.Totals.Tokens() != 900 || cu.Personas["Ann"].Tokens() != 900 {
+		t.Errorf("Canvas(c1) = %+v, %v after restart, want 900 tokens for Ann", cu, ok)
+	}
+	if err := after.CheckBudget(Scope{Canvas: "c1"}, "gemini-2.5-flash", 50, 25, 0); err != nil {
+		t.Errorf("CheckBudget under the cap: %v", err)
+	}
+	if err := after.CheckBudget(Scope{Canvas: "c1"}, "gemini-2.5-flash", 100, 200, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("CheckBudget over the canvas cap = %v, want ErrBudgetExceeded", err)
+	}
+}
@@ -0,0 +1,292 @@
+// Package usage captures token and image usage from LLM calls and prices it
+// per question, persona and canvas.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scope identifies who an LLM call is made on behalf of
+type Scope struct {
+	Canvas   string `json:"canvas"`
+	Question string `json:"question,omitempty"`
+	Persona  string `json:"persona,omitempty"`
+}
+
+type scopeKey struct{}
+
+// WithScope attaches a usage scope to the context
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// WithPersona returns a context whose scope is the current scope with the persona set
+func WithPersona(ctx context.Context, persona string) context.Context {
+	s := ScopeFrom(ctx)
+	s.Persona = persona
+	return WithScope(ctx, s)
+}
+
+// ScopeFrom returns the usage scope attached to the context, if any
+func ScopeFrom(ctx context.Context) Scope {
+	if ctx == nil {
+		return Scope{}
+	}
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// Record is a single priced LLM call
+type Record struct {
+	Time         time.Time `json:"time"`
+	Scope        Scope     `json:"scope"`
+	Model        string    `json:"model"`
+	Operation    string    `json:"operation"`
+	PromptTokens int       `json:"prompt_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Images       int       `json:"images"`
+	Cost         float64   `json:"cost_usd"`
+}
+
+// Totals aggregates usage over a number of calls
+type Totals struct {
+	Calls        int     `json:"calls"`
+	PromptTokens int     `json:"prompt_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Images       int     `json:"images"`
+	Cost         float64 `json:"cost_usd"`
+}
+
+// Tokens returns the total number of prompt and output tokens
+func (t Totals) Tokens() int {
+	return t.PromptTokens + t.OutputTokens
+}
+
+func (t *Totals) add(r Record) {
+	t.Calls++
+	t.PromptTokens += r.PromptTokens
+	t.OutputTokens += r.OutputTokens
+	t.Images += r.Images
+	t.Cost += r.Cost
+}
+
+// QuestionUsage aggregates usage for one question, broken down by persona
+type QuestionUsage struct {
+	Canvas   string            `json:"canvas"`
+	Question string            `json:"question"`
+	Totals   Totals            `json:"totals"`
+	Personas map[string]Totals `json:"personas"`
+}
+
+// CanvasUsage aggregates usage for one canvas, broken down by persona
+type CanvasUsage struct {
+	Canvas   string            `json:"canvas"`
+	Totals   Totals            `json:"totals"`
+	Personas map[string]Totals `json:"personas"`
+}
+
+// Snapshot is a point-in-time copy of all aggregated usage
+type Snapshot struct {
+	Budget    Budget          `json:"budget"`
+	Today     Totals          `json:"today"`
+	Canvases  []CanvasUsage   `json:"canvases"`
+	Questions []QuestionUsage `json:"questions"`
+}
+
+// Tracker prices and aggregates LLM usage
+type Tracker struct {
+	prices PriceTable
+
+	// State - owned by this organism
+	mu        sync.Mutex
+	budget    Budget
+	canvases  map[string]*CanvasUsage
+	questions map[string]*QuestionUsage
+	day       string
+	dayTotals Totals
+	stateFile string // where the totals are saved; empty to keep them in memory only
+}
+
+// NewTracker creates a Tracker using the given price table
+func NewTracker(prices PriceTable) *Tracker {
+	return &Tracker{
+		prices:    prices,
+		canvases:  make(map[string]*CanvasUsage),
+		questions: make(map[string]*QuestionUsage),
+	}
+}
+
+// Prices returns the price table used by the tracker
+func (t *Tracker) Prices() PriceTable {
+	return t.prices
+}
+
+// Record prices a call made under the scope in ctx and adds it to the aggregates
+func (t *Tracker) Record(ctx context.Context, model, operation string, promptTokens, outputTokens, images int) Record {
+	r := Record{
+		Time:         time.Now(),
+		Scope:        ScopeFrom(ctx),
+		Model:        model,
+		Operation:    operation,
+		PromptTokens: promptTokens,
+		OutputTokens: outputTokens,
+		Images:       images,
+	}
+	if _, ok := t.prices.Lookup(model); !ok {
+		log.Printf("[usage] No price configured for model %q; recording cost as 0", model)
+	}
+	r.Cost = t.prices.Cost(model, promptTokens, outputTokens, images)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollDayLocked(r.Time)
+	t.dayTotals.add(r)
+
+	cu, ok := t.canvases[r.Scope.Canvas]
+	if !ok {
+		cu = &CanvasUsage{Canvas: r.Scope.Canvas, Personas: make(map[string]Totals)}
+		t.canvases[r.Scope.Canvas] = cu
+	}
+	cu.Totals.add(r)
+	if r.Scope.Persona != "" {
+		pt := cu.Personas[r.Scope.Persona]
+		pt.add(r)
+		cu.Personas[r.Scope.Persona] = pt
+	}
+
+	if r.Scope.Question != "" {
+		qu, ok := t.questions[r.Scope.Question]
+		if !ok {
+			qu = &QuestionUsage{Canvas: r.Scope.Canvas, Question: r.Scope.Question, Personas: make(map[string]Totals)}
+			t.questions[r.Scope.Question] = qu
+		}
+		qu.Totals.add(r)
+		if r.Scope.Persona != "" {
+			pt := qu.Personas[r.Scope.Persona]
+			pt.add(r)
+			qu.Personas[r.Scope.Persona] = pt
+		}
+	}
+
+	t.saveStateLocked()
+
+	log.Printf("[usage] %s model=%s canvas=%s question=%s persona=%s prompt=%d output=%d images=%d cost=$%.5f",
+		operation, model, r.Scope.Canvas, r.Scope.Question, r.Scope.Persona, promptTokens, outputTokens, images, r.Cost)
+	return r
+}
+
+// Question returns the aggregated usage for a question
+func (t *Tracker) Question(questionID string) (QuestionUsage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	qu, ok := t.questions[questionID]
+	if !ok {
+		return QuestionUsage{}, false
+	}
+	return copyQuestion(qu), true
+}
+
+// Canvas returns the aggregated usage for a canvas
+func (t *Tracker) Canvas(canvasID string) (CanvasUsage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cu, ok := t.canvases[canvasID]
+	if !ok {
+		return CanvasUsage{}, false
+	}
+	return copyCanvas(cu), true
+}
+
+// Snapshot returns a copy of all aggregated usage, sorted by canvas and question
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollDayLocked(time.Now())
+	snap := Snapshot{
+		Budget:    t.budget,
+		Today:     t.dayTotals,
+		Canvases:  make([]CanvasUsage, 0, len(t.canvases)),
+		Questions: make([]QuestionUsage, 0, len(t.questions)),
+	}
+	for _, cu := range t.canvases {
+		snap.Canvases = append(snap.Canvases, copyCanvas(cu))
+	}
+	for _, qu := range t.questions {
+		snap.Questions = append(snap.Questions, copyQuestion(qu))
+	}
+	sort.Slice(snap.Canvases, func(i, j int) bool { return snap.Canvases[i].Canvas < snap.Canvases[j].Canvas })
+	sort.Slice(snap.Questions, func(i, j int) bool {
+		if snap.Questions[i].Canvas != snap.Questions[j].Canvas {
+			return snap.Questions[i].Canvas < snap.Questions[j].Canvas
+		}
+		return snap.Questions[i].Question < snap.Questions[j].Question
+	})
+	return snap
+}
+
+func copyQuestion(qu *QuestionUsage) QuestionUsage {
+	out := *qu
+	out.Personas = make(map[string]Totals, len(qu.Personas))
+	for k, v := range qu.Personas {
+		out.Personas[k] = v
+	}
+	return out
+}
+
+func copyCanvas(cu *CanvasUsage) CanvasUsage {
+	out := *cu
+	out.Personas = make(map[string]Totals, len(cu.Personas))
+	for k, v := range cu.Personas {
+		out.Personas[k] = v
+	}
+	return out
+}
+
+// FormatCostNote renders the text of the on-canvas "Cost" note for a question
+func FormatCostNote(q QuestionUsage, canvas CanvasUsage) string {
+	text := fmt.Sprintf("This question: $%.4f\nTokens: %d in / %d out\nCalls: %d",
+		q.Totals.Cost, q.Totals.PromptTokens, q.Totals.OutputTokens, q.Totals.Calls)
+	if q.Totals.Images > 0 {
+		text += fmt.Sprintf("\nImages: %d", q.Totals.Images)
+	}
+	names := make([]string, 0, len(q.Personas))
+	for name := range q.Personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		text += "\n"
+		for _, name := range names {
+			text += fmt.Sprintf("\n%s: $%.4f (%d tokens)", name, q.Personas[name].Cost, q.Personas[name].Tokens())
+		}
+	}
+	text += fmt.Sprintf("\n\nCanvas total: $%.4f (%d tokens)", canvas.Totals.Cost, canvas.Totals.Tokens())
+	return text
+}
+
+// --- Global instance shared by all workflows ---
+var (
+	globalTracker     *Tracker
+	globalTrackerOnce sync.Once
+)
+
+// SetGlobalTracker replaces the process-wide Tracker. Call it at startup, before any LLM call.
+func SetGlobalTracker(t *Tracker) {
+	globalTrackerOnce.Do(func() {})
+	globalTracker = t
+}
+
+// GetGlobalTracker returns the process-wide Tracker, with the default prices and
+// no budget caps unless SetGlobalTracker was called
+func GetGlobalTracker() *Tracker {
+	globalTrackerOnce.Do(func() {
+		globalTracker = NewTracker(DefaultPriceTable())
+	})
+	return globalTracker
+}
@@ -0,0 +1,219 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Showmax/go-fqdn"
+	"github.com/jaypaulb/AI-personas/canvusapi"
+)
+
+// Hub serves the question pages of several canvases from one listener.
+// Each canvas is reached at /c/{name}/ and gets its own Remote QR code;
+// with a single canvas, / serves it directly as before.
+type Hub struct {
+	Config  ServerConfig
+	servers []*Server
+	byName  map[string]*Server
+}
+
+// NewHub creates a hub for one server per canvas
+func NewHub(config ServerConfig, servers ...*Server) *Hub {
+	h := &Hub{Config: config, servers: servers, byName: make(map[string]*Server)}
+	for _, s := range servers {
+		h.byName[s.Client.Name] = s
+		if len(servers) > 1 {
+			// Each canvas needs its own QR image file
+			s.Config.QRCodePath = fmt.Sprintf("qr_remote_%s.png", s.Client.Name)
+		}
+	}
+	return h
+}
+
+// NewHubForClients creates a hub with a server for each client
+func NewHubForClients(config ServerConfig, clients []*canvusapi.Client) *Hub {
+	servers := make([]*Server, 0, len(clients))
+	for _, c := range clients {
+		servers = append(servers, NewServerWithConfig(c, config))
+	}
+	return NewHub(config, servers...)
+}
+
+// CanvasURL returns the public URL of the question page for s
+func (h *Hub) CanvasURL(s *Server) string {
+	base := s.GetWebURL()
+	if len(h.servers) == 1 {
+		return base
+	}
+	return strings.TrimRight(base, "/") + "/c/" + s.Client.Name + "/"
+}
+
+// Start registers the routes, starts a QR code watcher per canvas and listens on the configured port
+func (h *Hub) Start() {
+	for _, s := range h.servers {
+		s.startQRCodeWatcher(h.CanvasURL(s))
+	}
+
+	fqdnHost, _ := fqdn.FqdnHostname()
+	log.Printf("[web] Starting web server on :%s (FQDN: %s) for %d canvases", h.Config.Port, fqdnHost, len(h.servers))
+
+	http.HandleFunc("/", h.handleIndex)
+	http.HandleFunc("/c/", h.handleCanvas)
+	http.HandleFunc("/health", h.handleHealth)
+	http.HandleFunc("/api/usage", h.servers[0].handleUsage)
+	http.HandleFunc("/api/cancel", h.handleCancel)
+	http.HandleFunc("/api/library", h.requireAdmin(handleLibrary))
+	http.HandleFunc("/api/library/save", h.requireAdmin(h.forCanvas((*Server).handleLibrarySave)))
+	http.HandleFunc("/api/library/import", h.requireAdmin(h.forCanvas((*Server).handleLibraryImport)))
+	http.HandleFunc("/api/personas/regenerate", h.requireAdmin(h.forCanvas((*Server).handleRegeneratePersona)))
+	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+
+	go func() {
+		log.Printf("[web] Listening on :%s (FQDN: %s)", h.Config.Port, fqdnHost)
+		http.ListenAndServe(":"+h.Config.Port, nil)
+	}()
+}
+
+// handleIndex serves the only canvas at / or, with several canvases, a list of links
+func (h *Hub) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if len(h.servers) == 1 {
+		h.servers[0].handleRoot(w, r)
+		return
+	}
+	if r.URL.Path != "/" || r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><head><meta charset=\"UTF-8\" /><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\" /><title>Ask a Question</title></head><body><h2>Choose a room</h2><ul>")
+	for _, s := range h.servers {
+		name := html.EscapeString(s.Client.Name)
+		fmt.Fprintf(&b, "<li><a href=\"/c/%s/\">%s</a></li>", name, name)
+	}
+	b.WriteString("</ul></body></html>")
+	w.Write([]byte(b.String()))
+}
+
+// handleCanvas routes /c/{name}/ to the named canvas's question page
+func (h *Hub) handleCanvas(w http.ResponseWriter, r *http.Request) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/c/"), "/")
+	s, ok := h.byName[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Unknown canvas"))
+		return
+	}
+	s.handleRoot(w, r)
+}
+
+// handleCancel dispatches /api/cancel to the canvas named by the "canvas" form value
+// (the only canvas if omitted)
+func (h *Hub) handleCancel(w http.ResponseWriter, r *http.Request) {
+	h.forCanvas((*Server).handleCancel)(w, r)
+}
+
+// forCanvas wraps a server handler so it runs for the canvas named by the
+// "canvas" form value, defaulting to the first canvas
+func (h *Hub) forCanvas(handler func(*Server, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := h.servers[0]
+		if name := r.FormValue("canvas"); name != "" {
+			var ok bool
+			if s, ok = h.byName[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("Unknown canvas"))
+				return
+			}
+		}
+		handler(s, w, r)
+	}
+}
+
+// hasAdminToken reports whether r carries adminToken as "Authorization: Bearer <token>"
+func hasAdminToken(r *http.Request, adminToken string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && adminToken != "" && tokensEqual(strings.TrimSpace(token), adminToken)
+}
+
+// tokensEqual compares a token sent by a client with the expected one in constant time
+func tokensEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// requireAdmin wraps an endpoint that changes personas or spends budget so only
+// holders of the admin token reach it, not every visitor of the question page
+func (h *Hub) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Config.AdminToken == "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Admin endpoints are disabled: set WEB_ADMIN_TOKEN"))
+			return
+		}
+		if !hasAdminToken(r, h.Config.AdminToken) {
+			log.Printf("[web] Rejected unauthenticated %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Admin token required"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// handleHealth reports healthy if every canvas is reachable, degraded if only some are
+func (h *Hub) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if len(h.servers) == 1 {
+		h.servers[0].handleHealth(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	canvases := make(map[string]bool, len(h.servers))
+	reachable := 0
+	for _, s := range h.servers {
+		_, err := s.Client.GetWidgets(false)
+		canvases[s.Client.Name] = err == nil
+		if err != nil {
+			log.Printf("[web][health] Canvus API check failed for canvas %s: %v", s.Client.Name, err)
+			continue
+		}
+		reachable++
+	}
+
+	status := HealthStatusHealthy
+	switch {
+	case reachable == 0:
+		status = HealthStatusUnhealthy
+	case reachable < len(h.servers):
+		status = HealthStatusDegraded
+	}
+
+	response := struct {
+		HealthResponse
+		Canvases map[string]bool `json:"canvases"`
+	}{
+		HealthResponse: HealthResponse{Status: status, Uptime: formatUptime(time.Since(startTime)), Version: Version},
+		Canvases:       canvases,
+	}
+	response.Details.CanvusAPI = reachable > 0
+
+	w.Header().Set("Content-Type", "application/json")
+	if status == HealthStatusUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[web][error] Failed to encode health response: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaypaulb/AI-personas/canvusapi"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }
+	tests := []struct {
+		name   string
+		token  string // configured admin token
+		header string // Authorization header sent
+		want   int
+	}{
+		{"disabled without a token", "", "Bearer ", http.StatusForbidden},
+		{"no credentials", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"token without the bearer scheme", "s3cret", "s3cret", http.StatusUnauthorized},
+		{"admin token", "s3cret", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Hub{Config: ServerConfig{AdminToken: tt.token}}
+			r := httptest.NewRequest(http.MethodDelete, "/api/library?id=x", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.requireAdmin(ok)(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelNeedsTheQuestionsToken(t *testing.T) {
+	s := NewServerWithConfig(&canvusapi.Client{Name: "lobby", CanvasID: "c1"}, ServerConfig{AdminToken: "s3cret"})
+	s.cancelTokens.Store("q1", "page-token")
+	tests := []struct {
+		name   string
+		form   url.Values
+		header string
+		want   int
+	}{
+		{"no token", url.Values{"id": {"q1"}}, "", http.StatusForbidden},
+		{"another question's token", url.Values{"id": {"q2"}, "token": {"page-token"}}, "", http.StatusForbidden},
+		{"wrong token", url.Values{"id": {"q1"}, "token": {"guess"}}, "", http.StatusForbidden},
+		// Allowed, but nothing is running for the question
+		{"the page's token", url.Values{"id": {"q1"}, "token": {"page-token"}}, "", http.StatusConflict},
+		{"admin token", url.Values{"id": {"q2"}}, "Bearer s3cret", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/cancel", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			s.handleCancel(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d (%s), want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
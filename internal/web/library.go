@@ -0,0 +1,140 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jaypaulb/AI-personas/internal/gemini"
+	"github.com/jaypaulb/AI-personas/internal/library"
+)
+
+// apiPersonasKey identifies persona imports started from the API, in place of an Import_Personas note ID
+const apiPersonasKey = "api-personas"
+
+// maxLibraryUpload caps the size of a persona JSON upload
+const maxLibraryUpload = 1 << 20
+
+// formList splits a comma separated form value, dropping empty items
+func formList(r *http.Request, key string) []string {
+	var items []string
+	for _, item := range strings.Split(r.FormValue(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// writeJSON encodes response as the JSON body of w
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[web][error] Failed to encode response: %v", err)
+	}
+}
+
+// handleLibrary serves the persona library: GET lists the personas with any of
+// the "tags" (all without), which is also the JSON export; POST loads personas
+// from a JSON body, adding "tags"; DELETE removes the persona "id"
+func handleLibrary(w http.ResponseWriter, r *http.Request) {
+	lib := library.GetGlobalLibrary()
+	switch r.Method {
+	case http.MethodGet:
+		entries := lib.List(formList(r, "tags"))
+		if entries == nil {
+			entries = []library.Entry{}
+		}
+		writeJSON(w, entries)
+
+	case http.MethodPost:
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxLibraryUpload))
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte("Failed to read body"))
+			return
+		}
+		entries, err := library.Parse(data)
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		saved, err := lib.Import(entries, formList(r, "tags"), r.FormValue("source"))
+		if err != nil {
+			log.Printf("[web][error] Failed to load personas into the library: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		log.Printf("[web] Loaded %d persona(s) into the library", len(saved))
+		writeJSON(w, saved)
+
+	case http.MethodDelete:
+		id := strings.TrimSpace(r.FormValue("id"))
+		if id == "" {
+			w.WriteHeader(400)
+			w.Write([]byte("Persona ID required"))
+			return
+		}
+		if err := lib.Delete(id); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.Write([]byte("Persona deleted"))
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLibrarySave handles POST /api/library/save, saving the canvas's personas
+// named by "persona" (slot numbers, names or "all") to the library with "tags"
+func (s *Server) handleLibrarySave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	refs := formList(r, "persona")
+	if len(refs) == 0 {
+		w.WriteHeader(400)
+		w.Write([]byte("Persona required"))
+		return
+	}
+	saved, err := gemini.SavePersonasToLibrary(s.Client, refs, formList(r, "tags"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	writeJSON(w, saved)
+}
+
+// handleLibraryImport handles POST /api/library/import, placing the library
+// personas given by "id", or those with any of "tags", in the canvas's free
+// persona slots
+func (s *Server) handleLibraryImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	entries, err := library.GetGlobalLibrary().Select(formList(r, "tags"), formList(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	// Headshots are generated after the response, so they must outlive the request
+	placed, err := gemini.ImportPersonasFromLibrary(context.Background(), apiPersonasKey, s.Client, entries)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	log.Printf("[web] Imported %d persona(s) into canvas %s", len(placed), s.Client.Name)
+	writeJSON(w, placed)
+}
@@ -0,0 +1,42 @@
+package web
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/gemini"
+	"github.com/jaypaulb/AI-personas/internal/types"
+)
+
+// handleRegeneratePersona handles POST /api/personas/regenerate, replacing the
+// canvas's persona "persona" (1-4) of panel "panel" (customer if omitted) with a
+// new one. The response is the new persona; its headshot follows shortly after.
+func (s *Server) handleRegeneratePersona(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	slot, ok := atom.PersonaSlot(r.FormValue("persona"))
+	if !ok {
+		w.WriteHeader(400)
+		w.Write([]byte("Persona number (1-4) required"))
+		return
+	}
+	panel := types.PanelCustomer
+	if name := r.FormValue("panel"); name != "" {
+		if panel, ok = atom.ParsePanel(name); !ok {
+			w.WriteHeader(400)
+			w.Write([]byte("Panel must be customer, internal, partner or investor"))
+			return
+		}
+	}
+	_, persona, err := gemini.RegeneratePersonaSlot(r.Context(), s.Client, panel, slot)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	log.Printf("[web] Regenerated %s persona %d on canvas %s: %s", panel, slot+1, s.Client.Name, persona.Name)
+	writeJSON(w, persona)
+}
@@ -3,17 +3,24 @@ package web
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Showmax/go-fqdn"
 	"github.com/jaypaulb/AI-personas/canvusapi"
+	"github.com/jaypaulb/AI-personas/internal/atom"
+	"github.com/jaypaulb/AI-personas/internal/gemini"
+	"github.com/jaypaulb/AI-personas/internal/usage"
 	"github.com/skip2/go-qrcode"
 )
 
@@ -51,22 +58,14 @@ type ServerConfig struct {
 	Port         string
 	PublicWebURL string
 	QRCodePath   string
+	AdminToken   string // required by the admin endpoints, which are disabled if empty
 }
 
-// DefaultServerConfig returns configuration from environment
+// DefaultServerConfig returns the default configuration
 func DefaultServerConfig() ServerConfig {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = os.Getenv("WEB_PORT")
-	}
-	if port == "" {
-		port = "8080"
-	}
-
 	return ServerConfig{
-		Port:         port,
-		PublicWebURL: os.Getenv("PUBLIC_WEB_URL"),
-		QRCodePath:   "qr_remote.png",
+		Port:       "8080",
+		QRCodePath: "qr_remote.png",
 	}
 }
 
@@ -74,6 +73,9 @@ func DefaultServerConfig() ServerConfig {
 type Server struct {
 	Client *canvusapi.Client
 	Config ServerConfig
+
+	// State - owned by this organism
+	cancelTokens sync.Map // question note ID -> token given to the page that submitted it
 }
 
 // NewServer creates a new web server instance
@@ -102,22 +104,9 @@ func (s *Server) GetWebURL() string {
 	return "http://" + fqdnHost + ":" + s.Config.Port + "/"
 }
 
-// Start starts the web server and QR code watcher
+// Start starts the web server and QR code watcher for this server's canvas alone
 func (s *Server) Start() {
-	webURL := s.GetWebURL()
-	s.startQRCodeWatcher(webURL)
-
-	fqdnHost, _ := fqdn.FqdnHostname()
-	log.Printf("[web] Starting web server on :%s (FQDN: %s)", s.Config.Port, fqdnHost)
-
-	http.HandleFunc("/", s.handleRoot)
-	http.HandleFunc("/health", s.handleHealth)
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
-
-	go func() {
-		log.Printf("[web] Listening on :%s (FQDN: %s)", s.Config.Port, fqdnHost)
-		http.ListenAndServe(":"+s.Config.Port, nil)
-	}()
+	NewHub(s.Config, s).Start()
 }
 
 // handleHealth handles the /health endpoint for service health checks
@@ -162,6 +151,85 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleUsage handles the /api/usage endpoint reporting token usage and cost.
+// Without parameters it returns all canvases and questions; ?question=ID or
+// ?canvas=ID narrows the response to a single question or canvas.
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	tracker := usage.GetGlobalTracker()
+	var response interface{}
+	if questionID := r.URL.Query().Get("question"); questionID != "" {
+		qUsage, ok := tracker.Question(questionID)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("No usage recorded for question"))
+			return
+		}
+		response = qUsage
+	} else if canvasID := r.URL.Query().Get("canvas"); canvasID != "" {
+		cUsage, ok := tracker.Canvas(canvasID)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("No usage recorded for canvas"))
+			return
+		}
+		response = cUsage
+	} else {
+		response = tracker.Snapshot()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[web][error] Failed to encode usage response: %v", err)
+	}
+}
+
+// handleCancel handles POST /api/cancel, cancelling the running workflow of the
+// question note given by the "id" form value. Only the page that submitted the
+// question, which sends back its "token", or a holder of the admin token may cancel it.
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte("Invalid form"))
+		return
+	}
+	noteID := strings.TrimSpace(r.FormValue("id"))
+	if noteID == "" {
+		w.WriteHeader(400)
+		w.Write([]byte("Question ID required"))
+		return
+	}
+	token, ok := s.cancelTokens.Load(noteID)
+	if !(ok && tokensEqual(r.FormValue("token"), token.(string))) && !hasAdminToken(r, s.Config.AdminToken) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Not allowed to cancel this question"))
+		return
+	}
+	canvasID, active := gemini.QuestionCanvas(noteID)
+	if !active {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("Question is not being answered"))
+		return
+	}
+	if canvasID != s.Client.CanvasID {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Question not found on this canvas"))
+		return
+	}
+	log.Printf("[web] Cancel requested for question %s", noteID)
+	s.cancelTokens.Delete(noteID)
+	go gemini.CancelQuestion(s.Client, noteID, true)
+	w.Write([]byte("Question cancelled"))
+}
+
 // formatUptime formats a duration into a human-readable string
 func formatUptime(d time.Duration) string {
 	days := int(d.Hours()) / 24
@@ -220,18 +288,56 @@ func (s *Server) handleQuestionSubmission(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Ensure the question ends with a '?'
+	noteID, err := s.SubmitQuestion(question)
+	if err != nil {
+		if errors.Is(err, ErrAnchorFull) {
+			w.WriteHeader(409)
+		} else {
+			w.WriteHeader(500)
+		}
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	// The page keeps the note ID and a token proving it asked the question, so
+	// the question can be cancelled via /api/cancel
+	if noteID != "" {
+		w.Header().Set("X-Question-ID", noteID)
+		if token, err := newCancelToken(); err != nil {
+			log.Printf("[web][error] Failed to create cancel token for question %s: %v", noteID, err)
+		} else {
+			s.cancelTokens.Store(noteID, token)
+			w.Header().Set("X-Cancel-Token", token)
+		}
+	}
+	w.Write([]byte("Question submitted!"))
+}
+
+// newCancelToken returns a random token for cancelling a submitted question
+func newCancelToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ErrAnchorFull is returned when the Remote anchor has no free segment for a new question
+var ErrAnchorFull = errors.New("Anchor is full: no free segments available")
+
+// SubmitQuestion places a New_AI_Question note in a free segment of the Remote
+// anchor, where the event monitor picks it up, and returns the note's ID
+func (s *Server) SubmitQuestion(question string) (string, error) {
+	// Ensure the question ends with a '?' (or the full-width '？')
 	question = strings.TrimSpace(question)
-	if !strings.HasSuffix(question, "?") {
+	if !atom.EndsWithQuestionMark(question) {
 		question = question + "?"
 	}
 
 	// Find the Remote anchor zone
 	widgets, err := s.Client.GetWidgets(false)
 	if err != nil {
-		w.WriteHeader(500)
-		w.Write([]byte("Failed to fetch widgets"))
-		return
+		return "", fmt.Errorf("Failed to fetch widgets: %w", err)
 	}
 
 	var remoteAnchor map[string]interface{}
@@ -245,9 +351,7 @@ func (s *Server) handleQuestionSubmission(w http.ResponseWriter, r *http.Request
 	}
 
 	if remoteAnchor == nil {
-		w.WriteHeader(500)
-		w.Write([]byte("Remote anchor not found"))
-		return
+		return "", errors.New("Remote anchor not found")
 	}
 
 	// Calculate note position
@@ -260,9 +364,7 @@ func (s *Server) handleQuestionSubmission(w http.ResponseWriter, r *http.Request
 
 	noteX, noteY, noteW, noteH, scale, err := s.findFreeSegment(widgets, ax, ay, aw, ah)
 	if err != nil {
-		w.WriteHeader(409)
-		w.Write([]byte(err.Error()))
-		return
+		return "", err
 	}
 
 	noteMeta := map[string]interface{}{
@@ -274,14 +376,12 @@ func (s *Server) handleQuestionSubmission(w http.ResponseWriter, r *http.Request
 		"background_color": "#FFFFFFFF",
 	}
 
-	_, err = s.Client.CreateNote(noteMeta)
+	note, err := s.Client.CreateNote(noteMeta)
 	if err != nil {
-		w.WriteHeader(500)
-		w.Write([]byte("Failed to create note: " + err.Error()))
-		return
+		return "", fmt.Errorf("Failed to create note: %w", err)
 	}
-
-	w.Write([]byte("Question submitted!"))
+	noteID, _ := note["id"].(string)
+	return noteID, nil
 }
 
 // findFreeSegment finds a free segment in the Remote anchor grid
@@ -332,7 +432,7 @@ func (s *Server) findFreeSegment(widgets []map[string]interface{}, ax, ay, aw, a
 	}
 
 	if !segmentFound {
-		return 0, 0, 0, 0, 0, fmt.Errorf("Anchor is full: no free segments available")
+		return 0, 0, 0, 0, 0, ErrAnchorFull
 	}
 
 	// Center of the segment